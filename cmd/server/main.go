@@ -13,6 +13,7 @@ import (
 	"github.com/BohdanBoriak/boilerplate-go-back/config/container"
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/database"
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/scheduler"
 )
 
 func main() {
@@ -48,6 +49,16 @@ func main() {
 
 	cont := container.New(conf)
 
+	// Background jobs
+	go scheduler.Every(ctx, conf.SessionCleanup, "expired sessions cleanup", cont.AuthService.DeleteExpiredSessions)
+	go scheduler.Every(ctx, conf.SessionCleanup, "expired password resets cleanup", cont.PasswordResetService.DeleteExpired)
+	go scheduler.Every(ctx, conf.SessionCleanup, "expired magic links cleanup", cont.MagicLinkService.DeleteExpired)
+	go scheduler.Every(ctx, conf.SessionCleanup, "expired oidc logins cleanup", cont.OidcService.DeleteExpired)
+	go scheduler.Every(ctx, conf.SessionCleanup, "stale login attempts cleanup", cont.LoginThrottleService.DeleteStale)
+	go scheduler.Every(ctx, conf.SessionCleanup, "stale rate limit buckets cleanup", cont.RateLimitStore.DeleteStale)
+	go scheduler.Every(ctx, conf.SessionCleanup, "expired security events cleanup", cont.SecurityEventService.DeleteExpired)
+	go scheduler.Every(ctx, conf.SessionCleanup, "deleted accounts anonymization", cont.AccountDeletionService.AnonymizeExpired)
+
 	// HTTP Server
 	err = http.Server(
 		ctx,
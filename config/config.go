@@ -3,36 +3,164 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 )
 
+// MinSecretLength is 32 bytes, the size of the HMAC-SHA256 output
+const MinSecretLength = 32
+
 type Configuration struct {
-	DatabaseName        string
-	DatabaseHost        string
-	DatabaseUser        string
-	DatabasePassword    string
-	MigrateToVersion    string
-	MigrationLocation   string
-	FileStorageLocation string
-	JwtSecret           string
-	JwtTTL              time.Duration
+	DatabaseName              string
+	DatabaseHost              string
+	DatabaseUser              string
+	DatabasePassword          string
+	MigrateToVersion          string
+	MigrationLocation         string
+	FileStorageLocation       string
+	JwtSecret                 string
+	JwtSigningKey             string
+	JwtSigningKeyId           string
+	JwtVerificationKeys       []string
+	JwtTTL                    time.Duration
+	PasswordHasher            string
+	Argon2Memory              int
+	Argon2Iterations          int
+	Argon2Parallelism         int
+	BcryptCost                int
+	AuthProviders             []string
+	LdapUrl                   string
+	LdapStartTls              bool
+	LdapBindDn                string
+	LdapBindPassword          string
+	LdapBaseDn                string
+	LdapUserFilter            string
+	LdapEmailAttr             string
+	LdapFirstNameAttr         string
+	LdapSecondNameAttr        string
+	LdapGroupsAttr            string
+	LdapAdminGroups           []string
+	RefreshTokenTTL           time.Duration
+	SessionCleanup            time.Duration
+	ImpersonationTTL          time.Duration
+	SecurityEventRetention    time.Duration
+	AccountDeletionGrace      time.Duration
+	AuthCookies               bool
+	CookieDomain              string
+	CookieSecure              bool
+	CookieSameSite            string
+	CorsAllowedOrigins        []string
+	TrustedProxies            []string
+	AppName                   string
+	AdminEmails               []string
+	AppUrl                    string
+	FrontendUrl               string
+	PasswordResetTTL          time.Duration
+	MagicLinkTTL              time.Duration
+	MagicLinkMaxPerHour       int
+	OidcIssuer                string
+	OidcClientId              string
+	OidcClientSecret          string
+	OidcRedirectUrl           string
+	OidcScopes                []string
+	ScimToken                 string
+	MailDriver                string
+	MailFrom                  string
+	MailLogLocation           string
+	SmtpHost                  string
+	SmtpPort                  int
+	SmtpUser                  string
+	SmtpPassword              string
+	AppKey                    string
+	EmailVerificationTTL      time.Duration
+	EmailVerificationRequired bool
+	LoginFreeAttempts         int
+	LoginLockoutAttempts      int
+	LoginLockoutDuration      time.Duration
+	LoginAttemptsWindow       time.Duration
+	RateLimitStore            string
+	RateLimitAuth             string
+	RateLimitTaskReads        string
+	RateLimitTaskWrites       string
 }
 
 func GetConfiguration() Configuration {
 	return Configuration{
-		DatabaseName:        getOrDefault("DB_NAME", "roman_db"),
-		DatabaseHost:        getOrDefault("DB_HOST", "127.0.0.1:5432"),
-		DatabaseUser:        getOrDefault("DB_USER", "postgres"),
-		DatabasePassword:    getOrDefault("DB_PASSWORD", "1234"),
-		MigrateToVersion:    getOrDefault("MIGRATE", "latest"),
-		MigrationLocation:   getOrDefault("MIGRATION_LOCATION", "D:/git/todo-go-back-25/internal/infra/database/migrations"),
-		FileStorageLocation: getOrDefault("FILES_LOCATION", "file_storage"),
-		JwtSecret:           getOrDefault("JWT_SECRET", "1234567890"),
-		JwtTTL:              72 * time.Hour,
+		DatabaseName:              getOrDefault("DB_NAME", "roman_db"),
+		DatabaseHost:              getOrDefault("DB_HOST", "127.0.0.1:5432"),
+		DatabaseUser:              getOrDefault("DB_USER", "postgres"),
+		DatabasePassword:          getOrDefault("DB_PASSWORD", "1234"),
+		MigrateToVersion:          getOrDefault("MIGRATE", "latest"),
+		MigrationLocation:         getOrDefault("MIGRATION_LOCATION", "D:/git/todo-go-back-25/internal/infra/database/migrations"),
+		FileStorageLocation:       getOrDefault("FILES_LOCATION", "file_storage"),
+		JwtSecret:                 getOrDefault("JWT_SECRET", ""),
+		JwtSigningKey:             getOrDefault("JWT_SIGNING_KEY", ""),
+		JwtSigningKeyId:           getOrDefault("JWT_SIGNING_KEY_ID", ""),
+		JwtVerificationKeys:       getListOrDefault("JWT_VERIFICATION_KEYS", nil),
+		JwtTTL:                    getDurationOrDefault("JWT_TTL", 15*time.Minute),
+		PasswordHasher:            getOrDefault("PASSWORD_HASHER", "argon2id"),
+		Argon2Memory:              getIntOrDefault("ARGON2_MEMORY", 64*1024),
+		Argon2Iterations:          getIntOrDefault("ARGON2_ITERATIONS", 3),
+		Argon2Parallelism:         getIntOrDefault("ARGON2_PARALLELISM", 4),
+		BcryptCost:                getIntOrDefault("BCRYPT_COST", 10),
+		AuthProviders:             getListOrDefault("AUTH_PROVIDERS", []string{"local"}),
+		LdapUrl:                   getOrDefault("LDAP_URL", ""),
+		LdapStartTls:              getBoolOrDefault("LDAP_START_TLS", false),
+		LdapBindDn:                getOrDefault("LDAP_BIND_DN", ""),
+		LdapBindPassword:          getOrDefault("LDAP_BIND_PASSWORD", ""),
+		LdapBaseDn:                getOrDefault("LDAP_BASE_DN", ""),
+		LdapUserFilter:            getOrDefault("LDAP_USER_FILTER", "(&(objectClass=person)(mail=%s))"),
+		LdapEmailAttr:             getOrDefault("LDAP_EMAIL_ATTRIBUTE", "mail"),
+		LdapFirstNameAttr:         getOrDefault("LDAP_FIRST_NAME_ATTRIBUTE", "givenName"),
+		LdapSecondNameAttr:        getOrDefault("LDAP_SECOND_NAME_ATTRIBUTE", "sn"),
+		LdapGroupsAttr:            getOrDefault("LDAP_GROUPS_ATTRIBUTE", "memberOf"),
+		LdapAdminGroups:           getListOrDefault("LDAP_ADMIN_GROUPS", nil),
+		RefreshTokenTTL:           getDurationOrDefault("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+		SessionCleanup:            getDurationOrDefault("SESSION_CLEANUP_INTERVAL", time.Hour),
+		ImpersonationTTL:          getDurationOrDefault("IMPERSONATION_TTL", 30*time.Minute),
+		SecurityEventRetention:    getDurationOrDefault("SECURITY_EVENT_RETENTION", 365*24*time.Hour),
+		AccountDeletionGrace:      getDurationOrDefault("ACCOUNT_DELETION_GRACE", 30*24*time.Hour),
+		AuthCookies:               getBoolOrDefault("AUTH_COOKIES", false),
+		CookieDomain:              getOrDefault("COOKIE_DOMAIN", ""),
+		CookieSecure:              getBoolOrDefault("COOKIE_SECURE", true),
+		CookieSameSite:            getOrDefault("COOKIE_SAME_SITE", "lax"),
+		CorsAllowedOrigins:        getListOrDefault("CORS_ALLOWED_ORIGINS", []string{"https://*", "http://*", "capacitor://localhost"}),
+		TrustedProxies:            getListOrDefault("TRUSTED_PROXIES", nil),
+		AppName:                   getOrDefault("APP_NAME", "Todo"),
+		AdminEmails:               getListOrDefault("ADMIN_EMAILS", nil),
+		AppUrl:                    getOrDefault("APP_URL", "http://localhost:8080"),
+		FrontendUrl:               getOrDefault("FRONTEND_URL", "http://localhost:3000"), // the links in emails open pages of the web app
+		PasswordResetTTL:          getDurationOrDefault("PASSWORD_RESET_TTL", time.Hour),
+		MagicLinkTTL:              getDurationOrDefault("MAGIC_LINK_TTL", 15*time.Minute),
+		MagicLinkMaxPerHour:       getIntOrDefault("MAGIC_LINK_MAX_PER_HOUR", 5),
+		OidcIssuer:                getOrDefault("OIDC_ISSUER", ""),
+		OidcClientId:              getOrDefault("OIDC_CLIENT_ID", ""),
+		OidcClientSecret:          getOrDefault("OIDC_CLIENT_SECRET", ""),
+		OidcRedirectUrl:           getOrDefault("OIDC_REDIRECT_URL", ""),
+		OidcScopes:                getListOrDefault("OIDC_SCOPES", []string{"openid", "email", "profile"}),
+		ScimToken:                 getOrDefault("SCIM_TOKEN", ""),
+		MailDriver:                getOrDefault("MAIL_DRIVER", "log"),
+		MailFrom:                  getOrDefault("MAIL_FROM", "no-reply@localhost"),
+		MailLogLocation:           getOrDefault("MAIL_LOG_LOCATION", "mail_log"),
+		SmtpHost:                  getOrDefault("SMTP_HOST", "127.0.0.1"),
+		SmtpPort:                  getIntOrDefault("SMTP_PORT", 25),
+		SmtpUser:                  getOrDefault("SMTP_USER", ""),
+		SmtpPassword:              getOrDefault("SMTP_PASSWORD", ""),
+		AppKey:                    getSecretOrFail("APP_KEY"),
+		EmailVerificationTTL:      getDurationOrDefault("EMAIL_VERIFICATION_TTL", 24*time.Hour),
+		EmailVerificationRequired: getBoolOrDefault("EMAIL_VERIFICATION_REQUIRED", false),
+		LoginFreeAttempts:         getIntOrDefault("LOGIN_FREE_ATTEMPTS", 5),
+		LoginLockoutAttempts:      getIntOrDefault("LOGIN_LOCKOUT_ATTEMPTS", 10),
+		LoginLockoutDuration:      getDurationOrDefault("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+		LoginAttemptsWindow:       getDurationOrDefault("LOGIN_ATTEMPTS_WINDOW", time.Hour),
+		RateLimitStore:            getOrDefault("RATE_LIMIT_STORE", "memory"),
+		RateLimitAuth:             getOrDefault("RATE_LIMIT_AUTH", "20/1m"),
+		RateLimitTaskReads:        getOrDefault("RATE_LIMIT_TASK_READS", "300/1m"),
+		RateLimitTaskWrites:       getOrDefault("RATE_LIMIT_TASK_WRITES", "60/1m"),
 	}
 }
 
-//nolint:unused
 func getOrFail(key string) string {
 	env, set := os.LookupEnv(key)
 	if !set || env == "" {
@@ -41,6 +169,16 @@ func getOrFail(key string) string {
 	return env
 }
 
+// getSecretOrFail has no default on purpose, a key known from the sources
+// would let anyone forge what is signed with it
+func getSecretOrFail(key string) string {
+	env := getOrFail(key)
+	if len(env) < MinSecretLength {
+		log.Fatalf("%s env var must be at least %d characters long", key, MinSecretLength)
+	}
+	return env
+}
+
 func getOrDefault(key, defaultVal string) string {
 	env, set := os.LookupEnv(key)
 	if !set {
@@ -48,3 +186,62 @@ func getOrDefault(key, defaultVal string) string {
 	}
 	return env
 }
+
+func getDurationOrDefault(key string, defaultVal time.Duration) time.Duration {
+	env, set := os.LookupEnv(key)
+	if !set {
+		return defaultVal
+	}
+
+	d, err := time.ParseDuration(env)
+	if err != nil {
+		log.Fatalf("%s env var is not a valid duration: %s", key, err)
+	}
+	// every duration is a TTL or an interval, zero would expire everything at once or panic a ticker
+	if d <= 0 {
+		log.Fatalf("%s env var must be a positive duration, got %s", key, d)
+	}
+	return d
+}
+
+func getIntOrDefault(key string, defaultVal int) int {
+	env, set := os.LookupEnv(key)
+	if !set {
+		return defaultVal
+	}
+
+	i, err := strconv.Atoi(env)
+	if err != nil {
+		log.Fatalf("%s env var is not a valid integer: %s", key, err)
+	}
+	return i
+}
+
+func getBoolOrDefault(key string, defaultVal bool) bool {
+	env, set := os.LookupEnv(key)
+	if !set {
+		return defaultVal
+	}
+
+	b, err := strconv.ParseBool(env)
+	if err != nil {
+		log.Fatalf("%s env var is not a valid boolean: %s", key, err)
+	}
+	return b
+}
+
+func getListOrDefault(key string, defaultVal []string) []string {
+	env, set := os.LookupEnv(key)
+	if !set {
+		return defaultVal
+	}
+
+	var list []string
+	for _, item := range strings.Split(env, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -3,71 +3,316 @@ package container
 import (
 	"log"
 	"net/http"
+	"net/netip"
+	"strings"
+	"time"
 
 	"github.com/BohdanBoriak/boilerplate-go-back/config"
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/app"
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/database"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/directory"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/filesystem"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/hashing"
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/controllers"
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/middlewares"
-	"github.com/go-chi/jwtauth/v5"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/jwtkeys"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/mail"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/oidc"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/ratelimit"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/totp"
+	"github.com/go-chi/chi/v5"
 	"github.com/upper/db/v4"
 	"github.com/upper/db/v4/adapter/postgresql"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type Container struct {
 	Middlewares
 	Services
 	Controllers
+	RateLimitStore ratelimit.Store
 }
 
 type Middlewares struct {
-	AuthMw func(http.Handler) http.Handler
+	RealIpMw             func(http.Handler) http.Handler
+	AuthMw               func(http.Handler) http.Handler
+	EmailVerifiedMw      func(http.Handler) http.Handler
+	PublicLinkMw         func(http.Handler) http.Handler
+	ScimAuthMw           func(http.Handler) http.Handler
+	AuthRateLimitMw      func(http.Handler) http.Handler
+	TaskReadRateLimitMw  func(http.Handler) http.Handler
+	TaskWriteRateLimitMw func(http.Handler) http.Handler
 }
 
 type Services struct {
 	app.AuthService
 	app.UserService
 	app.TaskService
+	app.AvatarService
+	app.AuthorizationService
+	app.TaskShareService
+	app.WorkspaceService
+	app.PublicLinkService
+	app.PasswordResetService
+	app.EmailVerificationService
+	app.TwoFactorService
+	app.PersonalAccessTokenService
+	app.AdminService
+	app.LoginThrottleService
+	app.MagicLinkService
+	app.OidcService
+	app.ScimService
+	app.ImpersonationService
+	app.SecurityEventService
+	app.AccountDeletionService
 }
 
 type Controllers struct {
-	AuthController controllers.AuthController
-	UserController controllers.UserController
-	TaskController controllers.TaskController
+	AuthController                controllers.AuthController
+	UserController                controllers.UserController
+	TaskController                controllers.TaskController
+	TaskShareController           controllers.TaskShareController
+	WorkspaceController           controllers.WorkspaceController
+	PublicLinkController          controllers.PublicLinkController
+	TwoFactorController           controllers.TwoFactorController
+	PersonalAccessTokenController controllers.PersonalAccessTokenController
+	AdminController               controllers.AdminController
+	JwksController                controllers.JwksController
+	OidcController                controllers.OidcController
+	ScimController                controllers.ScimController
+	ImpersonationController       controllers.ImpersonationController
+	SecurityEventController       controllers.SecurityEventController
 }
 
 func New(conf config.Configuration) Container {
-	tknAuth := jwtauth.New("HS256", []byte(conf.JwtSecret), nil)
+	jwtKeys := getJwtKeys(conf)
 	sess := getDbSess(conf)
 
 	sessionRepository := database.NewSessRepository(sess)
+	refreshTokenRepository := database.NewRefreshTokenRepository(sess)
 	userRepository := database.NewUserRepository(sess)
 	taskRepository := database.NewTaskRepository(sess)
+	taskShareRepository := database.NewTaskShareRepository(sess)
+	workspaceRepository := database.NewWorkspaceRepository(sess)
+	workspaceMemberRepository := database.NewWorkspaceMemberRepository(sess)
+	workspaceInvitationRepository := database.NewWorkspaceInvitationRepository(sess)
+	publicLinkRepository := database.NewPublicLinkRepository(sess)
+	passwordResetRepository := database.NewPasswordResetRepository(sess)
+	recoveryCodeRepository := database.NewRecoveryCodeRepository(sess)
+	personalAccessTokenRepository := database.NewPersonalAccessTokenRepository(sess)
+	loginAttemptRepository := database.NewLoginAttemptRepository(sess)
+	lockoutEventRepository := database.NewLockoutEventRepository(sess)
+	magicLinkRepository := database.NewMagicLinkRepository(sess)
+	oidcLoginRepository := database.NewOidcLoginRepository(sess)
+	userIdentityRepository := database.NewUserIdentityRepository(sess)
+	impersonationAuditRepository := database.NewImpersonationAuditRepository(sess)
+	securityEventRepository := database.NewSecurityEventRepository(sess)
+	accountErasureRepository := database.NewAccountErasureRepository(sess)
+
+	imageStorageService := filesystem.NewImageStorageService(conf.FileStorageLocation)
+	mailer := getMailer(conf)
+	passwordHasher := getPasswordHasher(conf)
+	signer := app.NewSigner(conf.AppKey)
 
 	userService := app.NewUserService(userRepository)
-	authService := app.NewAuthService(sessionRepository, userRepository, tknAuth, conf.JwtTTL)
-	taskService := app.NewTaskService(taskRepository)
+	securityEventService := app.NewSecurityEventService(securityEventRepository, conf.SecurityEventRetention)
+	twoFactorService := app.NewTwoFactorService(
+		userRepository,
+		recoveryCodeRepository,
+		totp.NewGenerator(totp.SystemClock()),
+		app.NewEncrypter(conf.AppKey),
+		conf.AppName,
+	)
+	loginThrottleService := app.NewLoginThrottleService(
+		loginAttemptRepository,
+		lockoutEventRepository,
+		conf.LoginFreeAttempts,
+		conf.LoginLockoutAttempts,
+		conf.LoginLockoutDuration,
+		conf.LoginAttemptsWindow,
+	)
+	authService := app.NewAuthService(
+		sessionRepository,
+		userRepository,
+		refreshTokenRepository,
+		twoFactorService,
+		loginThrottleService,
+		securityEventService,
+		getAuthProviders(conf, userRepository, passwordHasher),
+		signer,
+		passwordHasher,
+		jwtKeys,
+		conf.JwtTTL,
+		conf.RefreshTokenTTL,
+	)
+	authorizationService := app.NewAuthorizationService(taskShareRepository, workspaceMemberRepository)
+	taskService := app.NewTaskService(taskRepository, userRepository, authorizationService)
+	taskShareService := app.NewTaskShareService(taskShareRepository, userRepository)
+	avatarService := app.NewAvatarService(userRepository, imageStorageService)
+	workspaceService := app.NewWorkspaceService(
+		workspaceRepository,
+		workspaceMemberRepository,
+		workspaceInvitationRepository,
+		taskRepository,
+		userRepository,
+		mailer,
+		conf.FrontendUrl,
+	)
+	publicLinkService := app.NewPublicLinkService(publicLinkRepository, taskRepository, workspaceRepository, authorizationService)
+	passwordResetService := app.NewPasswordResetService(
+		passwordResetRepository,
+		userRepository,
+		sessionRepository,
+		securityEventService,
+		mailer,
+		passwordHasher,
+		conf.FrontendUrl,
+		conf.PasswordResetTTL,
+	)
+	magicLinkService := app.NewMagicLinkService(
+		magicLinkRepository,
+		userRepository,
+		authService,
+		mailer,
+		conf.FrontendUrl,
+		conf.MagicLinkTTL,
+		conf.MagicLinkMaxPerHour,
+	)
+	oidcService := app.NewOidcService(
+		oidcLoginRepository,
+		userIdentityRepository,
+		userRepository,
+		authService,
+		getOidcProvider(conf),
+		conf.OidcIssuer,
+	)
+	scimService := app.NewScimService(userRepository, sessionRepository)
+	personalAccessTokenService := app.NewPersonalAccessTokenService(personalAccessTokenRepository)
+	impersonationService := app.NewImpersonationService(
+		sessionRepository,
+		impersonationAuditRepository,
+		authService,
+		conf.ImpersonationTTL,
+	)
+	accountDeletionService := app.NewAccountDeletionService(
+		userRepository,
+		sessionRepository,
+		personalAccessTokenRepository,
+		accountErasureRepository,
+		imageStorageService,
+		signer,
+		mailer,
+		conf.FrontendUrl,
+		conf.AccountDeletionGrace,
+	)
+	adminService := app.NewAdminService(userRepository, sessionRepository, securityEventService, accountDeletionService)
+	emailVerificationService := app.NewEmailVerificationService(
+		userRepository,
+		securityEventService,
+		signer,
+		mailer,
+		conf.AppUrl,
+		conf.EmailVerificationTTL,
+		conf.AdminEmails,
+	)
 
-	authController := controllers.NewAuthController(authService, userService)
-	userController := controllers.NewUserController(userService, authService)
-	taskController := controllers.NewTaskController(taskService)
+	authCookies := getAuthCookies(conf)
+	authController := controllers.NewAuthController(
+		authService,
+		userService,
+		passwordResetService,
+		emailVerificationService,
+		magicLinkService,
+		accountDeletionService,
+		authCookies,
+	)
+	userController := controllers.NewUserController(
+		userService,
+		authService,
+		avatarService,
+		emailVerificationService,
+		accountDeletionService,
+		authCookies,
+	)
+	taskController := controllers.NewTaskController(taskService, authorizationService)
+	taskShareController := controllers.NewTaskShareController(taskShareService, authorizationService)
+	workspaceController := controllers.NewWorkspaceController(workspaceService, authorizationService)
+	publicLinkController := controllers.NewPublicLinkController(publicLinkService, authorizationService)
+	twoFactorController := controllers.NewTwoFactorController(twoFactorService)
+	personalAccessTokenController := controllers.NewPersonalAccessTokenController(personalAccessTokenService)
+	adminController := controllers.NewAdminController(adminService)
+	jwksController := controllers.NewJwksController(jwtKeys)
+	oidcController := controllers.NewOidcController(oidcService, authCookies)
+	scimController := controllers.NewScimController(scimService, conf.AppUrl+"/scim/v2")
+	impersonationController := controllers.NewImpersonationController(impersonationService)
+	securityEventController := controllers.NewSecurityEventController(securityEventService)
 
-	authMiddleware := middlewares.AuthMiddleware(tknAuth, authService, userService)
+	authMiddleware := middlewares.AuthMiddleware(
+		jwtKeys,
+		authService,
+		userService,
+		personalAccessTokenService,
+		authCookies,
+	)
+	// every route behind authentication is audited while an admin impersonates
+	authMiddleware = chi.Chain(authMiddleware, middlewares.ImpersonationMiddleware(impersonationService)).Handler
+	emailVerifiedMiddleware := middlewares.EmailVerifiedMiddleware(conf.EmailVerificationRequired)
+	publicLinkMiddleware := middlewares.PublicLinkMiddleware(publicLinkService)
+	rateLimitStore := getRateLimitStore(conf, sess)
+	authRateLimitMiddleware := middlewares.RateLimitMiddleware(rateLimitStore, "auth", getRateLimit(conf.RateLimitAuth))
+	taskReadRateLimitMiddleware := middlewares.RateLimitMiddleware(rateLimitStore, "tasks:read", getRateLimit(conf.RateLimitTaskReads))
+	taskWriteRateLimitMiddleware := middlewares.RateLimitMiddleware(rateLimitStore, "tasks:write", getRateLimit(conf.RateLimitTaskWrites))
 
 	return Container{
 		Middlewares: Middlewares{
-			AuthMw: authMiddleware,
+			RealIpMw:             middlewares.RealIpMiddleware(getTrustedProxies(conf)),
+			AuthMw:               authMiddleware,
+			EmailVerifiedMw:      emailVerifiedMiddleware,
+			PublicLinkMw:         publicLinkMiddleware,
+			ScimAuthMw:           middlewares.ScimAuthMiddleware(conf.ScimToken),
+			AuthRateLimitMw:      authRateLimitMiddleware,
+			TaskReadRateLimitMw:  taskReadRateLimitMiddleware,
+			TaskWriteRateLimitMw: taskWriteRateLimitMiddleware,
 		},
 		Services: Services{
 			authService,
 			userService,
 			taskService,
+			avatarService,
+			authorizationService,
+			taskShareService,
+			workspaceService,
+			publicLinkService,
+			passwordResetService,
+			emailVerificationService,
+			twoFactorService,
+			personalAccessTokenService,
+			adminService,
+			loginThrottleService,
+			magicLinkService,
+			oidcService,
+			scimService,
+			impersonationService,
+			securityEventService,
+			accountDeletionService,
 		},
 		Controllers: Controllers{
 			authController,
 			userController,
 			taskController,
+			taskShareController,
+			workspaceController,
+			publicLinkController,
+			twoFactorController,
+			personalAccessTokenController,
+			adminController,
+			jwksController,
+			oidcController,
+			scimController,
+			impersonationController,
+			securityEventController,
 		},
+		RateLimitStore: rateLimitStore,
 	}
 }
 
@@ -84,3 +329,202 @@ func getDbSess(conf config.Configuration) db.Session {
 	}
 	return sess
 }
+
+// getJwtKeys uses the PEM keys when a signing key is configured,
+// otherwise HS256 with an explicitly set shared secret, there is no default one
+func getJwtKeys(conf config.Configuration) jwtkeys.Manager {
+	if conf.JwtSigningKey == "" {
+		if len(conf.JwtSecret) < config.MinSecretLength {
+			log.Fatalf("Set JWT_SIGNING_KEY, or JWT_SECRET of at least %d characters for HS256\n", config.MinSecretLength)
+		}
+		log.Print("JWT_SIGNING_KEY is not set, access tokens are signed with HS256 and the JWKS is empty")
+		return jwtkeys.NewHmacManager(conf.JwtSecret)
+	}
+
+	// each entry is either "path" or "kid=path"
+	verification := make([]jwtkeys.KeyFile, len(conf.JwtVerificationKeys))
+	for i, item := range conf.JwtVerificationKeys {
+		kid, path, found := strings.Cut(item, "=")
+		if !found {
+			kid, path = "", item
+		}
+		verification[i] = jwtkeys.KeyFile{Id: kid, Path: path}
+	}
+
+	keys, err := jwtkeys.LoadManager(
+		jwtkeys.KeyFile{Id: conf.JwtSigningKeyId, Path: conf.JwtSigningKey},
+		verification,
+	)
+	if err != nil {
+		log.Fatalf("Unable to load JWT keys: %q\n", err)
+	}
+	return keys
+}
+
+// getPasswordHasher keeps the other algorithm for verification,
+// so switching PASSWORD_HASHER doesn't lock anybody out
+func getPasswordHasher(conf config.Configuration) hashing.PasswordHasher {
+	if conf.Argon2Memory <= 0 || conf.Argon2Iterations <= 0 || conf.Argon2Parallelism <= 0 || conf.Argon2Parallelism > 255 {
+		log.Fatalf("Invalid argon2 parameters (memory %d, iterations %d, parallelism %d)\n", conf.Argon2Memory, conf.Argon2Iterations, conf.Argon2Parallelism)
+	}
+	if conf.BcryptCost < bcrypt.MinCost || conf.BcryptCost > bcrypt.MaxCost {
+		log.Fatalf("Invalid bcrypt cost %d\n", conf.BcryptCost)
+	}
+
+	argon2id := hashing.NewArgon2idHasher(hashing.Argon2Params{
+		Memory:      uint32(conf.Argon2Memory),
+		Iterations:  uint32(conf.Argon2Iterations),
+		Parallelism: uint8(conf.Argon2Parallelism),
+	})
+	bcryptHasher := hashing.NewBcryptHasher(conf.BcryptCost)
+
+	switch conf.PasswordHasher {
+	case "argon2id":
+		return hashing.NewPasswordHasher(argon2id, bcryptHasher)
+	case "bcrypt":
+		return hashing.NewPasswordHasher(bcryptHasher, argon2id)
+	default:
+		log.Fatalf("Unknown password hasher %q (use argon2id or bcrypt)\n", conf.PasswordHasher)
+		return nil
+	}
+}
+
+func getRateLimitStore(conf config.Configuration, sess db.Session) ratelimit.Store {
+	switch conf.RateLimitStore {
+	case "memory":
+		return ratelimit.NewMemoryStore()
+	case "postgres":
+		return ratelimit.NewPostgresStore(sess)
+	default:
+		log.Fatalf("Unknown rate limit store %q (use memory or postgres)", conf.RateLimitStore)
+		return nil
+	}
+}
+
+func getRateLimit(s string) ratelimit.Limit {
+	limit, err := ratelimit.ParseLimit(s)
+	if err != nil {
+		log.Fatalf("Invalid rate limit: %s", err)
+	}
+	return limit
+}
+
+func getAuthCookies(conf config.Configuration) controllers.AuthCookies {
+	var sameSite http.SameSite
+	switch strings.ToLower(conf.CookieSameSite) {
+	case "lax":
+		sameSite = http.SameSiteLaxMode
+	case "strict":
+		sameSite = http.SameSiteStrictMode
+	case "none":
+		if !conf.CookieSecure {
+			log.Fatalf("COOKIE_SAME_SITE=none requires COOKIE_SECURE=true, browsers reject it otherwise")
+		}
+		sameSite = http.SameSiteNoneMode
+	default:
+		log.Fatalf("Unknown COOKIE_SAME_SITE %q (use lax, strict or none)", conf.CookieSameSite)
+	}
+
+	// with credentials allowed a wildcard or the opaque "null" origin would let any site call the API as the user
+	if conf.AuthCookies {
+		for _, origin := range conf.CorsAllowedOrigins {
+			if strings.Contains(origin, "*") || strings.EqualFold(origin, "null") {
+				log.Fatalf("AUTH_COOKIES requires exact CORS_ALLOWED_ORIGINS, %q matches any site", origin)
+			}
+		}
+	}
+
+	return controllers.NewAuthCookies(
+		conf.AuthCookies,
+		conf.CookieDomain,
+		conf.CookieSecure,
+		sameSite,
+		conf.JwtTTL,
+		conf.RefreshTokenTTL,
+	)
+}
+
+// getAuthProviders builds the login chain in the configured order
+func getAuthProviders(conf config.Configuration, ur database.UserRepository, ph hashing.PasswordHasher) []app.AuthProvider {
+	var providers []app.AuthProvider
+	for _, name := range conf.AuthProviders {
+		switch name {
+		case "local":
+			providers = append(providers, app.NewLocalAuthProvider(ur, ph))
+		case "ldap":
+			if conf.LdapUrl == "" || conf.LdapBaseDn == "" {
+				log.Fatalf("LDAP_URL and LDAP_BASE_DN are required for the ldap auth provider")
+			}
+			if strings.Count(conf.LdapUserFilter, "%s") != 1 {
+				log.Fatalf("LDAP_USER_FILTER must contain exactly one %%s")
+			}
+			authenticator := directory.NewAuthenticator(directory.Config{
+				Url:            conf.LdapUrl,
+				StartTls:       conf.LdapStartTls,
+				BindDn:         conf.LdapBindDn,
+				BindPassword:   conf.LdapBindPassword,
+				BaseDn:         conf.LdapBaseDn,
+				UserFilter:     conf.LdapUserFilter,
+				EmailAttr:      conf.LdapEmailAttr,
+				FirstNameAttr:  conf.LdapFirstNameAttr,
+				SecondNameAttr: conf.LdapSecondNameAttr,
+				GroupsAttr:     conf.LdapGroupsAttr,
+			})
+			providers = append(providers, app.NewLdapAuthProvider(ur, authenticator, conf.LdapAdminGroups))
+		default:
+			log.Fatalf("Unknown auth provider %q (use local or ldap)", name)
+		}
+	}
+
+	if len(providers) == 0 {
+		log.Fatalf("AUTH_PROVIDERS must list at least one provider")
+	}
+	return providers
+}
+
+// getTrustedProxies accepts networks in CIDR notation and single addresses
+func getTrustedProxies(conf config.Configuration) []netip.Prefix {
+	proxies := make([]netip.Prefix, len(conf.TrustedProxies))
+	for i, item := range conf.TrustedProxies {
+		p, err := netip.ParsePrefix(item)
+		if err != nil {
+			addr, aerr := netip.ParseAddr(item)
+			if aerr != nil {
+				log.Fatalf("Invalid TRUSTED_PROXIES entry %q: %s", item, err)
+			}
+			p = netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen())
+		}
+		proxies[i] = p.Masked()
+	}
+	return proxies
+}
+
+// getOidcProvider returns nil when no issuer is configured, which disables the login
+func getOidcProvider(conf config.Configuration) *oidc.Provider {
+	if conf.OidcIssuer == "" {
+		return nil
+	}
+	if conf.OidcClientId == "" || conf.OidcRedirectUrl == "" {
+		log.Fatalf("OIDC_CLIENT_ID and OIDC_REDIRECT_URL are required when OIDC_ISSUER is set")
+	}
+
+	return oidc.NewProvider(oidc.Config{
+		Issuer:       conf.OidcIssuer,
+		ClientId:     conf.OidcClientId,
+		ClientSecret: conf.OidcClientSecret,
+		RedirectUrl:  conf.OidcRedirectUrl,
+		Scopes:       conf.OidcScopes,
+	}, &http.Client{Timeout: 10 * time.Second})
+}
+
+func getMailer(conf config.Configuration) mail.Mailer {
+	switch conf.MailDriver {
+	case "smtp":
+		return mail.NewSmtpMailer(conf.SmtpHost, conf.SmtpPort, conf.SmtpUser, conf.SmtpPassword, conf.MailFrom)
+	case "log":
+		return mail.NewLogMailer(conf.MailLogLocation)
+	default:
+		log.Fatalf("Unknown mail driver %q (use smtp or log)\n", conf.MailDriver)
+		return nil
+	}
+}
@@ -0,0 +1,194 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/database"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/filesystem"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/mail"
+	"github.com/upper/db/v4"
+)
+
+const accountRestorePurpose = "account-restore"
+
+var (
+	ErrInvalidRestoreToken = errors.New("restore link is invalid or expired")
+	ErrAccountEmailTaken   = errors.New("the email of this account is already used by another one")
+)
+
+type AccountDeletionService interface {
+	Delete(user domain.User) error
+	Restore(token string) (domain.User, error)
+	AnonymizeExpired() error
+}
+
+type accountDeletionService struct {
+	userRepo     database.UserRepository
+	sessionRepo  database.SessionRepository
+	tokenRepo    database.PersonalAccessTokenRepository
+	erasureRepo  database.AccountErasureRepository
+	imageStorage filesystem.ImageStorageService
+	signer       Signer
+	mailer       mail.Mailer
+	frontendUrl  string
+	gracePeriod  time.Duration
+}
+
+func NewAccountDeletionService(
+	ur database.UserRepository,
+	sr database.SessionRepository,
+	patr database.PersonalAccessTokenRepository,
+	aer database.AccountErasureRepository,
+	is filesystem.ImageStorageService,
+	s Signer,
+	m mail.Mailer,
+	frontendUrl string,
+	gracePeriod time.Duration,
+) AccountDeletionService {
+	return accountDeletionService{
+		userRepo:     ur,
+		sessionRepo:  sr,
+		tokenRepo:    patr,
+		erasureRepo:  aer,
+		imageStorage: is,
+		signer:       s,
+		mailer:       m,
+		frontendUrl:  frontendUrl,
+		gracePeriod:  gracePeriod,
+	}
+}
+
+// Delete locks the user out at once and mails a link which undoes the deletion
+// until the grace period is over, after that the account is anonymized
+func (s accountDeletionService) Delete(user domain.User) error {
+	err := s.userRepo.RequestDeletion(user.Id)
+	if err != nil {
+		log.Printf("AccountDeletionService: %s", err)
+		return err
+	}
+
+	err = s.sessionRepo.DeleteByUser(user.Id)
+	if err != nil {
+		log.Printf("AccountDeletionService: %s", err)
+		return err
+	}
+
+	err = s.tokenRepo.RevokeByUser(user.Id)
+	if err != nil {
+		log.Printf("AccountDeletionService: %s", err)
+		return err
+	}
+
+	deleted, err := s.userRepo.FindByIdWithDeleted(user.Id)
+	if err != nil || deleted.DeletedDate == nil {
+		log.Printf("AccountDeletionService: failed to reload deleted user %d: %v", user.Id, err)
+		return nil
+	}
+
+	// the link is bound to this deletion, so it can't undo a later one
+	payload := fmt.Sprintf("%d|%d", deleted.Id, deleted.DeletedDate.UnixNano())
+	token := s.signer.Sign(accountRestorePurpose, payload, s.gracePeriod)
+
+	go func() {
+		err := s.mailer.Send(mail.Message{
+			To:      deleted.Email,
+			Subject: "Your account is deleted",
+			Body: fmt.Sprintf(
+				"Hello, %s!\n\nYour account is deleted and you are logged out everywhere. Your data is kept for %s and erased after that.\n\nIf it was a mistake, you can restore the account by following the link below.\n\n%s/account/restore?token=%s",
+				deleted.FirstName, s.gracePeriod, s.frontendUrl, url.QueryEscape(token),
+			),
+		})
+		if err != nil {
+			log.Printf("AccountDeletionService: failed to send email %s", err)
+		}
+	}()
+
+	return nil
+}
+
+// Restore brings the account back, the user has to log in again afterwards
+func (s accountDeletionService) Restore(token string) (domain.User, error) {
+	payload, err := s.signer.Verify(accountRestorePurpose, token)
+	if err != nil {
+		return domain.User{}, ErrInvalidRestoreToken
+	}
+
+	id, deletedNano, _ := strings.Cut(payload, "|")
+	userId, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return domain.User{}, ErrInvalidRestoreToken
+	}
+
+	u, err := s.userRepo.FindByIdWithDeleted(userId)
+	if err != nil {
+		if errors.Is(err, db.ErrNoMoreRows) {
+			return domain.User{}, ErrInvalidRestoreToken
+		}
+		log.Printf("AccountDeletionService: %s", err)
+		return domain.User{}, err
+	}
+	if u.DeletedDate == nil || u.AnonymizedAt != nil || strconv.FormatInt(u.DeletedDate.UnixNano(), 10) != deletedNano {
+		return domain.User{}, ErrInvalidRestoreToken
+	}
+
+	// somebody could have registered with the freed email during the grace period
+	_, err = s.userRepo.FindByEmail(u.Email)
+	if err == nil {
+		return domain.User{}, ErrAccountEmailTaken
+	} else if !errors.Is(err, db.ErrNoMoreRows) {
+		log.Printf("AccountDeletionService: %s", err)
+		return domain.User{}, err
+	}
+
+	err = s.userRepo.Restore(u.Id)
+	if err != nil {
+		log.Printf("AccountDeletionService: %s", err)
+		return domain.User{}, err
+	}
+
+	u.DeletedDate = nil
+	return u, nil
+}
+
+// AnonymizeExpired erases the accounts deleted by Delete longer than the grace period ago,
+// the ones only deactivated are kept. One failed account doesn't stop the others
+func (s accountDeletionService) AnonymizeExpired() error {
+	users, err := s.userRepo.FindDeletionRequestedBefore(time.Now().Add(-s.gracePeriod))
+	if err != nil {
+		return err
+	}
+
+	for _, u := range users {
+		err = s.erasureRepo.Erase(domain.User{
+			Id:         u.Id,
+			Email:      fmt.Sprintf("deleted-%d@deleted.invalid", u.Id),
+			FirstName:  "Deleted",
+			SecondName: "User",
+		})
+		if err != nil {
+			if !errors.Is(err, db.ErrNoMoreRows) {
+				log.Printf("AccountDeletionService: failed to anonymize user %d: %s", u.Id, err)
+			}
+			continue
+		}
+
+		if u.Avatar != nil {
+			for _, size := range domain.AvatarSizes {
+				err = s.imageStorage.RemoveImage(domain.AvatarPath(*u.Avatar, size))
+				if err != nil {
+					log.Printf("AccountDeletionService: %s", err)
+				}
+			}
+		}
+		log.Printf("AccountDeletionService: user %d is anonymized", u.Id)
+	}
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/database"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/mail"
+	"github.com/upper/db/v4"
+)
+
+// deletionUserRepository keeps users by id and mimics the soft deletion columns
+type deletionUserRepository struct {
+	database.UserRepository
+	users map[uint64]domain.User
+}
+
+func (r *deletionUserRepository) FindByIdWithDeleted(id uint64) (domain.User, error) {
+	u, ok := r.users[id]
+	if !ok {
+		return domain.User{}, db.ErrNoMoreRows
+	}
+	return u, nil
+}
+
+func (r *deletionUserRepository) Delete(id uint64) error {
+	u := r.users[id]
+	if u.DeletedDate == nil {
+		now := time.Now()
+		u.DeletedDate = &now
+	}
+	r.users[id] = u
+	return nil
+}
+
+func (r *deletionUserRepository) RequestDeletion(id uint64) error {
+	u := r.users[id]
+	if u.DeletedDate == nil {
+		now := time.Now()
+		u.DeletedDate = &now
+		u.DeletionRequestedAt = &now
+	}
+	r.users[id] = u
+	return nil
+}
+
+func (r *deletionUserRepository) FindDeletionRequestedBefore(t time.Time) ([]domain.User, error) {
+	var users []domain.User
+	for _, u := range r.users {
+		if u.DeletedDate != nil && u.DeletionRequestedAt != nil && u.DeletionRequestedAt.Before(t) && u.AnonymizedAt == nil {
+			users = append(users, u)
+		}
+	}
+	return users, nil
+}
+
+// backdate moves the deletion of a user into the past, as if the grace period was over
+func (r *deletionUserRepository) backdate(id uint64, d time.Duration) {
+	u := r.users[id]
+	if u.DeletedDate != nil {
+		t := u.DeletedDate.Add(-d)
+		u.DeletedDate = &t
+	}
+	if u.DeletionRequestedAt != nil {
+		t := u.DeletionRequestedAt.Add(-d)
+		u.DeletionRequestedAt = &t
+	}
+	r.users[id] = u
+}
+
+type stubSessionRepository struct {
+	database.SessionRepository
+}
+
+func (r stubSessionRepository) DeleteByUser(userId uint64) error {
+	return nil
+}
+
+type stubPersonalAccessTokenRepository struct {
+	database.PersonalAccessTokenRepository
+}
+
+func (r stubPersonalAccessTokenRepository) RevokeByUser(userId uint64) error {
+	return nil
+}
+
+type stubAccountErasureRepository struct {
+	erased []uint64
+}
+
+func (r *stubAccountErasureRepository) Erase(anonymized domain.User) error {
+	r.erased = append(r.erased, anonymized.Id)
+	return nil
+}
+
+type stubMailer struct{}
+
+func (m stubMailer) Send(msg mail.Message) error {
+	return nil
+}
+
+// a deactivation by the identity provider has to stay reversible, only a deletion is followed by the erasure
+func TestAnonymizeExpiredKeepsDeactivatedUsers(t *testing.T) {
+	const (
+		deactivatedId = 1
+		deletedId     = 2
+		gracePeriod   = time.Hour
+	)
+
+	users := &deletionUserRepository{users: map[uint64]domain.User{
+		deactivatedId: {Id: deactivatedId, Email: "scim@example.com"},
+		deletedId:     {Id: deletedId, Email: "self@example.com"},
+	}}
+	erasure := &stubAccountErasureRepository{}
+	deletion := NewAccountDeletionService(
+		users,
+		stubSessionRepository{},
+		stubPersonalAccessTokenRepository{},
+		erasure,
+		nil,
+		NewSigner("test-key"),
+		stubMailer{},
+		"",
+		gracePeriod,
+	)
+	scim := NewScimService(users, stubSessionRepository{})
+
+	_, err := scim.Deactivate(users.users[deactivatedId])
+	if err != nil {
+		t.Fatalf("Deactivate: %s", err)
+	}
+	err = deletion.Delete(users.users[deletedId])
+	if err != nil {
+		t.Fatalf("Delete: %s", err)
+	}
+	users.backdate(deactivatedId, 2*gracePeriod)
+	users.backdate(deletedId, 2*gracePeriod)
+
+	err = deletion.AnonymizeExpired()
+	if err != nil {
+		t.Fatalf("AnonymizeExpired: %s", err)
+	}
+
+	if len(erasure.erased) != 1 || erasure.erased[0] != deletedId {
+		t.Fatalf("erased = %v, want only user %d", erasure.erased, deletedId)
+	}
+}
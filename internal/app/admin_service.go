@@ -0,0 +1,180 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/database"
+	"github.com/upper/db/v4"
+)
+
+var (
+	// ErrSelfModification keeps an admin from locking themselves out
+	ErrSelfModification = errors.New("admins can not suspend, delete or demote themselves")
+	ErrUserDeleted      = errors.New("user is deleted, restore it first")
+)
+
+type AdminService interface {
+	Find(id uint64) (interface{}, error)
+	FindUsers(f domain.UserFilters) (domain.Users, error)
+	Suspend(admin, user domain.User) (domain.User, error)
+	Restore(user domain.User) (domain.User, error)
+	Delete(admin, user domain.User) error
+	ForceLogout(user domain.User) error
+	ChangeRole(admin, user domain.User, role domain.Role, device domain.Device) (domain.User, error)
+}
+
+type adminService struct {
+	userRepo        database.UserRepository
+	sessionRepo     database.SessionRepository
+	securityEvents  SecurityEventService
+	accountDeletion AccountDeletionService
+}
+
+func NewAdminService(
+	ur database.UserRepository,
+	sr database.SessionRepository,
+	ses SecurityEventService,
+	ads AccountDeletionService,
+) AdminService {
+	return adminService{
+		userRepo:        ur,
+		sessionRepo:     sr,
+		securityEvents:  ses,
+		accountDeletion: ads,
+	}
+}
+
+// Find includes deleted users, the admin lists them and has to be able to restore them
+func (s adminService) Find(id uint64) (interface{}, error) {
+	user, err := s.userRepo.FindByIdWithDeleted(id)
+	if err != nil {
+		return domain.User{}, err
+	}
+	// anonymized accounts are only tombstones, they are not listed either
+	if user.AnonymizedAt != nil {
+		return domain.User{}, db.ErrNoMoreRows
+	}
+
+	return user, nil
+}
+
+func (s adminService) FindUsers(f domain.UserFilters) (domain.Users, error) {
+	users, err := s.userRepo.FindAll(f)
+	if err != nil {
+		log.Printf("AdminService: %s", err)
+		return domain.Users{}, err
+	}
+
+	return users, nil
+}
+
+func (s adminService) Suspend(admin, user domain.User) (domain.User, error) {
+	if admin.Id == user.Id {
+		return domain.User{}, ErrSelfModification
+	}
+	if user.DeletedDate != nil {
+		return domain.User{}, ErrUserDeleted
+	}
+	if user.IsSuspended() {
+		return user, nil
+	}
+
+	now := time.Now()
+	user.SuspendedAt = &now
+	user, err := s.userRepo.Update(user)
+	if err != nil {
+		log.Printf("AdminService: %s", err)
+		return domain.User{}, err
+	}
+
+	err = s.ForceLogout(user)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	return user, nil
+}
+
+// Restore lifts the suspension and brings back a deleted user
+func (s adminService) Restore(user domain.User) (domain.User, error) {
+	if user.DeletedDate != nil {
+		// somebody could have taken the freed email in the meantime
+		other, err := s.userRepo.FindByEmail(user.Email)
+		if err == nil && other.Id != user.Id {
+			return domain.User{}, ErrEmailTaken
+		} else if err != nil && !errors.Is(err, db.ErrNoMoreRows) {
+			log.Printf("AdminService: %s", err)
+			return domain.User{}, err
+		}
+
+		err = s.userRepo.Restore(user.Id)
+		if err != nil {
+			log.Printf("AdminService: %s", err)
+			return domain.User{}, err
+		}
+		user.DeletedDate = nil
+	}
+
+	user.SuspendedAt = nil
+	user, err := s.userRepo.Update(user)
+	if err != nil {
+		log.Printf("AdminService: %s", err)
+		return domain.User{}, err
+	}
+
+	return user, nil
+}
+
+// Delete goes the same way as a deletion by the user, so the access is revoked
+// and the user gets the link which undoes it during the grace period
+func (s adminService) Delete(admin, user domain.User) error {
+	if admin.Id == user.Id {
+		return ErrSelfModification
+	}
+	if user.DeletedDate != nil {
+		return nil
+	}
+
+	return s.accountDeletion.Delete(user)
+}
+
+func (s adminService) ForceLogout(user domain.User) error {
+	err := s.sessionRepo.DeleteByUser(user.Id)
+	if err != nil {
+		log.Printf("AdminService: %s", err)
+		return err
+	}
+
+	return nil
+}
+
+func (s adminService) ChangeRole(admin, user domain.User, role domain.Role, device domain.Device) (domain.User, error) {
+	if admin.Id == user.Id {
+		return domain.User{}, ErrSelfModification
+	}
+	if user.DeletedDate != nil {
+		return domain.User{}, ErrUserDeleted
+	}
+
+	previous := user.Role
+	user.Role = role
+	user, err := s.userRepo.Update(user)
+	if err != nil {
+		log.Printf("AdminService: %s", err)
+		return domain.User{}, err
+	}
+
+	if previous != role {
+		// the device is the admin's, the event belongs to the user whose role changed
+		e := securityEvent(domain.SecurityEventRoleChange, user.Id, device)
+		e.Email = user.Email
+		e.Details = fmt.Sprintf("%s -> %s by user %d", previous, role, admin.Id)
+		s.securityEvents.Record(e)
+	}
+
+	return user, nil
+}
@@ -0,0 +1,178 @@
+package app
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/database"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/directory"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/hashing"
+	"github.com/upper/db/v4"
+)
+
+var ErrLdapAccountConflict = errors.New("an account with this email exists but the email is not verified, log in with the password first")
+
+// AuthProvider checks the email and password, ErrInvalidCredentials
+// means the next provider of the chain should be tried
+type AuthProvider interface {
+	Authenticate(email, password string) (domain.User, error)
+}
+
+type localAuthProvider struct {
+	userRepo       database.UserRepository
+	passwordHasher hashing.PasswordHasher
+}
+
+// NewLocalAuthProvider checks the password hash stored in the users table
+func NewLocalAuthProvider(ur database.UserRepository, ph hashing.PasswordHasher) AuthProvider {
+	return localAuthProvider{
+		userRepo:       ur,
+		passwordHasher: ph,
+	}
+}
+
+func (p localAuthProvider) Authenticate(email, password string) (domain.User, error) {
+	u, err := p.userRepo.FindByEmail(email)
+	if err != nil {
+		if errors.Is(err, db.ErrNoMoreRows) {
+			return domain.User{}, ErrInvalidCredentials
+		}
+		return domain.User{}, err
+	}
+
+	// users created by external providers have no password
+	if u.Password == "" {
+		return domain.User{}, ErrInvalidCredentials
+	}
+
+	valid, err := p.passwordHasher.Verify(password, u.Password)
+	if err != nil {
+		log.Printf("LocalAuthProvider: %s", err)
+		return domain.User{}, ErrInvalidCredentials
+	}
+	if !valid {
+		return domain.User{}, ErrInvalidCredentials
+	}
+
+	// the plain password is known only here, so legacy hashes are upgraded on login
+	if p.passwordHasher.NeedsRehash(u.Password) {
+		u = p.rehashPassword(u, password)
+	}
+
+	return u, nil
+}
+
+// rehashPassword never fails the login, the old hash just stays until the next one
+func (p localAuthProvider) rehashPassword(user domain.User, password string) domain.User {
+	hash, err := p.passwordHasher.Hash(password)
+	if err != nil {
+		log.Printf("LocalAuthProvider: failed to rehash password %s", err)
+		return user
+	}
+
+	// only the password is written, the user was read before the check and
+	// a full update would revert whatever changed in the meantime
+	err = p.userRepo.UpdatePassword(user.Id, user.Password, hash)
+	if err != nil {
+		log.Printf("LocalAuthProvider: failed to rehash password %s", err)
+		return user
+	}
+
+	user.Password = hash
+	return user
+}
+
+type ldapAuthProvider struct {
+	userRepo      database.UserRepository
+	authenticator directory.Authenticator
+	adminGroups   []string
+}
+
+// NewLdapAuthProvider creates the user on the first login from the directory
+// attributes. When adminGroups is set the role follows the group membership
+// on every login, otherwise roles are managed in the app
+func NewLdapAuthProvider(ur database.UserRepository, a directory.Authenticator, adminGroups []string) AuthProvider {
+	return ldapAuthProvider{
+		userRepo:      ur,
+		authenticator: a,
+		adminGroups:   adminGroups,
+	}
+}
+
+func (p ldapAuthProvider) Authenticate(email, password string) (domain.User, error) {
+	entry, err := p.authenticator.Authenticate(email, password)
+	if err != nil {
+		if errors.Is(err, directory.ErrInvalidCredentials) {
+			return domain.User{}, ErrInvalidCredentials
+		}
+		return domain.User{}, err
+	}
+
+	u, err := p.userRepo.FindByEmail(entry.Email)
+	if errors.Is(err, db.ErrNoMoreRows) {
+		return p.createUser(entry)
+	} else if err != nil {
+		return domain.User{}, err
+	}
+
+	// otherwise anyone could register the email of a directory user first
+	// and keep the password they chose once the account gets linked
+	if !u.IsEmailVerified() {
+		return domain.User{}, ErrLdapAccountConflict
+	}
+
+	changed := false
+	// the directory takes the account over, a local password would be a second way in
+	if u.Password != "" {
+		u.Password = ""
+		changed = true
+	}
+	if len(p.adminGroups) > 0 {
+		role := p.roleOf(entry)
+		if u.Role != role {
+			u.Role = role
+			changed = true
+		}
+	}
+
+	if changed {
+		u, err = p.userRepo.Update(u)
+		if err != nil {
+			return domain.User{}, err
+		}
+	}
+
+	return u, nil
+}
+
+// createUser leaves the password empty, the directory stays the only way to log in
+func (p ldapAuthProvider) createUser(entry directory.Entry) (domain.User, error) {
+	firstName := entry.FirstName
+	if firstName == "" {
+		firstName, _, _ = strings.Cut(entry.Email, "@")
+	}
+
+	// the directory is trusted with the email, so it counts as verified
+	now := time.Now()
+	return p.userRepo.Save(domain.User{
+		Email:           entry.Email,
+		FirstName:       truncate(firstName, maxNameLength),
+		SecondName:      truncate(entry.SecondName, maxNameLength),
+		Role:            p.roleOf(entry),
+		EmailVerifiedAt: &now,
+	})
+}
+
+func (p ldapAuthProvider) roleOf(entry directory.Entry) domain.Role {
+	for _, group := range entry.Groups {
+		for _, admin := range p.adminGroups {
+			if strings.EqualFold(group, admin) {
+				return domain.AdminRole
+			}
+		}
+	}
+	return domain.CustomerRole
+}
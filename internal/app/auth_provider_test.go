@@ -0,0 +1,164 @@
+package app
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/database"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/directory"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/upper/db/v4"
+)
+
+const (
+	testServiceDn       = "cn=service,dc=example,dc=com"
+	testServicePassword = "service-secret"
+	testUserDn          = "uid=jane,ou=people,dc=example,dc=com"
+	testUserPassword    = "directory-secret"
+)
+
+// stubConn is an in-process stand-in for the directory with a single person
+type stubConn struct {
+	groups []string
+}
+
+func (c stubConn) Bind(username, password string) error {
+	if (username == testServiceDn && password == testServicePassword) ||
+		(username == testUserDn && password == testUserPassword) {
+		return nil
+	}
+	return ldap.NewError(ldap.LDAPResultInvalidCredentials, errors.New("invalid credentials"))
+}
+
+func (c stubConn) Search(req *ldap.SearchRequest) (*ldap.SearchResult, error) {
+	if !strings.Contains(req.Filter, "jane@example.com") {
+		return &ldap.SearchResult{}, nil
+	}
+
+	return &ldap.SearchResult{Entries: []*ldap.Entry{
+		ldap.NewEntry(testUserDn, map[string][]string{
+			"mail":      {"jane@example.com"},
+			"givenName": {"Jane"},
+			"sn":        {"Doe"},
+			"memberOf":  c.groups,
+		}),
+	}}, nil
+}
+
+func (c stubConn) Close() error {
+	return nil
+}
+
+// stubUserRepository keeps users in memory, the methods the provider doesn't use panic
+type stubUserRepository struct {
+	database.UserRepository
+	users map[string]domain.User
+}
+
+func (r *stubUserRepository) FindByEmail(email string) (domain.User, error) {
+	u, ok := r.users[strings.ToLower(email)]
+	if !ok {
+		return domain.User{}, db.ErrNoMoreRows
+	}
+	return u, nil
+}
+
+func (r *stubUserRepository) Save(user domain.User) (domain.User, error) {
+	user.Id = uint64(len(r.users) + 1)
+	r.users[strings.ToLower(user.Email)] = user
+	return user, nil
+}
+
+func (r *stubUserRepository) Update(user domain.User) (domain.User, error) {
+	r.users[strings.ToLower(user.Email)] = user
+	return user, nil
+}
+
+func newTestLdapProvider(repo *stubUserRepository, groups []string) AuthProvider {
+	auth := directory.NewAuthenticatorWithDialer(directory.Config{
+		BindDn:         testServiceDn,
+		BindPassword:   testServicePassword,
+		BaseDn:         "dc=example,dc=com",
+		UserFilter:     "(&(objectClass=person)(mail=%s))",
+		EmailAttr:      "mail",
+		FirstNameAttr:  "givenName",
+		SecondNameAttr: "sn",
+		GroupsAttr:     "memberOf",
+	}, func() (directory.Conn, error) {
+		return stubConn{groups: groups}, nil
+	})
+
+	return NewLdapAuthProvider(repo, auth, []string{"cn=admins,dc=example,dc=com"})
+}
+
+func TestLdapAuthProviderCreatesUserOnFirstLogin(t *testing.T) {
+	repo := &stubUserRepository{users: map[string]domain.User{}}
+	p := newTestLdapProvider(repo, []string{"cn=admins,dc=example,dc=com"})
+
+	u, err := p.Authenticate("jane@example.com", testUserPassword)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if u.Email != "jane@example.com" || u.FirstName != "Jane" || u.SecondName != "Doe" {
+		t.Errorf("attributes are not mapped: %+v", u)
+	}
+	if u.Role != domain.AdminRole {
+		t.Errorf("role = %s, want %s", u.Role, domain.AdminRole)
+	}
+	if !u.IsEmailVerified() || u.Password != "" {
+		t.Errorf("directory user must be verified and have no password: %+v", u)
+	}
+}
+
+func TestLdapAuthProviderRejectsWrongPassword(t *testing.T) {
+	repo := &stubUserRepository{users: map[string]domain.User{}}
+	p := newTestLdapProvider(repo, nil)
+
+	_, err := p.Authenticate("jane@example.com", "wrong")
+	if !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("err = %v, want %v", err, ErrInvalidCredentials)
+	}
+	if len(repo.users) != 0 {
+		t.Errorf("no user must be created")
+	}
+}
+
+func TestLdapAuthProviderRefusesUnverifiedLocalAccount(t *testing.T) {
+	squatter := domain.User{Id: 7, Email: "jane@example.com", Password: "attacker-hash", Role: domain.CustomerRole}
+	repo := &stubUserRepository{users: map[string]domain.User{"jane@example.com": squatter}}
+	p := newTestLdapProvider(repo, []string{"cn=admins,dc=example,dc=com"})
+
+	_, err := p.Authenticate("jane@example.com", testUserPassword)
+	if !errors.Is(err, ErrLdapAccountConflict) {
+		t.Fatalf("err = %v, want %v", err, ErrLdapAccountConflict)
+	}
+	if repo.users["jane@example.com"] != squatter {
+		t.Errorf("unverified account must stay untouched: %+v", repo.users["jane@example.com"])
+	}
+}
+
+func TestLdapAuthProviderTakesOverVerifiedLocalAccount(t *testing.T) {
+	verified := time.Now()
+	local := domain.User{Id: 7, Email: "jane@example.com", Password: "local-hash", Role: domain.CustomerRole, EmailVerifiedAt: &verified}
+	repo := &stubUserRepository{users: map[string]domain.User{"jane@example.com": local}}
+	p := newTestLdapProvider(repo, []string{"cn=admins,dc=example,dc=com"})
+
+	u, err := p.Authenticate("jane@example.com", testUserPassword)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if u.Id != local.Id {
+		t.Errorf("id = %d, want the existing account %d", u.Id, local.Id)
+	}
+	if u.Password != "" {
+		t.Errorf("local password must be cleared")
+	}
+	if u.Role != domain.AdminRole {
+		t.Errorf("role = %s, want %s", u.Role, domain.AdminRole)
+	}
+}
@@ -4,106 +4,394 @@ import (
 	"errors"
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/database"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/hashing"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/jwtkeys"
 	"github.com/go-chi/jwtauth/v5"
 	"github.com/google/uuid"
 	"github.com/upper/db/v4"
-	"golang.org/x/crypto/bcrypt"
 	"log"
+	"strconv"
 	"time"
 )
 
+const (
+	maxUserAgentLength  = 255
+	mfaChallengePurpose = "mfa-challenge"
+	mfaChallengeTTL     = 5 * time.Minute
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrSessionNotFound     = errors.New("session not found")
+	ErrInvalidOldPassword  = errors.New("old password is incorrect")
+	ErrInvalidMfaToken     = errors.New("mfa token is invalid or expired")
+	ErrUserSuspended       = errors.New("account is suspended")
+	ErrAccountDeleted      = errors.New("account is deleted")
+	ErrInvalidCredentials  = errors.New("invalid credentials")
+)
+
 type AuthService interface {
-	Register(user domain.User) (domain.User, string, error)
-	Login(user domain.User) (domain.User, string, error)
-	Logout(sess domain.Session) error
+	Register(user domain.User, device domain.Device) (domain.User, domain.AuthTokens, error)
+	Login(user domain.User, device domain.Device) (domain.User, domain.AuthTokens, error)
+	LoginMfa(mfaToken, code string, device domain.Device) (domain.User, domain.AuthTokens, error)
+	CompleteLogin(user domain.User, device domain.Device, method domain.LoginMethod) (domain.AuthTokens, error)
+	Refresh(refreshToken string, device domain.Device) (domain.User, domain.AuthTokens, error)
+	Logout(sess domain.Session, device domain.Device) error
 	Check(sess domain.Session) error
-	GenerateJwt(user domain.User) (string, error)
+	GenerateTokens(user domain.User, device domain.Device) (domain.AuthTokens, error)
+	IssueAccessToken(sess domain.Session) (string, error)
+	ChangePassword(user domain.User, cp domain.ChangePassword, device domain.Device) (domain.AuthTokens, error)
+
+	FindSessions(userId uint64) ([]domain.Session, error)
+	RevokeSession(sess domain.Session) error
+	RevokeOtherSessions(sess domain.Session) error
+	DeleteExpiredSessions() error
 }
 
 type authService struct {
-	authRepo  database.SessionRepository
-	userRepo  database.UserRepository
-	tokenAuth *jwtauth.JWTAuth
-	jwtTTL    time.Duration
+	authRepo         database.SessionRepository
+	userRepo         database.UserRepository
+	refreshTokenRepo database.RefreshTokenRepository
+	twoFactorService TwoFactorService
+	loginThrottle    LoginThrottleService
+	securityEvents   SecurityEventService
+	authProviders    []AuthProvider
+	signer           Signer
+	passwordHasher   hashing.PasswordHasher
+	jwtKeys          jwtkeys.Manager
+	jwtTTL           time.Duration
+	refreshTokenTTL  time.Duration
 }
 
-func NewAuthService(ar database.SessionRepository, ur database.UserRepository, ta *jwtauth.JWTAuth, jwtTtl time.Duration) AuthService {
+func NewAuthService(
+	ar database.SessionRepository,
+	ur database.UserRepository,
+	rtr database.RefreshTokenRepository,
+	tfs TwoFactorService,
+	lts LoginThrottleService,
+	ses SecurityEventService,
+	aps []AuthProvider,
+	sg Signer,
+	ph hashing.PasswordHasher,
+	jk jwtkeys.Manager,
+	jwtTtl time.Duration,
+	refreshTokenTtl time.Duration,
+) AuthService {
 	return authService{
-		authRepo:  ar,
-		userRepo:  ur,
-		tokenAuth: ta,
-		jwtTTL:    jwtTtl,
+		authRepo:         ar,
+		userRepo:         ur,
+		refreshTokenRepo: rtr,
+		twoFactorService: tfs,
+		loginThrottle:    lts,
+		securityEvents:   ses,
+		authProviders:    aps,
+		signer:           sg,
+		passwordHasher:   ph,
+		jwtKeys:          jk,
+		jwtTTL:           jwtTtl,
+		refreshTokenTTL:  refreshTokenTtl,
 	}
 }
 
-func (s authService) Register(user domain.User) (domain.User, string, error) {
+func (s authService) Register(user domain.User, device domain.Device) (domain.User, domain.AuthTokens, error) {
 	_, err := s.userRepo.FindByEmail(user.Email)
 	if err == nil {
 		log.Printf("invalid credentials")
-		return domain.User{}, "", errors.New("invalid credentials")
+		return domain.User{}, domain.AuthTokens{}, errors.New("invalid credentials")
 	} else if !errors.Is(err, db.ErrNoMoreRows) {
 		log.Print(err)
-		return domain.User{}, "", err
+		return domain.User{}, domain.AuthTokens{}, err
 	}
 
-	user.Password, err = s.generatePasswordHash(user.Password)
+	user.Password, err = s.passwordHasher.Hash(user.Password)
 	if err != nil {
 		log.Printf("UserService: %s", err)
-		return domain.User{}, "", err
+		return domain.User{}, domain.AuthTokens{}, err
 	}
 
+	// admins from config are promoted only once they prove they own the email
+	user.Role = domain.CustomerRole
+
 	user, err = s.userRepo.Save(user)
 	if err != nil {
 		log.Print(err)
-		return domain.User{}, "", err
+		return domain.User{}, domain.AuthTokens{}, err
+	}
+
+	sess, tokens, err := s.startSession(user, device)
+	if err != nil {
+		return domain.User{}, domain.AuthTokens{}, err
+	}
+	s.securityEvents.Record(sessionEvent(domain.SecurityEventRegister, sess, device))
+
+	return user, tokens, nil
+}
+
+func (s authService) Login(user domain.User, device domain.Device) (domain.User, domain.AuthTokens, error) {
+	err := s.loginThrottle.Check(user.Email, device.Ip)
+	if err != nil {
+		return domain.User{}, domain.AuthTokens{}, err
+	}
+
+	u, err := s.authenticate(user.Email, user.Password)
+	if err != nil {
+		// unknown emails are counted too, so guessing them is throttled the same way
+		if errors.Is(err, ErrInvalidCredentials) {
+			s.loginFailed(user.Email, device, "invalid_credentials")
+		}
+		return domain.User{}, domain.AuthTokens{}, err
+	}
+
+	tokens, err := s.CompleteLogin(u, device, domain.LoginMethodPassword)
+	if err != nil {
+		return domain.User{}, domain.AuthTokens{}, err
+	}
+
+	return u, tokens, nil
+}
+
+// CompleteLogin starts a session for a user whose first factor is already checked,
+// with 2FA enabled only the mfa challenge is returned
+func (s authService) CompleteLogin(u domain.User, device domain.Device, method domain.LoginMethod) (domain.AuthTokens, error) {
+	if u.IsSuspended() {
+		e := securityEvent(domain.SecurityEventLoginFailure, u.Id, device)
+		e.Email = u.Email
+		e.Details = "suspended"
+		s.securityEvents.Record(e)
+		return domain.AuthTokens{}, ErrUserSuspended
+	}
+
+	if u.IsTwoFactorEnabled() {
+		mfaToken := s.signer.Sign(mfaChallengePurpose, strconv.FormatUint(u.Id, 10), mfaChallengeTTL)
+		return domain.AuthTokens{MfaToken: mfaToken}, nil
+	}
+
+	sess, tokens, err := s.startSession(u, device)
+	if err != nil {
+		log.Printf("AuthService->s.startSession %s", err)
+		return domain.AuthTokens{}, err
+	}
+	// the failures are forgiven only once a session is issued,
+	// otherwise a known password would reset the count of code guesses
+	s.loginThrottle.RegisterSuccess(u.Email)
+	s.loginSucceeded(u, sess, device, method)
+
+	return tokens, nil
+}
+
+func (s authService) LoginMfa(mfaToken, code string, device domain.Device) (domain.User, domain.AuthTokens, error) {
+	payload, err := s.signer.Verify(mfaChallengePurpose, mfaToken)
+	if err != nil {
+		return domain.User{}, domain.AuthTokens{}, ErrInvalidMfaToken
+	}
+
+	userId, err := strconv.ParseUint(payload, 10, 64)
+	if err != nil {
+		return domain.User{}, domain.AuthTokens{}, ErrInvalidMfaToken
+	}
+
+	u, err := s.userRepo.FindById(userId)
+	if err != nil {
+		if errors.Is(err, db.ErrNoMoreRows) {
+			return domain.User{}, domain.AuthTokens{}, ErrInvalidMfaToken
+		}
+		log.Printf("AuthService: %s", err)
+		return domain.User{}, domain.AuthTokens{}, err
+	}
+
+	if u.IsSuspended() {
+		return domain.User{}, domain.AuthTokens{}, ErrUserSuspended
+	}
+
+	// the challenge token is valid for minutes, so codes are throttled like passwords
+	err = s.loginThrottle.Check(u.Email, device.Ip)
+	if err != nil {
+		return domain.User{}, domain.AuthTokens{}, err
+	}
+
+	err = s.twoFactorService.Verify(u, code)
+	if err != nil {
+		if errors.Is(err, ErrTwoFactorDisabled) {
+			return domain.User{}, domain.AuthTokens{}, ErrInvalidMfaToken
+		}
+		if errors.Is(err, ErrInvalidTwoFactorCode) {
+			s.loginFailed(u.Email, device, "invalid_mfa_code")
+		}
+		return domain.User{}, domain.AuthTokens{}, err
 	}
 
-	token, err := s.GenerateJwt(user)
-	return user, token, err
+	sess, tokens, err := s.startSession(u, device)
+	if err != nil {
+		log.Printf("AuthService->s.startSession %s", err)
+		return domain.User{}, domain.AuthTokens{}, err
+	}
+	s.loginThrottle.RegisterSuccess(u.Email)
+	s.loginSucceeded(u, sess, device, domain.LoginMethodMfa)
+
+	return u, tokens, nil
 }
 
-func (s authService) Login(user domain.User) (domain.User, string, error) {
-	u, err := s.userRepo.FindByEmail(user.Email)
+func (s authService) Refresh(refreshToken string, device domain.Device) (domain.User, domain.AuthTokens, error) {
+	rt, err := s.refreshTokenRepo.FindByTokenHash(hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, db.ErrNoMoreRows) {
+			return domain.User{}, domain.AuthTokens{}, ErrInvalidRefreshToken
+		}
+		log.Printf("AuthService: %s", err)
+		return domain.User{}, domain.AuthTokens{}, err
+	}
+
+	sess := domain.Session{UserId: rt.UserId, UUID: rt.SessionUUID}
+	if rt.UsedDate != nil {
+		s.revokeFamily(sess, device)
+		return domain.User{}, domain.AuthTokens{}, ErrInvalidRefreshToken
+	}
+
+	if time.Now().After(rt.ExpiresAt) {
+		return domain.User{}, domain.AuthTokens{}, ErrInvalidRefreshToken
+	}
+
+	fresh, err := s.refreshTokenRepo.MarkUsed(rt.Id)
+	if err != nil {
+		log.Printf("AuthService: %s", err)
+		return domain.User{}, domain.AuthTokens{}, err
+	}
+	if !fresh {
+		s.revokeFamily(sess, device)
+		return domain.User{}, domain.AuthTokens{}, ErrInvalidRefreshToken
+	}
+
+	u, err := s.userRepo.FindById(rt.UserId)
 	if err != nil {
 		if errors.Is(err, db.ErrNoMoreRows) {
-			log.Printf("AuthService: failed to find user %s", err)
+			return domain.User{}, domain.AuthTokens{}, ErrInvalidRefreshToken
 		}
-		log.Printf("AuthService: login error %s", err)
-		return domain.User{}, "", err
+		log.Printf("AuthService: %s", err)
+		return domain.User{}, domain.AuthTokens{}, err
+	}
+	if u.IsSuspended() {
+		return domain.User{}, domain.AuthTokens{}, ErrUserSuspended
 	}
 
-	valid := s.checkPasswordHash(user.Password, u.Password)
-	if !valid {
-		return domain.User{}, "", errors.New("invalid credentials")
+	tokens, err := s.issueTokens(sess)
+	if err != nil {
+		return domain.User{}, domain.AuthTokens{}, err
 	}
 
-	token, err := s.GenerateJwt(u)
+	// the session lives as long as its newest refresh token
+	err = s.authRepo.Prolong(sess, time.Now().Add(s.refreshTokenTTL))
 	if err != nil {
-		log.Printf("AuthService->s.GenerateJwt %s", err)
-		return domain.User{}, "", err
+		log.Printf("AuthService: %s", err)
+		return domain.User{}, domain.AuthTokens{}, err
 	}
+	s.securityEvents.Record(sessionEvent(domain.SecurityEventTokenRefresh, sess, device))
 
-	return u, token, err
+	return u, tokens, nil
 }
 
-func (s authService) Logout(sess domain.Session) error {
-	return s.authRepo.Delete(sess)
+func (s authService) Logout(sess domain.Session, device domain.Device) error {
+	err := s.authRepo.Delete(sess)
+	if err != nil {
+		return err
+	}
+	s.securityEvents.Record(sessionEvent(domain.SecurityEventLogout, sess, device))
+
+	return nil
 }
 
-func (s authService) GenerateJwt(user domain.User) (string, error) {
-	sess := domain.Session{UserId: user.Id, UUID: uuid.New()}
+func (s authService) GenerateTokens(user domain.User, device domain.Device) (domain.AuthTokens, error) {
+	_, tokens, err := s.startSession(user, device)
+	return tokens, err
+}
+
+func (s authService) startSession(user domain.User, device domain.Device) (domain.Session, domain.AuthTokens, error) {
+	// cut by runes, a byte cut could split a multibyte character
+	userAgent := truncate(device.UserAgent, maxUserAgentLength)
+
+	sess := domain.Session{
+		UserId:    user.Id,
+		UUID:      uuid.New(),
+		UserAgent: userAgent,
+		Ip:        device.Ip,
+		ExpiresAt: time.Now().Add(s.refreshTokenTTL),
+	}
 	err := s.authRepo.Save(sess)
 	if err != nil {
 		log.Printf("AuthService: failed to save session %s", err)
+		return domain.Session{}, domain.AuthTokens{}, err
+	}
+
+	tokens, err := s.issueTokens(sess)
+	if err != nil {
+		return domain.Session{}, domain.AuthTokens{}, err
+	}
+
+	return sess, tokens, nil
+}
+
+func (s authService) issueTokens(sess domain.Session) (domain.AuthTokens, error) {
+	access, err := s.generateJwt(sess)
+	if err != nil {
+		log.Printf("AuthService: failed to generate jwt %s", err)
+		return domain.AuthTokens{}, err
+	}
+
+	refresh, err := generateToken()
+	if err != nil {
+		log.Printf("AuthService: failed to generate refresh token %s", err)
+		return domain.AuthTokens{}, err
+	}
+
+	_, err = s.refreshTokenRepo.Save(domain.RefreshToken{
+		UserId:      sess.UserId,
+		SessionUUID: sess.UUID,
+		TokenHash:   hashToken(refresh),
+		ExpiresAt:   time.Now().Add(s.refreshTokenTTL),
+	})
+	if err != nil {
+		log.Printf("AuthService: failed to save refresh token %s", err)
+		return domain.AuthTokens{}, err
+	}
+
+	return domain.AuthTokens{AccessToken: access, RefreshToken: refresh}, nil
+}
+
+// revokeFamily ends the session, which deletes every refresh token issued for it
+func (s authService) revokeFamily(sess domain.Session, device domain.Device) {
+	log.Printf("AuthService: refresh token reuse detected for user %d, revoking session %s", sess.UserId, sess.UUID)
+	err := s.authRepo.Delete(sess)
+	if err != nil {
+		log.Printf("AuthService: failed to revoke session %s", err)
+	}
+
+	e := sessionEvent(domain.SecurityEventTokenRefresh, sess, device)
+	e.Details = "reuse_detected"
+	s.securityEvents.Record(e)
+}
+
+// IssueAccessToken signs an access token for an already saved session,
+// no refresh token is issued so the session can not outlive its expiry
+func (s authService) IssueAccessToken(sess domain.Session) (string, error) {
+	access, err := s.generateJwt(sess)
+	if err != nil {
+		log.Printf("AuthService: failed to generate jwt %s", err)
 		return "", err
 	}
 
+	return access, nil
+}
+
+func (s authService) generateJwt(sess domain.Session) (string, error) {
 	claims := map[string]interface{}{
 		"user_id": sess.UserId,
 		"uuid":    sess.UUID,
 	}
+	if sess.IsImpersonation() {
+		claims["actor_id"] = *sess.ActorId
+		claims["allow_destructive"] = sess.AllowDestructive
+	}
 	jwtauth.SetExpiryIn(claims, s.jwtTTL)
-	_, tokenString, err := s.tokenAuth.Encode(claims)
+	tokenString, err := s.jwtKeys.Sign(claims)
 	if err != nil {
 		return "", err
 	}
@@ -112,14 +400,158 @@ func (s authService) GenerateJwt(user domain.User) (string, error) {
 }
 
 func (s authService) Check(sess domain.Session) error {
-	return s.authRepo.Exists(sess)
+	err := s.authRepo.Exists(sess)
+	if err != nil {
+		return err
+	}
+
+	err = s.authRepo.Touch(sess)
+	if err != nil {
+		log.Printf("AuthService: failed to update session activity %s", err)
+	}
+
+	return nil
+}
+
+func (s authService) ChangePassword(user domain.User, cp domain.ChangePassword, device domain.Device) (domain.AuthTokens, error) {
+	if !s.checkPassword(cp.OldPassword, user.Password) {
+		return domain.AuthTokens{}, ErrInvalidOldPassword
+	}
+
+	var err error
+	user.Password, err = s.passwordHasher.Hash(cp.NewPassword)
+	if err != nil {
+		log.Printf("AuthService: %s", err)
+		return domain.AuthTokens{}, err
+	}
+
+	user, err = s.userRepo.Update(user)
+	if err != nil {
+		log.Printf("AuthService: %s", err)
+		return domain.AuthTokens{}, err
+	}
+
+	// every existing session, including the current one, is ended
+	// and the current device gets a brand new session instead
+	err = s.authRepo.DeleteByUser(user.Id)
+	if err != nil {
+		log.Printf("AuthService: %s", err)
+		return domain.AuthTokens{}, err
+	}
+
+	sess, tokens, err := s.startSession(user, device)
+	if err != nil {
+		return domain.AuthTokens{}, err
+	}
+	s.securityEvents.Record(sessionEvent(domain.SecurityEventPasswordChange, sess, device))
+
+	return tokens, nil
+}
+
+func (s authService) FindSessions(userId uint64) ([]domain.Session, error) {
+	ss, err := s.authRepo.FindByUser(userId)
+	if err != nil {
+		log.Printf("AuthService: %s", err)
+		return nil, err
+	}
+
+	return ss, nil
+}
+
+func (s authService) RevokeSession(sess domain.Session) error {
+	err := s.authRepo.Exists(sess)
+	if err != nil {
+		return ErrSessionNotFound
+	}
+
+	err = s.authRepo.Delete(sess)
+	if err != nil {
+		log.Printf("AuthService: %s", err)
+		return err
+	}
+
+	return nil
+}
+
+func (s authService) RevokeOtherSessions(sess domain.Session) error {
+	err := s.authRepo.DeleteOthers(sess)
+	if err != nil {
+		log.Printf("AuthService: %s", err)
+		return err
+	}
+
+	return nil
+}
+
+func (s authService) DeleteExpiredSessions() error {
+	return s.authRepo.DeleteExpired()
 }
 
-func (s authService) generatePasswordHash(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	return string(bytes), err
+func (s authService) loginSucceeded(u domain.User, sess domain.Session, device domain.Device, method domain.LoginMethod) {
+	e := sessionEvent(domain.SecurityEventLoginSuccess, sess, device)
+	e.Email = u.Email
+	e.Details = string(method)
+	s.securityEvents.Record(e)
 }
 
-func (s authService) checkPasswordHash(password, hash string) bool {
-	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+// loginFailed counts the failure for throttling and records it,
+// together with the lockout when this failure triggered one
+func (s authService) loginFailed(email string, device domain.Device, reason string) {
+	lockedOut := s.loginThrottle.RegisterFailure(email, device.Ip)
+
+	var userId uint64
+	u, err := s.userRepo.FindByEmail(email)
+	if err == nil {
+		userId = u.Id
+	} else if !errors.Is(err, db.ErrNoMoreRows) {
+		log.Printf("AuthService: %s", err)
+	}
+
+	e := securityEvent(domain.SecurityEventLoginFailure, userId, device)
+	e.Email = email
+	e.Details = reason
+	s.securityEvents.Record(e)
+
+	if lockedOut {
+		e.Type = domain.SecurityEventLockout
+		s.securityEvents.Record(e)
+	}
+}
+
+func (s authService) checkPassword(password, hash string) bool {
+	valid, err := s.passwordHasher.Verify(password, hash)
+	if err != nil {
+		log.Printf("AuthService: %s", err)
+		return false
+	}
+	return valid
+}
+
+// authenticate tries the providers in order, an unavailable provider
+// doesn't stop the chain, its error is returned only when nobody matched
+func (s authService) authenticate(email, password string) (domain.User, error) {
+	var lastErr error
+	for _, p := range s.authProviders {
+		u, err := p.Authenticate(email, password)
+		if err == nil {
+			return u, nil
+		}
+		if !errors.Is(err, ErrInvalidCredentials) {
+			log.Printf("AuthService: %s", err)
+			lastErr = err
+		}
+	}
+
+	if lastErr != nil {
+		return domain.User{}, lastErr
+	}
+	return domain.User{}, ErrInvalidCredentials
+}
+
+func truncate(s string, length int) string {
+	runes := []rune(s)
+	if len(runes) > length {
+		return string(runes[:length])
+	}
+	return s
 }
@@ -0,0 +1,151 @@
+package app
+
+import (
+	"errors"
+	"log"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/database"
+	"github.com/upper/db/v4"
+)
+
+var ErrAccessDenied = errors.New("access denied")
+
+type AuthorizationService interface {
+	TaskPermission(userId uint64, t domain.Task) (domain.Permission, error)
+	CanViewTask(userId uint64, t domain.Task) error
+	CanEditTask(userId uint64, t domain.Task) error
+	CanUpdateTaskStatus(userId uint64, t domain.Task) error
+	CanManageTask(userId uint64, t domain.Task) error
+
+	WorkspaceRole(userId, workspaceId uint64) (domain.WorkspaceRole, error)
+	CanViewWorkspace(userId, workspaceId uint64) error
+	CanEditWorkspace(userId, workspaceId uint64) error
+	CanManageWorkspace(userId, workspaceId uint64) error
+}
+
+type authorizationService struct {
+	taskShareRepo       database.TaskShareRepository
+	workspaceMemberRepo database.WorkspaceMemberRepository
+}
+
+func NewAuthorizationService(tsr database.TaskShareRepository, wmr database.WorkspaceMemberRepository) AuthorizationService {
+	return authorizationService{
+		taskShareRepo:       tsr,
+		workspaceMemberRepo: wmr,
+	}
+}
+
+func (s authorizationService) TaskPermission(userId uint64, t domain.Task) (domain.Permission, error) {
+	if t.WorkspaceId == nil && t.UserId == userId {
+		return domain.OwnerPermission, nil
+	}
+
+	// workspace role and personal share are combined, the stronger one wins
+	var p domain.Permission
+	if t.WorkspaceId != nil {
+		role, err := s.WorkspaceRole(userId, *t.WorkspaceId)
+		if err == nil {
+			p = role.TaskPermission()
+			// the author controls a workspace task only while still a member
+			if t.UserId == userId {
+				p = domain.OwnerPermission
+			}
+		} else if !errors.Is(err, ErrAccessDenied) {
+			return "", err
+		}
+	}
+
+	share, err := s.taskShareRepo.Find(t.Id, userId)
+	if err == nil {
+		if share.Permission.Allows(p) {
+			p = share.Permission
+		}
+	} else if !errors.Is(err, db.ErrNoMoreRows) {
+		log.Printf("AuthorizationService: %s", err)
+		return "", err
+	}
+
+	if p == "" {
+		return "", ErrAccessDenied
+	}
+
+	return p, nil
+}
+
+func (s authorizationService) CanViewTask(userId uint64, t domain.Task) error {
+	if isAssignee(userId, t) {
+		return nil
+	}
+	return s.requireTaskPermission(userId, t, domain.ViewerPermission)
+}
+
+func (s authorizationService) CanEditTask(userId uint64, t domain.Task) error {
+	return s.requireTaskPermission(userId, t, domain.EditorPermission)
+}
+
+func (s authorizationService) CanUpdateTaskStatus(userId uint64, t domain.Task) error {
+	if isAssignee(userId, t) {
+		return nil
+	}
+	return s.requireTaskPermission(userId, t, domain.EditorPermission)
+}
+
+func (s authorizationService) CanManageTask(userId uint64, t domain.Task) error {
+	return s.requireTaskPermission(userId, t, domain.OwnerPermission)
+}
+
+func (s authorizationService) requireTaskPermission(userId uint64, t domain.Task, required domain.Permission) error {
+	p, err := s.TaskPermission(userId, t)
+	if err != nil {
+		return err
+	}
+
+	if !p.Allows(required) {
+		return ErrAccessDenied
+	}
+
+	return nil
+}
+
+func isAssignee(userId uint64, t domain.Task) bool {
+	return t.AssigneeId != nil && *t.AssigneeId == userId
+}
+
+func (s authorizationService) WorkspaceRole(userId, workspaceId uint64) (domain.WorkspaceRole, error) {
+	member, err := s.workspaceMemberRepo.Find(workspaceId, userId)
+	if err != nil {
+		if errors.Is(err, db.ErrNoMoreRows) {
+			return "", ErrAccessDenied
+		}
+		log.Printf("AuthorizationService: %s", err)
+		return "", err
+	}
+
+	return member.Role, nil
+}
+
+func (s authorizationService) CanViewWorkspace(userId, workspaceId uint64) error {
+	return s.requireWorkspaceRole(userId, workspaceId, domain.WorkspaceGuestRole)
+}
+
+func (s authorizationService) CanEditWorkspace(userId, workspaceId uint64) error {
+	return s.requireWorkspaceRole(userId, workspaceId, domain.WorkspaceMemberRole)
+}
+
+func (s authorizationService) CanManageWorkspace(userId, workspaceId uint64) error {
+	return s.requireWorkspaceRole(userId, workspaceId, domain.WorkspaceAdminRole)
+}
+
+func (s authorizationService) requireWorkspaceRole(userId, workspaceId uint64, required domain.WorkspaceRole) error {
+	role, err := s.WorkspaceRole(userId, workspaceId)
+	if err != nil {
+		return err
+	}
+
+	if !role.Allows(required) {
+		return ErrAccessDenied
+	}
+
+	return nil
+}
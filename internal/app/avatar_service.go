@@ -0,0 +1,134 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"log"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/database"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/filesystem"
+	"github.com/google/uuid"
+)
+
+const maxAvatarDimension = 4096
+
+var ErrUnsupportedImage = errors.New("unsupported image: use png, jpeg or gif up to 4096x4096")
+
+type AvatarService interface {
+	Upload(user domain.User, content []byte) (domain.User, error)
+}
+
+type avatarService struct {
+	userRepo     database.UserRepository
+	imageStorage filesystem.ImageStorageService
+}
+
+func NewAvatarService(ur database.UserRepository, is filesystem.ImageStorageService) AvatarService {
+	return avatarService{
+		userRepo:     ur,
+		imageStorage: is,
+	}
+}
+
+func (s avatarService) Upload(user domain.User, content []byte) (domain.User, error) {
+	// check dimensions before decoding the whole image
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		log.Printf("AvatarService: %s", err)
+		return domain.User{}, ErrUnsupportedImage
+	}
+	if format != "png" && format != "jpeg" && format != "gif" ||
+		cfg.Width > maxAvatarDimension || cfg.Height > maxAvatarDimension {
+		return domain.User{}, ErrUnsupportedImage
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		log.Printf("AvatarService: %s", err)
+		return domain.User{}, ErrUnsupportedImage
+	}
+
+	// re-encoding to PNG drops all metadata of the original (EXIF, comments etc.)
+	name := uuid.New().String()
+	for _, size := range domain.AvatarSizes {
+		var buf bytes.Buffer
+		err = png.Encode(&buf, thumbnail(img, size))
+		if err != nil {
+			log.Printf("AvatarService: %s", err)
+			return domain.User{}, err
+		}
+
+		err = s.imageStorage.SaveImage(domain.AvatarPath(name, size), buf.Bytes())
+		if err != nil {
+			log.Printf("AvatarService: %s", err)
+			return domain.User{}, err
+		}
+	}
+
+	old := user.Avatar
+	user.Avatar = &name
+	user, err = s.userRepo.Update(user)
+	if err != nil {
+		log.Printf("AvatarService: %s", err)
+		s.removeAvatar(name)
+		return domain.User{}, err
+	}
+
+	if old != nil {
+		s.removeAvatar(*old)
+	}
+
+	return user, nil
+}
+
+func (s avatarService) removeAvatar(name string) {
+	for _, size := range domain.AvatarSizes {
+		err := s.imageStorage.RemoveImage(domain.AvatarPath(name, size))
+		if err != nil {
+			log.Printf("AvatarService: %s", err)
+		}
+	}
+}
+
+// thumbnail crops the central square of the image and scales it to size x size,
+// averaging all source pixels that fall into each destination pixel
+func thumbnail(src image.Image, size int) *image.NRGBA {
+	b := src.Bounds()
+	side := min(b.Dx(), b.Dy())
+	x0 := b.Min.X + (b.Dx()-side)/2
+	y0 := b.Min.Y + (b.Dy()-side)/2
+
+	dst := image.NewNRGBA(image.Rect(0, 0, size, size))
+	for dy := 0; dy < size; dy++ {
+		sy0 := y0 + dy*side/size
+		sy1 := max(y0+(dy+1)*side/size, sy0+1)
+		for dx := 0; dx < size; dx++ {
+			sx0 := x0 + dx*side/size
+			sx1 := max(x0+(dx+1)*side/size, sx0+1)
+
+			var r, g, bl, a, n uint64
+			for y := sy0; y < sy1; y++ {
+				for x := sx0; x < sx1; x++ {
+					pr, pg, pb, pa := src.At(x, y).RGBA()
+					r, g, bl, a = r+uint64(pr), g+uint64(pg), bl+uint64(pb), a+uint64(pa)
+					n++
+				}
+			}
+
+			dst.Set(dx, dy, color.RGBA64{
+				R: uint16(r / n),
+				G: uint16(g / n),
+				B: uint16(bl / n),
+				A: uint16(a / n),
+			})
+		}
+	}
+
+	return dst
+}
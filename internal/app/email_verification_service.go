@@ -0,0 +1,150 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/database"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/mail"
+	"github.com/upper/db/v4"
+)
+
+const emailVerificationPurpose = "email-verification"
+
+var (
+	ErrInvalidVerificationToken = errors.New("verification link is invalid or expired")
+	ErrEmailAlreadyVerified     = errors.New("email is already verified")
+)
+
+type EmailVerificationService interface {
+	Send(user domain.User) error
+	Verify(token string) (domain.User, error)
+}
+
+type emailVerificationService struct {
+	userRepo       database.UserRepository
+	securityEvents SecurityEventService
+	signer         Signer
+	mailer         mail.Mailer
+	appUrl         string
+	linkTTL        time.Duration
+	adminEmails    []string
+}
+
+func NewEmailVerificationService(
+	ur database.UserRepository,
+	ses SecurityEventService,
+	s Signer,
+	m mail.Mailer,
+	appUrl string,
+	linkTtl time.Duration,
+	adminEmails []string,
+) EmailVerificationService {
+	return emailVerificationService{
+		userRepo:       ur,
+		securityEvents: ses,
+		signer:         s,
+		mailer:         m,
+		appUrl:         appUrl,
+		linkTTL:        linkTtl,
+		adminEmails:    adminEmails,
+	}
+}
+
+// Send mails a link bound to the current email, so changing the email
+// again makes all previously sent links useless
+func (s emailVerificationService) Send(user domain.User) error {
+	if user.IsEmailVerified() {
+		return ErrEmailAlreadyVerified
+	}
+
+	token := s.signer.Sign(emailVerificationPurpose, fmt.Sprintf("%d|%s", user.Id, user.Email), s.linkTTL)
+
+	go func() {
+		err := s.mailer.Send(mail.Message{
+			To:      user.Email,
+			Subject: "Confirm your email",
+			Body: fmt.Sprintf(
+				"Hello, %s!\n\nPlease confirm your email by following the link below. It is valid for %s.\n\n%s/api/v1/auth/email/verify?token=%s\n\nIf you did not create an account, just ignore this email.",
+				user.FirstName, s.linkTTL, s.appUrl, url.QueryEscape(token),
+			),
+		})
+		if err != nil {
+			log.Printf("EmailVerificationService: failed to send email %s", err)
+		}
+	}()
+
+	return nil
+}
+
+func (s emailVerificationService) Verify(token string) (domain.User, error) {
+	payload, err := s.signer.Verify(emailVerificationPurpose, token)
+	if err != nil {
+		return domain.User{}, ErrInvalidVerificationToken
+	}
+
+	id, email, _ := strings.Cut(payload, "|")
+	userId, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return domain.User{}, ErrInvalidVerificationToken
+	}
+
+	user, err := s.userRepo.FindById(userId)
+	if err != nil {
+		if errors.Is(err, db.ErrNoMoreRows) {
+			return domain.User{}, ErrInvalidVerificationToken
+		}
+		log.Printf("EmailVerificationService: %s", err)
+		return domain.User{}, err
+	}
+
+	if user.Email != email {
+		return domain.User{}, ErrInvalidVerificationToken
+	}
+	if user.IsEmailVerified() {
+		return user, nil
+	}
+
+	verified, err := s.userRepo.MarkEmailVerified(user.Id, email)
+	if err != nil {
+		log.Printf("EmailVerificationService: %s", err)
+		return domain.User{}, err
+	}
+	if !verified {
+		return domain.User{}, ErrInvalidVerificationToken
+	}
+
+	now := time.Now()
+	user.EmailVerifiedAt = &now
+	return s.promoteAdmin(user)
+}
+
+// promoteAdmin bootstraps the first admins from config, the rest are promoted by them.
+// It runs only after verification, otherwise whoever registers a listed email first would get the role
+func (s emailVerificationService) promoteAdmin(user domain.User) (domain.User, error) {
+	if user.Role == domain.AdminRole || !slices.ContainsFunc(s.adminEmails, func(e string) bool { return strings.EqualFold(e, user.Email) }) {
+		return user, nil
+	}
+
+	previous := user.Role
+	user.Role = domain.AdminRole
+	user, err := s.userRepo.Update(user)
+	if err != nil {
+		log.Printf("EmailVerificationService: %s", err)
+		return domain.User{}, err
+	}
+
+	e := securityEvent(domain.SecurityEventRoleChange, user.Id, domain.Device{})
+	e.Email = user.Email
+	e.Details = fmt.Sprintf("%s -> %s by ADMIN_EMAILS", previous, user.Role)
+	s.securityEvents.Record(e)
+
+	return user, nil
+}
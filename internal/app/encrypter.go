@@ -0,0 +1,78 @@
+package app
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+const encryptedPrefix = "v1."
+
+var ErrInvalidCiphertext = errors.New("ciphertext is invalid")
+
+// Encrypter keeps secrets which have to be read back, e.g. TOTP secrets, encrypted at rest.
+// The purpose is authenticated with the data, so a value can't be moved to another row or flow
+type Encrypter interface {
+	Encrypt(purpose, plaintext string) (string, error)
+	Decrypt(purpose, ciphertext string) (string, error)
+}
+
+type encrypter struct {
+	aead cipher.AEAD
+}
+
+// NewEncrypter derives its own AES-256 key from the app key,
+// so the key of the Signer is never used for anything else
+func NewEncrypter(key string) Encrypter {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte("encryption"))
+
+	block, err := aes.NewCipher(mac.Sum(nil))
+	if err != nil {
+		panic(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		panic(err)
+	}
+
+	return encrypter{
+		aead: aead,
+	}
+}
+
+func (e encrypter) Encrypt(purpose, plaintext string) (string, error) {
+	nonce := make([]byte, e.aead.NonceSize())
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return "", err
+	}
+
+	sealed := e.aead.Seal(nonce, nonce, []byte(plaintext), []byte(purpose))
+	return encryptedPrefix + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+func (e encrypter) Decrypt(purpose, ciphertext string) (string, error) {
+	data, found := strings.CutPrefix(ciphertext, encryptedPrefix)
+	if !found {
+		return "", ErrInvalidCiphertext
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(data)
+	if err != nil || len(raw) < e.aead.NonceSize() {
+		return "", ErrInvalidCiphertext
+	}
+
+	nonce, sealed := raw[:e.aead.NonceSize()], raw[e.aead.NonceSize():]
+	plain, err := e.aead.Open(nil, nonce, sealed, []byte(purpose))
+	if err != nil {
+		return "", ErrInvalidCiphertext
+	}
+
+	return string(plain), nil
+}
@@ -0,0 +1,119 @@
+package app
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/database"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrImpersonationNotAllowed = errors.New("admins can not impersonate themselves or other admins")
+	ErrNotImpersonating        = errors.New("current session is not an impersonation")
+	ErrImpersonationReadOnly   = errors.New("changes are not allowed while impersonating")
+)
+
+type ImpersonationService interface {
+	Start(actor, user domain.User, imp domain.Impersonation, device domain.Device) (domain.Session, string, error)
+	Stop(sess domain.Session, device domain.Device) error
+	Record(a domain.ImpersonationAudit)
+}
+
+type impersonationService struct {
+	sessionRepo database.SessionRepository
+	auditRepo   database.ImpersonationAuditRepository
+	authService AuthService
+	ttl         time.Duration
+}
+
+func NewImpersonationService(
+	sr database.SessionRepository,
+	iar database.ImpersonationAuditRepository,
+	as AuthService,
+	ttl time.Duration,
+) ImpersonationService {
+	return impersonationService{
+		sessionRepo: sr,
+		auditRepo:   iar,
+		authService: as,
+		ttl:         ttl,
+	}
+}
+
+// Start opens a session of the user on behalf of the admin and returns its access token
+func (s impersonationService) Start(actor, user domain.User, imp domain.Impersonation, device domain.Device) (domain.Session, string, error) {
+	if actor.Id == user.Id || user.Role == domain.AdminRole || user.DeletedDate != nil {
+		return domain.Session{}, "", ErrImpersonationNotAllowed
+	}
+	if user.IsSuspended() {
+		return domain.Session{}, "", ErrUserSuspended
+	}
+
+	// cut by runes, a byte cut could split a multibyte character
+	userAgent := truncate(device.UserAgent, maxUserAgentLength)
+
+	sess := domain.Session{
+		UserId:           user.Id,
+		UUID:             uuid.New(),
+		UserAgent:        userAgent,
+		Ip:               device.Ip,
+		ExpiresAt:        time.Now().Add(s.ttl),
+		ActorId:          &actor.Id,
+		AllowDestructive: imp.AllowDestructive,
+	}
+	err := s.sessionRepo.Save(sess)
+	if err != nil {
+		log.Printf("ImpersonationService: %s", err)
+		return domain.Session{}, "", err
+	}
+
+	token, err := s.authService.IssueAccessToken(sess)
+	if err != nil {
+		return domain.Session{}, "", err
+	}
+
+	log.Printf("ImpersonationService: admin %d started impersonating user %d (session %s)", actor.Id, user.Id, sess.UUID)
+	s.Record(domain.ImpersonationAudit{
+		SessionUUID: sess.UUID,
+		ActorId:     actor.Id,
+		UserId:      user.Id,
+		Action:      domain.ImpersonationStarted,
+		Reason:      imp.Reason,
+		Ip:          device.Ip,
+	})
+
+	return sess, token, nil
+}
+
+func (s impersonationService) Stop(sess domain.Session, device domain.Device) error {
+	if !sess.IsImpersonation() {
+		return ErrNotImpersonating
+	}
+
+	err := s.sessionRepo.Delete(sess)
+	if err != nil {
+		log.Printf("ImpersonationService: %s", err)
+		return err
+	}
+
+	s.Record(domain.ImpersonationAudit{
+		SessionUUID: sess.UUID,
+		ActorId:     *sess.ActorId,
+		UserId:      sess.UserId,
+		Action:      domain.ImpersonationStopped,
+		Ip:          device.Ip,
+	})
+
+	return nil
+}
+
+// Record never fails the request it describes, a lost entry is only logged
+func (s impersonationService) Record(a domain.ImpersonationAudit) {
+	_, err := s.auditRepo.Save(a)
+	if err != nil {
+		log.Printf("ImpersonationService: failed to save audit entry %+v: %s", a, err)
+	}
+}
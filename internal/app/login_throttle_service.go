@@ -0,0 +1,160 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/database"
+)
+
+// one address is often shared by many users (NAT, offices),
+// so it gets more attempts than a single account
+const ipAttemptsFactor = 5
+
+var ErrTooManyAttempts = errors.New("too many failed login attempts")
+
+// TooManyAttemptsError tells when the next attempt will be accepted
+type TooManyAttemptsError struct {
+	RetryAfter time.Duration
+}
+
+func (e TooManyAttemptsError) Error() string {
+	return fmt.Sprintf("%s, retry in %s", ErrTooManyAttempts, e.RetryAfter.Round(time.Second))
+}
+
+func (e TooManyAttemptsError) Unwrap() error {
+	return ErrTooManyAttempts
+}
+
+type LoginThrottleService interface {
+	Check(email, ip string) error
+	// RegisterFailure reports whether this failure locked the account or the address out
+	RegisterFailure(email, ip string) bool
+	RegisterSuccess(email string)
+	DeleteStale() error
+}
+
+type loginThrottleService struct {
+	loginAttemptRepo database.LoginAttemptRepository
+	lockoutEventRepo database.LockoutEventRepository
+	freeAttempts     int
+	lockoutAttempts  int
+	lockoutDuration  time.Duration
+	window           time.Duration
+}
+
+func NewLoginThrottleService(
+	lar database.LoginAttemptRepository,
+	ler database.LockoutEventRepository,
+	freeAttempts int,
+	lockoutAttempts int,
+	lockoutDuration time.Duration,
+	window time.Duration,
+) LoginThrottleService {
+	return loginThrottleService{
+		loginAttemptRepo: lar,
+		lockoutEventRepo: ler,
+		freeAttempts:     freeAttempts,
+		lockoutAttempts:  lockoutAttempts,
+		lockoutDuration:  lockoutDuration,
+		window:           window,
+	}
+}
+
+func (s loginThrottleService) Check(email, ip string) error {
+	until, err := s.loginAttemptRepo.FindBlockedUntil([]string{accountKey(email), ipKey(ip)})
+	if err != nil {
+		// the database being down must not lock everybody out
+		log.Printf("LoginThrottleService: %s", err)
+		return nil
+	}
+	if until == nil {
+		return nil
+	}
+
+	return TooManyAttemptsError{RetryAfter: time.Until(*until)}
+}
+
+func (s loginThrottleService) RegisterFailure(email, ip string) bool {
+	account := s.registerFailure(accountKey(email), ip, s.freeAttempts, s.lockoutAttempts)
+	address := s.registerFailure(ipKey(ip), ip, s.freeAttempts*ipAttemptsFactor, s.lockoutAttempts*ipAttemptsFactor)
+	return account || address
+}
+
+// RegisterSuccess clears only the account, otherwise an attacker could
+// reset the counter of their address by logging in to their own account
+func (s loginThrottleService) RegisterSuccess(email string) {
+	err := s.loginAttemptRepo.Reset(accountKey(email))
+	if err != nil {
+		log.Printf("LoginThrottleService: %s", err)
+	}
+}
+
+func (s loginThrottleService) DeleteStale() error {
+	return s.loginAttemptRepo.DeleteStale(s.window)
+}
+
+// registerFailure lets the first free attempts through, then doubles the delay
+// after every failure (1s, 2s, 4s...) and locks the key out once the limit is reached,
+// it reports true only for the failure which started the lockout
+func (s loginThrottleService) registerFailure(key, ip string, free, lockout int) bool {
+	attempt, err := s.loginAttemptRepo.RegisterFailure(key, s.window)
+	if err != nil {
+		log.Printf("LoginThrottleService: %s", err)
+		return false
+	}
+
+	if attempt.Failures < free {
+		return false
+	}
+
+	if attempt.Failures >= lockout {
+		until := attempt.LastFailureAt.Add(s.lockoutDuration)
+		s.block(key, until)
+		// later failures only prolong the lockout, the event is recorded once
+		if attempt.Failures == lockout {
+			s.recordLockout(attempt, ip, until)
+			return true
+		}
+		return false
+	}
+
+	exp := float64(attempt.Failures - free)
+	delay := time.Duration(math.Min(math.Pow(2, exp)*float64(time.Second), float64(s.lockoutDuration)))
+	s.block(key, attempt.LastFailureAt.Add(delay))
+	return false
+}
+
+func (s loginThrottleService) block(key string, until time.Time) {
+	err := s.loginAttemptRepo.Block(key, until)
+	if err != nil {
+		log.Printf("LoginThrottleService: %s", err)
+	}
+}
+
+func (s loginThrottleService) recordLockout(attempt domain.LoginAttempt, ip string, until time.Time) {
+	log.Printf("LoginThrottleService: %s is locked out until %s after %d failures", attempt.Key, until.Format(time.RFC3339), attempt.Failures)
+
+	_, err := s.lockoutEventRepo.Save(domain.LockoutEvent{
+		Key:         attempt.Key,
+		Ip:          ip,
+		Failures:    attempt.Failures,
+		LockedUntil: until,
+	})
+	if err != nil {
+		log.Printf("LoginThrottleService: %s", err)
+	}
+}
+
+func accountKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
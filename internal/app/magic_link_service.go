@@ -0,0 +1,168 @@
+package app
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/database"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/mail"
+	"github.com/upper/db/v4"
+)
+
+var ErrInvalidMagicLink = errors.New("login link is invalid or expired")
+
+type MagicLinkService interface {
+	Request(email string) (string, error)
+	Consume(token, nonce string, device domain.Device) (domain.User, domain.AuthTokens, error)
+	DeleteExpired() error
+}
+
+type magicLinkService struct {
+	magicLinkRepo database.MagicLinkRepository
+	userRepo      database.UserRepository
+	authService   AuthService
+	mailer        mail.Mailer
+	frontendUrl   string
+	linkTTL       time.Duration
+	maxPerHour    int
+}
+
+func NewMagicLinkService(
+	mlr database.MagicLinkRepository,
+	ur database.UserRepository,
+	as AuthService,
+	m mail.Mailer,
+	frontendUrl string,
+	linkTtl time.Duration,
+	maxPerHour int,
+) MagicLinkService {
+	return magicLinkService{
+		magicLinkRepo: mlr,
+		userRepo:      ur,
+		authService:   as,
+		mailer:        m,
+		frontendUrl:   frontendUrl,
+		linkTTL:       linkTtl,
+		maxPerHour:    maxPerHour,
+	}
+}
+
+// Request returns the nonce which the requesting device has to present
+// together with the token from the email. A nonce is returned for unknown
+// emails as well, failures for existing accounts are only logged
+func (s magicLinkService) Request(email string) (string, error) {
+	nonce, err := generateToken()
+	if err != nil {
+		log.Printf("MagicLinkService: %s", err)
+		return "", err
+	}
+
+	u, err := s.userRepo.FindByEmail(email)
+	if err != nil {
+		if !errors.Is(err, db.ErrNoMoreRows) {
+			log.Printf("MagicLinkService: %s", err)
+		}
+		return nonce, nil
+	}
+
+	if u.IsSuspended() {
+		return nonce, nil
+	}
+
+	// keeps the inbox from being flooded, the IP limit of the auth routes doesn't cover that
+	sent, err := s.magicLinkRepo.CountSince(u.Id, time.Now().Add(-time.Hour))
+	if err != nil {
+		log.Printf("MagicLinkService: %s", err)
+		return nonce, nil
+	}
+	if sent >= uint64(s.maxPerHour) {
+		log.Printf("MagicLinkService: hourly limit of login links reached for user %d", u.Id)
+		return nonce, nil
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		log.Printf("MagicLinkService: %s", err)
+		return nonce, nil
+	}
+
+	_, err = s.magicLinkRepo.Save(domain.MagicLink{
+		UserId:    u.Id,
+		TokenHash: hashToken(token),
+		NonceHash: hashToken(nonce),
+		ExpiresAt: time.Now().Add(s.linkTTL),
+	})
+	if err != nil {
+		log.Printf("MagicLinkService: %s", err)
+		return nonce, nil
+	}
+
+	go func() {
+		err := s.mailer.Send(mail.Message{
+			To:      u.Email,
+			Subject: "Your login link",
+			Body: fmt.Sprintf(
+				"Hello, %s!\n\nTo log in follow the link below on the same device where you requested it. It is valid for %s and works only once.\n\n%s/magic-link?token=%s\n\nIf you did not request it, just ignore this email.",
+				u.FirstName, s.linkTTL, s.frontendUrl, token,
+			),
+		})
+		if err != nil {
+			log.Printf("MagicLinkService: failed to send email %s", err)
+		}
+	}()
+
+	return nonce, nil
+}
+
+func (s magicLinkService) Consume(token, nonce string, device domain.Device) (domain.User, domain.AuthTokens, error) {
+	ml, err := s.magicLinkRepo.FindByTokenHash(hashToken(token))
+	if err != nil {
+		if errors.Is(err, db.ErrNoMoreRows) {
+			return domain.User{}, domain.AuthTokens{}, ErrInvalidMagicLink
+		}
+		log.Printf("MagicLinkService: %s", err)
+		return domain.User{}, domain.AuthTokens{}, err
+	}
+
+	// a link opened on another device, e.g. forwarded or leaked, is useless without the nonce
+	if subtle.ConstantTimeCompare([]byte(ml.NonceHash), []byte(hashToken(nonce))) != 1 {
+		return domain.User{}, domain.AuthTokens{}, ErrInvalidMagicLink
+	}
+
+	if ml.UsedDate != nil || time.Now().After(ml.ExpiresAt) {
+		return domain.User{}, domain.AuthTokens{}, ErrInvalidMagicLink
+	}
+
+	fresh, err := s.magicLinkRepo.MarkUsed(ml.Id)
+	if err != nil {
+		log.Printf("MagicLinkService: %s", err)
+		return domain.User{}, domain.AuthTokens{}, err
+	}
+	if !fresh {
+		return domain.User{}, domain.AuthTokens{}, ErrInvalidMagicLink
+	}
+
+	u, err := s.userRepo.FindById(ml.UserId)
+	if err != nil {
+		if errors.Is(err, db.ErrNoMoreRows) {
+			return domain.User{}, domain.AuthTokens{}, ErrInvalidMagicLink
+		}
+		log.Printf("MagicLinkService: %s", err)
+		return domain.User{}, domain.AuthTokens{}, err
+	}
+
+	tokens, err := s.authService.CompleteLogin(u, device, domain.LoginMethodMagicLink)
+	if err != nil {
+		return domain.User{}, domain.AuthTokens{}, err
+	}
+
+	return u, tokens, nil
+}
+
+func (s magicLinkService) DeleteExpired() error {
+	return s.magicLinkRepo.DeleteExpired()
+}
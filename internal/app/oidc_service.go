@@ -0,0 +1,236 @@
+package app
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/database"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/oidc"
+	"github.com/upper/db/v4"
+)
+
+const (
+	oidcLoginTTL  = 10 * time.Minute
+	maxNameLength = 40
+)
+
+var (
+	ErrOidcDisabled         = errors.New("login with an external provider is not configured")
+	ErrInvalidOidcState     = errors.New("login state is invalid or expired")
+	ErrOidcEmailNotVerified = errors.New("the provider didn't confirm the email of the account")
+	ErrOidcAccountConflict  = errors.New("an account with this email exists but the email is not verified, log in with the password first")
+)
+
+type OidcService interface {
+	Start() (string, string, error)
+	Callback(code, state, clientNonce string, device domain.Device) (domain.User, domain.AuthTokens, error)
+	DeleteExpired() error
+}
+
+type oidcService struct {
+	oidcLoginRepo    database.OidcLoginRepository
+	userIdentityRepo database.UserIdentityRepository
+	userRepo         database.UserRepository
+	authService      AuthService
+	provider         *oidc.Provider
+	issuer           string
+}
+
+// NewOidcService accepts a nil provider, every login then fails with ErrOidcDisabled
+func NewOidcService(
+	olr database.OidcLoginRepository,
+	uir database.UserIdentityRepository,
+	ur database.UserRepository,
+	as AuthService,
+	p *oidc.Provider,
+	issuer string,
+) OidcService {
+	return oidcService{
+		oidcLoginRepo:    olr,
+		userIdentityRepo: uir,
+		userRepo:         ur,
+		authService:      as,
+		provider:         p,
+		issuer:           issuer,
+	}
+}
+
+// Start returns the URL of the provider's login page and the nonce which the client
+// has to keep and send back with the callback, so a callback URL of a login started
+// by somebody else, e.g. sent in a link, can't log the user into a foreign account
+func (s oidcService) Start() (string, string, error) {
+	if s.provider == nil {
+		return "", "", ErrOidcDisabled
+	}
+
+	state, err := generateToken()
+	if err != nil {
+		log.Printf("OidcService: %s", err)
+		return "", "", err
+	}
+	nonce, err := generateToken()
+	if err != nil {
+		log.Printf("OidcService: %s", err)
+		return "", "", err
+	}
+	verifier, err := generateToken()
+	if err != nil {
+		log.Printf("OidcService: %s", err)
+		return "", "", err
+	}
+	clientNonce, err := generateToken()
+	if err != nil {
+		log.Printf("OidcService: %s", err)
+		return "", "", err
+	}
+
+	err = s.oidcLoginRepo.Save(domain.OidcLogin{
+		StateHash:       hashToken(state),
+		CodeVerifier:    verifier,
+		Nonce:           nonce,
+		ClientNonceHash: hashToken(clientNonce),
+		ExpiresAt:       time.Now().Add(oidcLoginTTL),
+	})
+	if err != nil {
+		log.Printf("OidcService: %s", err)
+		return "", "", err
+	}
+
+	url, err := s.provider.AuthCodeUrl(context.Background(), state, nonce, oidc.CodeChallenge(verifier))
+	if err != nil {
+		log.Printf("OidcService: %s", err)
+		return "", "", err
+	}
+
+	return url, clientNonce, nil
+}
+
+func (s oidcService) Callback(code, state, clientNonce string, device domain.Device) (domain.User, domain.AuthTokens, error) {
+	if s.provider == nil {
+		return domain.User{}, domain.AuthTokens{}, ErrOidcDisabled
+	}
+
+	login, err := s.oidcLoginRepo.Take(hashToken(state))
+	if err != nil {
+		if errors.Is(err, db.ErrNoMoreRows) {
+			return domain.User{}, domain.AuthTokens{}, ErrInvalidOidcState
+		}
+		log.Printf("OidcService: %s", err)
+		return domain.User{}, domain.AuthTokens{}, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(login.ClientNonceHash), []byte(hashToken(clientNonce))) != 1 {
+		return domain.User{}, domain.AuthTokens{}, ErrInvalidOidcState
+	}
+
+	ctx := context.Background()
+	idToken, err := s.provider.Exchange(ctx, code, login.CodeVerifier)
+	if err != nil {
+		log.Printf("OidcService: %s", err)
+		return domain.User{}, domain.AuthTokens{}, err
+	}
+
+	claims, err := s.provider.VerifyIdToken(ctx, idToken, login.Nonce)
+	if err != nil {
+		log.Printf("OidcService: %s", err)
+		return domain.User{}, domain.AuthTokens{}, err
+	}
+
+	u, err := s.findOrCreateUser(claims)
+	if err != nil {
+		return domain.User{}, domain.AuthTokens{}, err
+	}
+
+	tokens, err := s.authService.CompleteLogin(u, device, domain.LoginMethodOidc)
+	if err != nil {
+		return domain.User{}, domain.AuthTokens{}, err
+	}
+
+	return u, tokens, nil
+}
+
+func (s oidcService) DeleteExpired() error {
+	return s.oidcLoginRepo.DeleteExpired()
+}
+
+// findOrCreateUser uses the linked identity first, then links the provider account
+// to a user with the same verified email, and creates a new user otherwise
+func (s oidcService) findOrCreateUser(claims oidc.Claims) (domain.User, error) {
+	identity, err := s.userIdentityRepo.FindBySubject(s.issuer, claims.Subject)
+	if err == nil {
+		u, err := s.userRepo.FindById(identity.UserId)
+		if err != nil {
+			if errors.Is(err, db.ErrNoMoreRows) {
+				return domain.User{}, ErrAccountDeleted
+			}
+			log.Printf("OidcService: %s", err)
+			return domain.User{}, err
+		}
+		return u, nil
+	} else if !errors.Is(err, db.ErrNoMoreRows) {
+		log.Printf("OidcService: %s", err)
+		return domain.User{}, err
+	}
+
+	if claims.Email == "" || !claims.EmailVerified {
+		return domain.User{}, ErrOidcEmailNotVerified
+	}
+
+	u, err := s.userRepo.FindByEmail(claims.Email)
+	if err == nil {
+		// otherwise anyone could register the email first and get the account linked to their password
+		if !u.IsEmailVerified() {
+			return domain.User{}, ErrOidcAccountConflict
+		}
+	} else if errors.Is(err, db.ErrNoMoreRows) {
+		u, err = s.createUser(claims)
+		if err != nil {
+			return domain.User{}, err
+		}
+	} else {
+		log.Printf("OidcService: %s", err)
+		return domain.User{}, err
+	}
+
+	_, err = s.userIdentityRepo.Save(domain.UserIdentity{
+		UserId:  u.Id,
+		Issuer:  s.issuer,
+		Subject: claims.Subject,
+		Email:   claims.Email,
+	})
+	if err != nil {
+		log.Printf("OidcService: %s", err)
+		return domain.User{}, err
+	}
+
+	return u, nil
+}
+
+// createUser leaves the password empty, it matches no hash, so the user
+// can log in with a password only after setting one through the reset
+func (s oidcService) createUser(claims oidc.Claims) (domain.User, error) {
+	firstName := claims.GivenName
+	if firstName == "" {
+		firstName, _, _ = strings.Cut(claims.Email, "@")
+	}
+
+	now := time.Now()
+	u, err := s.userRepo.Save(domain.User{
+		Email:           claims.Email,
+		FirstName:       truncate(firstName, maxNameLength),
+		SecondName:      truncate(claims.FamilyName, maxNameLength),
+		Role:            domain.CustomerRole,
+		EmailVerifiedAt: &now,
+	})
+	if err != nil {
+		log.Printf("OidcService: %s", err)
+		return domain.User{}, err
+	}
+
+	return u, nil
+}
@@ -0,0 +1,54 @@
+package app
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/oidc"
+	"github.com/upper/db/v4"
+)
+
+type stubOidcLoginRepository struct {
+	logins map[string]domain.OidcLogin
+}
+
+func (r *stubOidcLoginRepository) Save(l domain.OidcLogin) error {
+	r.logins[l.StateHash] = l
+	return nil
+}
+
+func (r *stubOidcLoginRepository) Take(stateHash string) (domain.OidcLogin, error) {
+	l, ok := r.logins[stateHash]
+	if !ok {
+		return domain.OidcLogin{}, db.ErrNoMoreRows
+	}
+	delete(r.logins, stateHash)
+	return l, nil
+}
+
+func (r *stubOidcLoginRepository) DeleteExpired() error {
+	return nil
+}
+
+// a callback URL of a login started by somebody else must not log the user in
+func TestOidcCallbackRequiresClientNonceOfTheLogin(t *testing.T) {
+	repo := &stubOidcLoginRepository{logins: map[string]domain.OidcLogin{}}
+	// the provider is never reached, the login is rejected before the code exchange
+	s := NewOidcService(repo, nil, nil, nil, oidc.NewProvider(oidc.Config{}, nil), "")
+
+	for _, clientNonce := range []string{"", "victim-client-nonce"} {
+		repo.logins[hashToken("attacker-state")] = domain.OidcLogin{
+			StateHash:       hashToken("attacker-state"),
+			ClientNonceHash: hashToken("attacker-client-nonce"),
+		}
+
+		_, _, err := s.Callback("attacker-code", "attacker-state", clientNonce, domain.Device{})
+		if !errors.Is(err, ErrInvalidOidcState) {
+			t.Errorf("client nonce %q: err = %v, want %v", clientNonce, err, ErrInvalidOidcState)
+		}
+		if _, left := repo.logins[hashToken("attacker-state")]; left {
+			t.Errorf("client nonce %q: the state must be used up", clientNonce)
+		}
+	}
+}
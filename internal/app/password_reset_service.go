@@ -0,0 +1,170 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/database"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/hashing"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/mail"
+	"github.com/upper/db/v4"
+)
+
+var ErrInvalidResetToken = errors.New("reset token is invalid or expired")
+
+type PasswordResetService interface {
+	Forgot(email string) error
+	Reset(token, password string, device domain.Device) error
+	DeleteExpired() error
+}
+
+type passwordResetService struct {
+	passwordResetRepo database.PasswordResetRepository
+	userRepo          database.UserRepository
+	sessionRepo       database.SessionRepository
+	securityEvents    SecurityEventService
+	mailer            mail.Mailer
+	passwordHasher    hashing.PasswordHasher
+	frontendUrl       string
+	tokenTTL          time.Duration
+}
+
+func NewPasswordResetService(
+	prr database.PasswordResetRepository,
+	ur database.UserRepository,
+	sr database.SessionRepository,
+	ses SecurityEventService,
+	m mail.Mailer,
+	ph hashing.PasswordHasher,
+	frontendUrl string,
+	tokenTtl time.Duration,
+) PasswordResetService {
+	return passwordResetService{
+		passwordResetRepo: prr,
+		userRepo:          ur,
+		sessionRepo:       sr,
+		securityEvents:    ses,
+		mailer:            m,
+		passwordHasher:    ph,
+		frontendUrl:       frontendUrl,
+		tokenTTL:          tokenTtl,
+	}
+}
+
+// Forgot never tells the caller whether the email exists,
+// failures for existing accounts are only logged. The token is issued
+// and sent in background, so both cases return right after the lookup
+// and the response time doesn't tell them apart either
+func (s passwordResetService) Forgot(email string) error {
+	u, err := s.userRepo.FindByEmail(email)
+	if err != nil {
+		if !errors.Is(err, db.ErrNoMoreRows) {
+			log.Printf("PasswordResetService: %s", err)
+		}
+		return nil
+	}
+
+	go s.sendResetLink(u)
+
+	return nil
+}
+
+func (s passwordResetService) sendResetLink(u domain.User) {
+	token, err := generateToken()
+	if err != nil {
+		log.Printf("PasswordResetService: %s", err)
+		return
+	}
+
+	_, err = s.passwordResetRepo.Save(domain.PasswordReset{
+		UserId:    u.Id,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(s.tokenTTL),
+	})
+	if err != nil {
+		log.Printf("PasswordResetService: %s", err)
+		return
+	}
+
+	err = s.mailer.Send(mail.Message{
+		To:      u.Email,
+		Subject: "Password reset",
+		Body: fmt.Sprintf(
+			"Hello, %s!\n\nTo reset your password follow the link below. It is valid for %s.\n\n%s/reset-password?token=%s\n\nIf you did not request a reset, just ignore this email.",
+			u.FirstName, s.tokenTTL, s.frontendUrl, token,
+		),
+	})
+	if err != nil {
+		log.Printf("PasswordResetService: failed to send email %s", err)
+	}
+}
+
+func (s passwordResetService) Reset(token, password string, device domain.Device) error {
+	pr, err := s.passwordResetRepo.FindByTokenHash(hashToken(token))
+	if err != nil {
+		if errors.Is(err, db.ErrNoMoreRows) {
+			return ErrInvalidResetToken
+		}
+		log.Printf("PasswordResetService: %s", err)
+		return err
+	}
+
+	if pr.UsedDate != nil || time.Now().After(pr.ExpiresAt) {
+		return ErrInvalidResetToken
+	}
+
+	fresh, err := s.passwordResetRepo.MarkUsed(pr.Id)
+	if err != nil {
+		log.Printf("PasswordResetService: %s", err)
+		return err
+	}
+	if !fresh {
+		return ErrInvalidResetToken
+	}
+
+	u, err := s.userRepo.FindById(pr.UserId)
+	if err != nil {
+		if errors.Is(err, db.ErrNoMoreRows) {
+			return ErrInvalidResetToken
+		}
+		log.Printf("PasswordResetService: %s", err)
+		return err
+	}
+
+	u.Password, err = s.passwordHasher.Hash(password)
+	if err != nil {
+		log.Printf("PasswordResetService: %s", err)
+		return err
+	}
+
+	_, err = s.userRepo.Update(u)
+	if err != nil {
+		log.Printf("PasswordResetService: %s", err)
+		return err
+	}
+
+	err = s.passwordResetRepo.InvalidateByUser(u.Id)
+	if err != nil {
+		log.Printf("PasswordResetService: %s", err)
+		return err
+	}
+
+	err = s.sessionRepo.DeleteByUser(u.Id)
+	if err != nil {
+		log.Printf("PasswordResetService: %s", err)
+		return err
+	}
+
+	e := securityEvent(domain.SecurityEventPasswordChange, u.Id, device)
+	e.Details = "reset"
+	s.securityEvents.Record(e)
+
+	return nil
+}
+
+func (s passwordResetService) DeleteExpired() error {
+	return s.passwordResetRepo.DeleteExpired()
+}
@@ -0,0 +1,107 @@
+package app
+
+import (
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/database"
+	"github.com/upper/db/v4"
+)
+
+var ErrInvalidAccessToken = errors.New("access token is invalid, expired or revoked")
+
+type PersonalAccessTokenService interface {
+	Save(t domain.PersonalAccessToken) (domain.PersonalAccessToken, error)
+	Find(id uint64) (interface{}, error)
+	FindByUser(userId uint64) ([]domain.PersonalAccessToken, error)
+	Revoke(id uint64) error
+	Authenticate(token string) (domain.PersonalAccessToken, error)
+}
+
+type personalAccessTokenService struct {
+	tokenRepo database.PersonalAccessTokenRepository
+}
+
+func NewPersonalAccessTokenService(patr database.PersonalAccessTokenRepository) PersonalAccessTokenService {
+	return personalAccessTokenService{
+		tokenRepo: patr,
+	}
+}
+
+// Save returns the raw token in the Token field, it is never shown again
+func (s personalAccessTokenService) Save(t domain.PersonalAccessToken) (domain.PersonalAccessToken, error) {
+	token, err := generateToken()
+	if err != nil {
+		log.Printf("PersonalAccessTokenService: %s", err)
+		return domain.PersonalAccessToken{}, err
+	}
+	token = domain.PersonalAccessTokenPrefix + token
+
+	t.TokenHash = hashToken(token)
+	t, err = s.tokenRepo.Save(t)
+	if err != nil {
+		log.Printf("PersonalAccessTokenService: %s", err)
+		return domain.PersonalAccessToken{}, err
+	}
+
+	t.Token = token
+	return t, nil
+}
+
+func (s personalAccessTokenService) Find(id uint64) (interface{}, error) {
+	t, err := s.tokenRepo.Find(id)
+	if err != nil {
+		log.Printf("PersonalAccessTokenService: %s", err)
+		return domain.PersonalAccessToken{}, err
+	}
+
+	return t, nil
+}
+
+func (s personalAccessTokenService) FindByUser(userId uint64) ([]domain.PersonalAccessToken, error) {
+	ts, err := s.tokenRepo.FindByUser(userId)
+	if err != nil {
+		log.Printf("PersonalAccessTokenService: %s", err)
+		return nil, err
+	}
+
+	return ts, nil
+}
+
+func (s personalAccessTokenService) Revoke(id uint64) error {
+	err := s.tokenRepo.Revoke(id)
+	if err != nil {
+		log.Printf("PersonalAccessTokenService: %s", err)
+		return err
+	}
+
+	return nil
+}
+
+func (s personalAccessTokenService) Authenticate(token string) (domain.PersonalAccessToken, error) {
+	if !strings.HasPrefix(token, domain.PersonalAccessTokenPrefix) {
+		return domain.PersonalAccessToken{}, ErrInvalidAccessToken
+	}
+
+	t, err := s.tokenRepo.FindByTokenHash(hashToken(token))
+	if err != nil {
+		if errors.Is(err, db.ErrNoMoreRows) {
+			return domain.PersonalAccessToken{}, ErrInvalidAccessToken
+		}
+		log.Printf("PersonalAccessTokenService: %s", err)
+		return domain.PersonalAccessToken{}, err
+	}
+
+	if !t.IsActive() {
+		return domain.PersonalAccessToken{}, ErrInvalidAccessToken
+	}
+
+	err = s.tokenRepo.Touch(t.Id)
+	if err != nil {
+		log.Printf("PersonalAccessTokenService: %s", err)
+	}
+
+	return t, nil
+}
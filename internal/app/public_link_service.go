@@ -0,0 +1,209 @@
+package app
+
+import (
+	"errors"
+	"log"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/database"
+	"github.com/upper/db/v4"
+)
+
+var ErrPublicLinkNotFound = errors.New("link not found or expired")
+
+type PublicLinkService interface {
+	Save(l domain.PublicLink) (domain.PublicLink, error)
+	Find(id uint64) (interface{}, error)
+	FindByUser(userId uint64) ([]domain.PublicLink, error)
+	FindByTask(taskId uint64) ([]domain.PublicLink, error)
+	FindByWorkspace(workspaceId uint64) ([]domain.PublicLink, error)
+	CanManage(userId uint64, l domain.PublicLink) error
+	Revoke(id uint64) error
+
+	Resolve(token string) (domain.PublicLink, error)
+	FindTask(l domain.PublicLink) (domain.Task, error)
+	FindList(l domain.PublicLink) (domain.Workspace, []domain.Task, error)
+}
+
+type publicLinkService struct {
+	publicLinkRepo database.PublicLinkRepository
+	taskRepo       database.TaskRepository
+	workspaceRepo  database.WorkspaceRepository
+	authService    AuthorizationService
+}
+
+func NewPublicLinkService(
+	plr database.PublicLinkRepository,
+	tr database.TaskRepository,
+	wr database.WorkspaceRepository,
+	as AuthorizationService,
+) PublicLinkService {
+	return publicLinkService{
+		publicLinkRepo: plr,
+		taskRepo:       tr,
+		workspaceRepo:  wr,
+		authService:    as,
+	}
+}
+
+func (s publicLinkService) Save(l domain.PublicLink) (domain.PublicLink, error) {
+	token, err := generateToken()
+	if err != nil {
+		log.Printf("PublicLinkService: %s", err)
+		return domain.PublicLink{}, err
+	}
+
+	l.TokenHash = hashToken(token)
+	l, err = s.publicLinkRepo.Save(l)
+	if err != nil {
+		log.Printf("PublicLinkService: %s", err)
+		return domain.PublicLink{}, err
+	}
+
+	l.Token = token
+	return l, nil
+}
+
+func (s publicLinkService) Find(id uint64) (interface{}, error) {
+	l, err := s.publicLinkRepo.Find(id)
+	if err != nil {
+		log.Printf("PublicLinkService: %s", err)
+		return domain.PublicLink{}, err
+	}
+
+	return l, nil
+}
+
+func (s publicLinkService) FindByUser(userId uint64) ([]domain.PublicLink, error) {
+	ls, err := s.publicLinkRepo.FindByUser(userId)
+	if err != nil {
+		log.Printf("PublicLinkService: %s", err)
+		return nil, err
+	}
+
+	return ls, nil
+}
+
+func (s publicLinkService) FindByTask(taskId uint64) ([]domain.PublicLink, error) {
+	ls, err := s.publicLinkRepo.FindByTask(taskId)
+	if err != nil {
+		log.Printf("PublicLinkService: %s", err)
+		return nil, err
+	}
+
+	return ls, nil
+}
+
+func (s publicLinkService) FindByWorkspace(workspaceId uint64) ([]domain.PublicLink, error) {
+	ls, err := s.publicLinkRepo.FindByWorkspace(workspaceId)
+	if err != nil {
+		log.Printf("PublicLinkService: %s", err)
+		return nil, err
+	}
+
+	return ls, nil
+}
+
+// CanManage checks the target of the link, so whoever manages the task or
+// the workspace controls all of its links, not only the ones they created
+func (s publicLinkService) CanManage(userId uint64, l domain.PublicLink) error {
+	if l.TaskId != nil {
+		t, err := s.taskRepo.Find(*l.TaskId)
+		if err != nil {
+			if errors.Is(err, db.ErrNoMoreRows) {
+				return ErrPublicLinkNotFound
+			}
+			log.Printf("PublicLinkService: %s", err)
+			return err
+		}
+		return s.authService.CanManageTask(userId, t)
+	}
+	if l.WorkspaceId != nil {
+		return s.authService.CanManageWorkspace(userId, *l.WorkspaceId)
+	}
+
+	return ErrPublicLinkNotFound
+}
+
+func (s publicLinkService) Revoke(id uint64) error {
+	err := s.publicLinkRepo.Revoke(id)
+	if err != nil {
+		log.Printf("PublicLinkService: %s", err)
+		return err
+	}
+
+	return nil
+}
+
+func (s publicLinkService) Resolve(token string) (domain.PublicLink, error) {
+	l, err := s.publicLinkRepo.FindByTokenHash(hashToken(token))
+	if err != nil {
+		if errors.Is(err, db.ErrNoMoreRows) {
+			return domain.PublicLink{}, ErrPublicLinkNotFound
+		}
+		log.Printf("PublicLinkService: %s", err)
+		return domain.PublicLink{}, err
+	}
+
+	if !l.IsActive() {
+		return domain.PublicLink{}, ErrPublicLinkNotFound
+	}
+
+	// the link publishes on behalf of its creator, so it stops working
+	// once they can't manage the target anymore, e.g. after leaving the workspace
+	err = s.CanManage(l.UserId, l)
+	if err != nil {
+		if errors.Is(err, ErrAccessDenied) {
+			return domain.PublicLink{}, ErrPublicLinkNotFound
+		}
+		return domain.PublicLink{}, err
+	}
+
+	err = s.publicLinkRepo.RegisterAccess(l.Id)
+	if err != nil {
+		log.Printf("PublicLinkService: %s", err)
+		return domain.PublicLink{}, err
+	}
+
+	return l, nil
+}
+
+func (s publicLinkService) FindTask(l domain.PublicLink) (domain.Task, error) {
+	if l.TaskId == nil {
+		return domain.Task{}, ErrPublicLinkNotFound
+	}
+
+	t, err := s.taskRepo.Find(*l.TaskId)
+	if err != nil {
+		if errors.Is(err, db.ErrNoMoreRows) {
+			return domain.Task{}, ErrPublicLinkNotFound
+		}
+		log.Printf("PublicLinkService: %s", err)
+		return domain.Task{}, err
+	}
+
+	return t, nil
+}
+
+func (s publicLinkService) FindList(l domain.PublicLink) (domain.Workspace, []domain.Task, error) {
+	if l.WorkspaceId == nil {
+		return domain.Workspace{}, nil, ErrPublicLinkNotFound
+	}
+
+	w, err := s.workspaceRepo.Find(*l.WorkspaceId)
+	if err != nil {
+		if errors.Is(err, db.ErrNoMoreRows) {
+			return domain.Workspace{}, nil, ErrPublicLinkNotFound
+		}
+		log.Printf("PublicLinkService: %s", err)
+		return domain.Workspace{}, nil, err
+	}
+
+	ts, err := s.taskRepo.FindAllTasks(domain.TaskFilters{WorkspaceId: &w.Id})
+	if err != nil {
+		log.Printf("PublicLinkService: %s", err)
+		return domain.Workspace{}, nil, err
+	}
+
+	return w, ts, nil
+}
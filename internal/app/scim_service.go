@@ -0,0 +1,176 @@
+package app
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/database"
+	"github.com/upper/db/v4"
+)
+
+var (
+	ErrScimUserNotFound = errors.New("user not found")
+	ErrScimUserExists   = errors.New("a user with this userName already exists")
+)
+
+// ScimService provisions accounts for an identity provider, an inactive
+// SCIM user is a soft deleted one
+type ScimService interface {
+	FindUsers(f domain.UserFilters) (domain.Users, error)
+	Find(id uint64) (domain.User, error)
+	Create(user domain.User, active bool) (domain.User, error)
+	Update(user domain.User, active bool) (domain.User, error)
+	Deactivate(user domain.User) (domain.User, error)
+}
+
+type scimService struct {
+	userRepo    database.UserRepository
+	sessionRepo database.SessionRepository
+}
+
+func NewScimService(ur database.UserRepository, sr database.SessionRepository) ScimService {
+	return scimService{
+		userRepo:    ur,
+		sessionRepo: sr,
+	}
+}
+
+func (s scimService) FindUsers(f domain.UserFilters) (domain.Users, error) {
+	// count=0 asks for the total only
+	if f.CountPerPage == 0 {
+		total, err := s.userRepo.Count(f)
+		if err != nil {
+			log.Printf("ScimService: %s", err)
+			return domain.Users{}, err
+		}
+		return domain.Users{Total: total}, nil
+	}
+
+	users, err := s.userRepo.FindAll(f)
+	if err != nil {
+		log.Printf("ScimService: %s", err)
+		return domain.Users{}, err
+	}
+
+	return users, nil
+}
+
+func (s scimService) Find(id uint64) (domain.User, error) {
+	// deactivated users are still visible to the identity provider, anonymized ones are gone
+	u, err := s.userRepo.FindByIdWithDeleted(id)
+	if err != nil {
+		if errors.Is(err, db.ErrNoMoreRows) {
+			return domain.User{}, ErrScimUserNotFound
+		}
+		log.Printf("ScimService: %s", err)
+		return domain.User{}, err
+	}
+	if u.AnonymizedAt != nil {
+		return domain.User{}, ErrScimUserNotFound
+	}
+
+	return u, nil
+}
+
+// Create makes a user without a password, provisioned users log in through the identity provider
+func (s scimService) Create(user domain.User, active bool) (domain.User, error) {
+	err := s.checkEmailIsFree(user.Email, 0)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	now := time.Now()
+	user.Role = domain.CustomerRole
+	user.EmailVerifiedAt = &now
+	user, err = s.userRepo.Save(user)
+	if err != nil {
+		log.Printf("ScimService: %s", err)
+		return domain.User{}, err
+	}
+
+	if !active {
+		return s.Deactivate(user)
+	}
+	return user, nil
+}
+
+// Update replaces the provisioned attributes, the rest of the user stays untouched
+func (s scimService) Update(user domain.User, active bool) (domain.User, error) {
+	current, err := s.Find(user.Id)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	err = s.checkEmailIsFree(user.Email, user.Id)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	// a deleted user can't be updated, so it is brought back first
+	if active && current.DeletedDate != nil {
+		err = s.userRepo.Restore(current.Id)
+		if err != nil {
+			log.Printf("ScimService: %s", err)
+			return domain.User{}, err
+		}
+		current.DeletedDate = nil
+	}
+
+	if current.DeletedDate == nil {
+		if current.Email != user.Email {
+			// the identity provider vouches for the new email
+			now := time.Now()
+			current.EmailVerifiedAt = &now
+		}
+		current.Email = user.Email
+		current.FirstName = user.FirstName
+		current.SecondName = user.SecondName
+		current, err = s.userRepo.Update(current)
+		if err != nil {
+			log.Printf("ScimService: %s", err)
+			return domain.User{}, err
+		}
+	}
+
+	if !active {
+		return s.Deactivate(current)
+	}
+	return current, nil
+}
+
+// Deactivate soft deletes the user and ends all of their sessions
+func (s scimService) Deactivate(user domain.User) (domain.User, error) {
+	if user.DeletedDate == nil {
+		err := s.userRepo.Delete(user.Id)
+		if err != nil {
+			log.Printf("ScimService: %s", err)
+			return domain.User{}, err
+		}
+	}
+
+	err := s.sessionRepo.DeleteByUser(user.Id)
+	if err != nil {
+		log.Printf("ScimService: %s", err)
+		return domain.User{}, err
+	}
+
+	return s.Find(user.Id)
+}
+
+func (s scimService) checkEmailIsFree(email string, ownerId uint64) error {
+	users, err := s.userRepo.FindAll(domain.UserFilters{
+		Email:      email,
+		Pagination: domain.Pagination{Page: 1, CountPerPage: 1},
+	})
+	if err != nil {
+		log.Printf("ScimService: %s", err)
+		return err
+	}
+
+	if len(users.Items) > 0 && users.Items[0].Id != ownerId {
+		return ErrScimUserExists
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package app
+
+import (
+	"log"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/database"
+)
+
+const (
+	maxEventEmailLength   = 255
+	maxEventDetailsLength = 255
+)
+
+type SecurityEventService interface {
+	Record(e domain.SecurityEvent)
+	FindAll(f domain.SecurityEventFilters) (domain.SecurityEvents, error)
+	DeleteExpired() error
+}
+
+type securityEventService struct {
+	eventRepo database.SecurityEventRepository
+	retention time.Duration
+}
+
+func NewSecurityEventService(ser database.SecurityEventRepository, retention time.Duration) SecurityEventService {
+	return securityEventService{
+		eventRepo: ser,
+		retention: retention,
+	}
+}
+
+// Record never fails the action it describes, a lost event is only logged
+func (s securityEventService) Record(e domain.SecurityEvent) {
+	e.Email = truncate(e.Email, maxEventEmailLength)
+	e.UserAgent = truncate(e.UserAgent, maxUserAgentLength)
+	e.Details = truncate(e.Details, maxEventDetailsLength)
+
+	_, err := s.eventRepo.Save(e)
+	if err != nil {
+		log.Printf("SecurityEventService: failed to save %s event: %s", e.Type, err)
+	}
+}
+
+func (s securityEventService) FindAll(f domain.SecurityEventFilters) (domain.SecurityEvents, error) {
+	es, err := s.eventRepo.FindAll(f)
+	if err != nil {
+		log.Printf("SecurityEventService: %s", err)
+		return domain.SecurityEvents{}, err
+	}
+
+	return es, nil
+}
+
+func (s securityEventService) DeleteExpired() error {
+	return s.eventRepo.DeleteOlderThan(time.Now().Add(-s.retention))
+}
+
+// securityEvent fills in the parts every event has, userId 0 means an unknown user
+func securityEvent(t domain.SecurityEventType, userId uint64, device domain.Device) domain.SecurityEvent {
+	e := domain.SecurityEvent{
+		Type:      t,
+		Ip:        device.Ip,
+		UserAgent: device.UserAgent,
+	}
+	if userId != 0 {
+		e.UserId = &userId
+	}
+	return e
+}
+
+func sessionEvent(t domain.SecurityEventType, sess domain.Session, device domain.Device) domain.SecurityEvent {
+	e := securityEvent(t, sess.UserId, device)
+	sessUUID := sess.UUID
+	e.SessionUUID = &sessUUID
+	return e
+}
@@ -0,0 +1,66 @@
+package app
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidSignature = errors.New("signature is invalid or expired")
+
+// Signer issues stateless tamper-proof tokens, the purpose is part of the
+// signed data so a token issued for one flow can't be replayed in another
+type Signer interface {
+	Sign(purpose, payload string, ttl time.Duration) string
+	Verify(purpose, token string) (string, error)
+}
+
+type signer struct {
+	key []byte
+}
+
+func NewSigner(key string) Signer {
+	return signer{
+		key: []byte(key),
+	}
+}
+
+func (s signer) Sign(purpose, payload string, ttl time.Duration) string {
+	exp := strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)
+	data := base64.RawURLEncoding.EncodeToString([]byte(exp + "|" + payload))
+	return data + "." + s.mac(purpose, data)
+}
+
+func (s signer) Verify(purpose, token string) (string, error) {
+	data, sig, found := strings.Cut(token, ".")
+	if !found || !hmac.Equal([]byte(sig), []byte(s.mac(purpose, data))) {
+		return "", ErrInvalidSignature
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(data)
+	if err != nil {
+		return "", ErrInvalidSignature
+	}
+
+	exp, payload, found := strings.Cut(string(raw), "|")
+	if !found {
+		return "", ErrInvalidSignature
+	}
+
+	unix, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || time.Now().Unix() > unix {
+		return "", ErrInvalidSignature
+	}
+
+	return payload, nil
+}
+
+func (s signer) mac(purpose, data string) string {
+	h := hmac.New(sha256.New, s.key)
+	h.Write([]byte(purpose + "|" + data))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
@@ -3,10 +3,10 @@ package app
 import (
 	"errors"
 	"log"
-	"time"
 
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/database"
+	"github.com/upper/db/v4"
 )
 
 type TaskService interface {
@@ -14,7 +14,7 @@ type TaskService interface {
 	Find(id uint64) (interface{}, error)
 
 	//update
-	FindAll(uId uint64, status *domain.TaskStatus, date *time.Time) ([]domain.Task, error)
+	FindAll(f domain.TaskFilters) ([]domain.Task, error)
 	//update
 
 	Update(t domain.Task) (domain.Task, error)
@@ -23,15 +23,27 @@ type TaskService interface {
 	//new
 	UpdateStatus(taskID uint64, userID uint64, status domain.TaskStatus) (domain.Task, error)
 	//new
+
+	Assign(t domain.Task, assigneeId uint64) (domain.Task, error)
+	Unassign(t domain.Task) (domain.Task, error)
 }
 
+var (
+	ErrAssigneeNotFound = errors.New("assignee not found")
+	ErrAssigneeNoAccess = errors.New("assignee has no access to the task")
+)
+
 type taskService struct {
-	taskRepo database.TaskRepository
+	taskRepo    database.TaskRepository
+	userRepo    database.UserRepository
+	authService AuthorizationService
 }
 
-func NewTaskService(tr database.TaskRepository) TaskService {
+func NewTaskService(tr database.TaskRepository, ur database.UserRepository, as AuthorizationService) TaskService {
 	return taskService{
-		taskRepo: tr,
+		taskRepo:    tr,
+		userRepo:    ur,
+		authService: as,
 	}
 }
 
@@ -52,17 +64,17 @@ func (s taskService) Find(id uint64) (interface{}, error) {
 		return domain.Task{}, err
 	}
 
-	return task, nil
+	return s.withAssignee(task)
 }
 
-func (s taskService) FindAll(uId uint64, status *domain.TaskStatus, date *time.Time) ([]domain.Task, error) {
-	tasks, err := s.taskRepo.FindAllTasks(uId, status, date)
+func (s taskService) FindAll(f domain.TaskFilters) ([]domain.Task, error) {
+	tasks, err := s.taskRepo.FindAllTasks(f)
 	if err != nil {
 		log.Printf("taskService.FindAll(s.taskRepo.FindAllTasks): %s", err)
 		return nil, err
 	}
 
-	return tasks, nil
+	return s.withAssignees(tasks)
 }
 
 func (s taskService) Update(t domain.Task) (domain.Task, error) {
@@ -72,7 +84,7 @@ func (s taskService) Update(t domain.Task) (domain.Task, error) {
 		return domain.Task{}, err
 	}
 
-	return task, nil
+	return s.withAssignee(task)
 }
 
 func (s taskService) Delete(id uint64) error {
@@ -92,12 +104,90 @@ func (s taskService) UpdateStatus(taskID uint64, userID uint64, status domain.Ta
 		return domain.Task{}, err
 	}
 
-	// перевіряємо власника
-	if task.UserId != userID {
-		return domain.Task{}, errors.New("access denied")
+	// перевіряємо права доступу
+	err = s.authService.CanUpdateTaskStatus(userID, task)
+	if err != nil {
+		return domain.Task{}, err
 	}
 
 	task.Status = status
 
-	return s.taskRepo.Update(task)
+	task, err = s.taskRepo.Update(task)
+	if err != nil {
+		return domain.Task{}, err
+	}
+
+	return s.withAssignee(task)
+}
+
+func (s taskService) Assign(t domain.Task, assigneeId uint64) (domain.Task, error) {
+	_, err := s.userRepo.FindById(assigneeId)
+	if err != nil {
+		if errors.Is(err, db.ErrNoMoreRows) {
+			return domain.Task{}, ErrAssigneeNotFound
+		}
+		log.Printf("taskService.Assign(s.userRepo.FindById): %s", err)
+		return domain.Task{}, err
+	}
+
+	// only the owner, workspace members and users the task is shared with can take it,
+	// otherwise assigning would hand the task to anybody
+	_, err = s.authService.TaskPermission(assigneeId, t)
+	if err != nil {
+		if errors.Is(err, ErrAccessDenied) {
+			return domain.Task{}, ErrAssigneeNoAccess
+		}
+		return domain.Task{}, err
+	}
+
+	t.AssigneeId = &assigneeId
+	return s.Update(t)
+}
+
+func (s taskService) Unassign(t domain.Task) (domain.Task, error) {
+	t.AssigneeId = nil
+	return s.Update(t)
+}
+
+func (s taskService) withAssignee(t domain.Task) (domain.Task, error) {
+	tasks, err := s.withAssignees([]domain.Task{t})
+	if err != nil {
+		return domain.Task{}, err
+	}
+
+	return tasks[0], nil
+}
+
+// withAssignees loads assignees of all given tasks with a single query
+func (s taskService) withAssignees(tasks []domain.Task) ([]domain.Task, error) {
+	var ids []uint64
+	for _, t := range tasks {
+		if t.AssigneeId != nil {
+			ids = append(ids, *t.AssigneeId)
+		}
+	}
+	if len(ids) == 0 {
+		return tasks, nil
+	}
+
+	users, err := s.userRepo.FindByIds(ids)
+	if err != nil {
+		log.Printf("taskService.withAssignees(s.userRepo.FindByIds): %s", err)
+		return nil, err
+	}
+
+	byId := make(map[uint64]domain.User, len(users))
+	for _, u := range users {
+		byId[u.Id] = u
+	}
+	for i, t := range tasks {
+		if t.AssigneeId == nil {
+			continue
+		}
+		if u, ok := byId[*t.AssigneeId]; ok {
+			tasks[i].Assignee = &u
+		}
+	}
+
+	return tasks, nil
 }
@@ -0,0 +1,86 @@
+package app
+
+import (
+	"errors"
+	"log"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/database"
+	"github.com/upper/db/v4"
+)
+
+var ErrCollaboratorNotFound = errors.New("user with this email not found")
+
+type TaskShareService interface {
+	Share(t domain.Task, email string, p domain.Permission) (domain.TaskShare, error)
+	FindByTask(taskId uint64) ([]domain.TaskShare, error)
+	Revoke(taskId, userId uint64) error
+}
+
+type taskShareService struct {
+	taskShareRepo database.TaskShareRepository
+	userRepo      database.UserRepository
+}
+
+func NewTaskShareService(tsr database.TaskShareRepository, ur database.UserRepository) TaskShareService {
+	return taskShareService{
+		taskShareRepo: tsr,
+		userRepo:      ur,
+	}
+}
+
+func (s taskShareService) Share(t domain.Task, email string, p domain.Permission) (domain.TaskShare, error) {
+	u, err := s.userRepo.FindByEmail(email)
+	if err != nil {
+		if errors.Is(err, db.ErrNoMoreRows) {
+			return domain.TaskShare{}, ErrCollaboratorNotFound
+		}
+		log.Printf("TaskShareService: %s", err)
+		return domain.TaskShare{}, err
+	}
+
+	if u.Id == t.UserId {
+		return domain.TaskShare{}, errors.New("task is already owned by this user")
+	}
+
+	share, err := s.taskShareRepo.Find(t.Id, u.Id)
+	if err == nil {
+		share.Permission = p
+		return s.taskShareRepo.Update(share)
+	} else if !errors.Is(err, db.ErrNoMoreRows) {
+		log.Printf("TaskShareService: %s", err)
+		return domain.TaskShare{}, err
+	}
+
+	share, err = s.taskShareRepo.Save(domain.TaskShare{
+		TaskId:     t.Id,
+		UserId:     u.Id,
+		Permission: p,
+	})
+	if err != nil {
+		log.Printf("TaskShareService: %s", err)
+		return domain.TaskShare{}, err
+	}
+
+	return share, nil
+}
+
+func (s taskShareService) FindByTask(taskId uint64) ([]domain.TaskShare, error) {
+	shares, err := s.taskShareRepo.FindByTask(taskId)
+	if err != nil {
+		log.Printf("TaskShareService: %s", err)
+		return nil, err
+	}
+
+	return shares, nil
+}
+
+func (s taskShareService) Revoke(taskId, userId uint64) error {
+	err := s.taskShareRepo.Delete(taskId, userId)
+	if err != nil {
+		log.Printf("TaskShareService: %s", err)
+		return err
+	}
+
+	return nil
+}
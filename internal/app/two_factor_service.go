@@ -0,0 +1,241 @@
+package app
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/database"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/totp"
+	"github.com/skip2/go-qrcode"
+)
+
+const (
+	recoveryCodesCount = 10
+	qrCodeSize         = 256
+)
+
+var (
+	ErrTwoFactorEnabled     = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorDisabled    = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorNotEnrolled = errors.New("two-factor enrollment was not started")
+	ErrInvalidTwoFactorCode = errors.New("two-factor code is invalid")
+)
+
+type TwoFactorService interface {
+	Enroll(user domain.User) (domain.TwoFactorEnrollment, error)
+	Enable(user domain.User, code string) ([]string, error)
+	Disable(user domain.User, code string) error
+	RegenerateRecoveryCodes(user domain.User, code string) ([]string, error)
+	// Verify accepts either a TOTP code or an unused recovery code
+	Verify(user domain.User, code string) error
+}
+
+type twoFactorService struct {
+	userRepo         database.UserRepository
+	recoveryCodeRepo database.RecoveryCodeRepository
+	totp             totp.Generator
+	encrypter        Encrypter
+	issuer           string
+}
+
+func NewTwoFactorService(
+	ur database.UserRepository,
+	rcr database.RecoveryCodeRepository,
+	g totp.Generator,
+	e Encrypter,
+	issuer string,
+) TwoFactorService {
+	return twoFactorService{
+		userRepo:         ur,
+		recoveryCodeRepo: rcr,
+		totp:             g,
+		encrypter:        e,
+		issuer:           issuer,
+	}
+}
+
+// Enroll stores a new pending secret, it takes effect only after Enable
+func (s twoFactorService) Enroll(user domain.User) (domain.TwoFactorEnrollment, error) {
+	if user.IsTwoFactorEnabled() {
+		return domain.TwoFactorEnrollment{}, ErrTwoFactorEnabled
+	}
+
+	secret, err := s.totp.GenerateSecret()
+	if err != nil {
+		log.Printf("TwoFactorService: %s", err)
+		return domain.TwoFactorEnrollment{}, err
+	}
+
+	encrypted, err := s.encrypter.Encrypt(totpPurpose(user.Id), secret)
+	if err != nil {
+		log.Printf("TwoFactorService: %s", err)
+		return domain.TwoFactorEnrollment{}, err
+	}
+
+	user.TotpSecret = &encrypted
+	_, err = s.userRepo.Update(user)
+	if err != nil {
+		log.Printf("TwoFactorService: %s", err)
+		return domain.TwoFactorEnrollment{}, err
+	}
+
+	uri := s.totp.Uri(s.issuer, user.Email, secret)
+	qr, err := qrcode.Encode(uri, qrcode.Medium, qrCodeSize)
+	if err != nil {
+		log.Printf("TwoFactorService: %s", err)
+		return domain.TwoFactorEnrollment{}, err
+	}
+
+	return domain.TwoFactorEnrollment{
+		Secret: secret,
+		Uri:    uri,
+		QrCode: qr,
+	}, nil
+}
+
+func (s twoFactorService) Enable(user domain.User, code string) ([]string, error) {
+	if user.IsTwoFactorEnabled() {
+		return nil, ErrTwoFactorEnabled
+	}
+	if user.TotpSecret == nil {
+		return nil, ErrTwoFactorNotEnrolled
+	}
+
+	err := s.verifyTotp(user, code)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	user.TotpEnabledAt = &now
+	_, err = s.userRepo.Update(user)
+	if err != nil {
+		log.Printf("TwoFactorService: %s", err)
+		return nil, err
+	}
+
+	return s.generateRecoveryCodes(user)
+}
+
+func (s twoFactorService) Disable(user domain.User, code string) error {
+	err := s.Verify(user, code)
+	if err != nil {
+		return err
+	}
+
+	user.TotpSecret = nil
+	user.TotpEnabledAt = nil
+	_, err = s.userRepo.Update(user)
+	if err != nil {
+		log.Printf("TwoFactorService: %s", err)
+		return err
+	}
+
+	err = s.recoveryCodeRepo.DeleteByUser(user.Id)
+	if err != nil {
+		log.Printf("TwoFactorService: %s", err)
+		return err
+	}
+
+	return nil
+}
+
+func (s twoFactorService) RegenerateRecoveryCodes(user domain.User, code string) ([]string, error) {
+	if !user.IsTwoFactorEnabled() {
+		return nil, ErrTwoFactorDisabled
+	}
+
+	err := s.verifyTotp(user, code)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.generateRecoveryCodes(user)
+}
+
+func (s twoFactorService) Verify(user domain.User, code string) error {
+	if !user.IsTwoFactorEnabled() {
+		return ErrTwoFactorDisabled
+	}
+
+	code = strings.TrimSpace(code)
+	if len(code) == 6 {
+		return s.verifyTotp(user, code)
+	}
+
+	used, err := s.recoveryCodeRepo.Use(user.Id, hashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		log.Printf("TwoFactorService: %s", err)
+		return err
+	}
+	if !used {
+		return ErrInvalidTwoFactorCode
+	}
+
+	return nil
+}
+
+func (s twoFactorService) verifyTotp(user domain.User, code string) error {
+	secret, err := s.encrypter.Decrypt(totpPurpose(user.Id), *user.TotpSecret)
+	if err != nil {
+		log.Printf("TwoFactorService: %s", err)
+		return err
+	}
+
+	step, valid := s.totp.Validate(secret, strings.TrimSpace(code))
+	if !valid {
+		return ErrInvalidTwoFactorCode
+	}
+
+	// a code intercepted on the way can't be replayed within its window
+	fresh, err := s.userRepo.UseTotpStep(user.Id, step)
+	if err != nil {
+		log.Printf("TwoFactorService: %s", err)
+		return err
+	}
+	if !fresh {
+		return ErrInvalidTwoFactorCode
+	}
+
+	return nil
+}
+
+// totpPurpose binds the encrypted secret to its user, a copied value is useless in another row
+func totpPurpose(userId uint64) string {
+	return fmt.Sprintf("totp|%d", userId)
+}
+
+func (s twoFactorService) generateRecoveryCodes(user domain.User) ([]string, error) {
+	codes := make([]string, recoveryCodesCount)
+	hashes := make([]string, recoveryCodesCount)
+	for i := range codes {
+		b := make([]byte, 10)
+		_, err := rand.Read(b)
+		if err != nil {
+			log.Printf("TwoFactorService: %s", err)
+			return nil, err
+		}
+
+		raw := strings.ToLower(base32.StdEncoding.EncodeToString(b))
+		codes[i] = raw[:8] + "-" + raw[8:16]
+		hashes[i] = hashToken(raw[:16])
+	}
+
+	err := s.recoveryCodeRepo.Replace(user.Id, hashes)
+	if err != nil {
+		log.Printf("TwoFactorService: %s", err)
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(code, "-", ""))
+}
@@ -1,18 +1,21 @@
 package app
 
 import (
+	"errors"
 	"log"
 
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/database"
+	"github.com/upper/db/v4"
 )
 
+var ErrEmailTaken = errors.New("email is already used by another account")
+
 type UserService interface {
 	FindByEmail(email string) (domain.User, error)
 	FindById(id uint64) (domain.User, error)
 	Find(id uint64) (interface{}, error)
 	Update(user domain.User) (domain.User, error)
-	Delete(id uint64) error
 }
 
 type userService struct {
@@ -54,21 +57,20 @@ func (s userService) Find(id uint64) (interface{}, error) {
 }
 
 func (s userService) Update(user domain.User) (domain.User, error) {
-	user, err := s.userRepo.Update(user)
-	if err != nil {
+	// the email is the login, two accounts with the same one would make it ambiguous
+	other, err := s.userRepo.FindByEmail(user.Email)
+	if err == nil && other.Id != user.Id {
+		return domain.User{}, ErrEmailTaken
+	} else if err != nil && !errors.Is(err, db.ErrNoMoreRows) {
 		log.Printf("UserService: %s", err)
 		return domain.User{}, err
 	}
 
-	return user, nil
-}
-
-func (s userService) Delete(id uint64) error {
-	err := s.userRepo.Delete(id)
+	user, err = s.userRepo.Update(user)
 	if err != nil {
 		log.Printf("UserService: %s", err)
-		return err
+		return domain.User{}, err
 	}
 
-	return nil
+	return user, nil
 }
@@ -0,0 +1,359 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/database"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/mail"
+	"github.com/upper/db/v4"
+)
+
+const invitationTTL = 7 * 24 * time.Hour
+
+var (
+	ErrMemberNotFound     = errors.New("workspace member not found")
+	ErrInvalidInvitation  = errors.New("invitation is invalid or expired")
+	ErrOwnerRoleImmutable = errors.New("workspace owner role can not be changed")
+)
+
+type WorkspaceService interface {
+	Save(w domain.Workspace) (domain.Workspace, error)
+	Find(id uint64) (interface{}, error)
+	FindByMember(userId uint64) ([]domain.Workspace, error)
+	Update(w domain.Workspace) (domain.Workspace, error)
+	Delete(id uint64) error
+
+	FindMembers(workspaceId uint64) ([]domain.WorkspaceMember, error)
+	UpdateMemberRole(actorRole domain.WorkspaceRole, m domain.WorkspaceMember) (domain.WorkspaceMember, error)
+	RemoveMember(actorRole domain.WorkspaceRole, workspaceId, userId uint64) error
+	Leave(workspaceId, userId uint64) error
+
+	Invite(i domain.WorkspaceInvitation) (domain.WorkspaceInvitation, error)
+	AcceptInvitation(token string, user domain.User) (domain.WorkspaceMember, error)
+}
+
+type workspaceService struct {
+	workspaceRepo  database.WorkspaceRepository
+	memberRepo     database.WorkspaceMemberRepository
+	invitationRepo database.WorkspaceInvitationRepository
+	taskRepo       database.TaskRepository
+	userRepo       database.UserRepository
+	mailer         mail.Mailer
+	frontendUrl    string
+}
+
+func NewWorkspaceService(
+	wr database.WorkspaceRepository,
+	wmr database.WorkspaceMemberRepository,
+	wir database.WorkspaceInvitationRepository,
+	tr database.TaskRepository,
+	ur database.UserRepository,
+	m mail.Mailer,
+	frontendUrl string,
+) WorkspaceService {
+	return workspaceService{
+		workspaceRepo:  wr,
+		memberRepo:     wmr,
+		invitationRepo: wir,
+		taskRepo:       tr,
+		userRepo:       ur,
+		mailer:         m,
+		frontendUrl:    frontendUrl,
+	}
+}
+
+func (s workspaceService) Save(w domain.Workspace) (domain.Workspace, error) {
+	w, err := s.workspaceRepo.Save(w)
+	if err != nil {
+		log.Printf("WorkspaceService: %s", err)
+		return domain.Workspace{}, err
+	}
+
+	_, err = s.memberRepo.Save(domain.WorkspaceMember{
+		WorkspaceId: w.Id,
+		UserId:      w.OwnerId,
+		Role:        domain.WorkspaceOwnerRole,
+	})
+	if err != nil {
+		log.Printf("WorkspaceService: %s", err)
+		return domain.Workspace{}, err
+	}
+
+	return w, nil
+}
+
+func (s workspaceService) Find(id uint64) (interface{}, error) {
+	w, err := s.workspaceRepo.Find(id)
+	if err != nil {
+		log.Printf("WorkspaceService: %s", err)
+		return domain.Workspace{}, err
+	}
+
+	return w, nil
+}
+
+func (s workspaceService) FindByMember(userId uint64) ([]domain.Workspace, error) {
+	ws, err := s.workspaceRepo.FindByMember(userId)
+	if err != nil {
+		log.Printf("WorkspaceService: %s", err)
+		return nil, err
+	}
+
+	return ws, nil
+}
+
+func (s workspaceService) Update(w domain.Workspace) (domain.Workspace, error) {
+	w, err := s.workspaceRepo.Update(w)
+	if err != nil {
+		log.Printf("WorkspaceService: %s", err)
+		return domain.Workspace{}, err
+	}
+
+	return w, nil
+}
+
+func (s workspaceService) Delete(id uint64) error {
+	err := s.workspaceRepo.Delete(id)
+	if err != nil {
+		log.Printf("WorkspaceService: %s", err)
+		return err
+	}
+
+	err = s.taskRepo.DeleteByWorkspace(id)
+	if err != nil {
+		log.Printf("WorkspaceService: %s", err)
+		return err
+	}
+
+	err = s.memberRepo.DeleteByWorkspace(id)
+	if err != nil {
+		log.Printf("WorkspaceService: %s", err)
+		return err
+	}
+
+	return nil
+}
+
+func (s workspaceService) FindMembers(workspaceId uint64) ([]domain.WorkspaceMember, error) {
+	members, err := s.memberRepo.FindByWorkspace(workspaceId)
+	if err != nil {
+		log.Printf("WorkspaceService: %s", err)
+		return nil, err
+	}
+
+	ids := make([]uint64, len(members))
+	for i, m := range members {
+		ids[i] = m.UserId
+	}
+	if len(ids) == 0 {
+		return members, nil
+	}
+
+	users, err := s.userRepo.FindByIds(ids)
+	if err != nil {
+		log.Printf("WorkspaceService: %s", err)
+		return nil, err
+	}
+
+	byId := make(map[uint64]domain.User, len(users))
+	for _, u := range users {
+		byId[u.Id] = u
+	}
+	for i, m := range members {
+		if u, ok := byId[m.UserId]; ok {
+			members[i].User = &u
+		}
+	}
+
+	return members, nil
+}
+
+func (s workspaceService) UpdateMemberRole(actorRole domain.WorkspaceRole, m domain.WorkspaceMember) (domain.WorkspaceMember, error) {
+	member, err := s.findMember(m.WorkspaceId, m.UserId)
+	if err != nil {
+		return domain.WorkspaceMember{}, err
+	}
+
+	err = checkMemberManagement(actorRole, member.Role, m.Role)
+	if err != nil {
+		return domain.WorkspaceMember{}, err
+	}
+
+	member.Role = m.Role
+	member, err = s.memberRepo.Update(member)
+	if err != nil {
+		log.Printf("WorkspaceService: %s", err)
+		return domain.WorkspaceMember{}, err
+	}
+
+	return member, nil
+}
+
+func (s workspaceService) RemoveMember(actorRole domain.WorkspaceRole, workspaceId, userId uint64) error {
+	member, err := s.findMember(workspaceId, userId)
+	if err != nil {
+		return err
+	}
+
+	err = checkMemberManagement(actorRole, member.Role, member.Role)
+	if err != nil {
+		return err
+	}
+
+	err = s.memberRepo.Delete(workspaceId, userId)
+	if err != nil {
+		log.Printf("WorkspaceService: %s", err)
+		return err
+	}
+
+	return nil
+}
+
+func (s workspaceService) Leave(workspaceId, userId uint64) error {
+	member, err := s.findMember(workspaceId, userId)
+	if err != nil {
+		return err
+	}
+
+	if member.Role == domain.WorkspaceOwnerRole {
+		return ErrOwnerRoleImmutable
+	}
+
+	err = s.memberRepo.Delete(workspaceId, userId)
+	if err != nil {
+		log.Printf("WorkspaceService: %s", err)
+		return err
+	}
+
+	return nil
+}
+
+// Invite mails the token to the invited email, the raw token is never stored
+// or returned, so only the owner of the mailbox can accept
+func (s workspaceService) Invite(i domain.WorkspaceInvitation) (domain.WorkspaceInvitation, error) {
+	w, err := s.workspaceRepo.Find(i.WorkspaceId)
+	if err != nil {
+		log.Printf("WorkspaceService: %s", err)
+		return domain.WorkspaceInvitation{}, err
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		log.Printf("WorkspaceService: %s", err)
+		return domain.WorkspaceInvitation{}, err
+	}
+
+	i.Email = strings.ToLower(i.Email)
+	i.TokenHash = hashToken(token)
+	i.ExpiresAt = time.Now().Add(invitationTTL)
+	i, err = s.invitationRepo.Save(i)
+	if err != nil {
+		log.Printf("WorkspaceService: %s", err)
+		return domain.WorkspaceInvitation{}, err
+	}
+
+	// the page of the web app accepts it with POST /api/v1/workspaces/invitations/{token}/accept
+	go func() {
+		err := s.mailer.Send(mail.Message{
+			To:      i.Email,
+			Subject: "You are invited to a workspace",
+			Body: fmt.Sprintf(
+				"Hello!\n\nYou are invited to join the workspace %q. To accept follow the link below. It is valid for %s.\n\n%s/invitations/accept?token=%s\n\nIf you did not expect this invitation, just ignore this email.",
+				w.Name, invitationTTL, s.frontendUrl, url.QueryEscape(token),
+			),
+		})
+		if err != nil {
+			log.Printf("WorkspaceService: failed to send email %s", err)
+		}
+	}()
+
+	return i, nil
+}
+
+func (s workspaceService) AcceptInvitation(token string, user domain.User) (domain.WorkspaceMember, error) {
+	i, err := s.invitationRepo.FindByTokenHash(hashToken(token))
+	if err != nil {
+		if errors.Is(err, db.ErrNoMoreRows) {
+			return domain.WorkspaceMember{}, ErrInvalidInvitation
+		}
+		log.Printf("WorkspaceService: %s", err)
+		return domain.WorkspaceMember{}, err
+	}
+
+	if i.AcceptedDate != nil || time.Now().After(i.ExpiresAt) || !strings.EqualFold(i.Email, user.Email) {
+		return domain.WorkspaceMember{}, ErrInvalidInvitation
+	}
+
+	_, err = s.workspaceRepo.Find(i.WorkspaceId)
+	if err != nil {
+		if errors.Is(err, db.ErrNoMoreRows) {
+			return domain.WorkspaceMember{}, ErrInvalidInvitation
+		}
+		log.Printf("WorkspaceService: %s", err)
+		return domain.WorkspaceMember{}, err
+	}
+
+	// only one of concurrent accepts wins, so the member is added once
+	fresh, err := s.invitationRepo.Accept(i.Id)
+	if err != nil {
+		log.Printf("WorkspaceService: %s", err)
+		return domain.WorkspaceMember{}, err
+	}
+	if !fresh {
+		return domain.WorkspaceMember{}, ErrInvalidInvitation
+	}
+
+	member, err := s.memberRepo.Find(i.WorkspaceId, user.Id)
+	if err == nil {
+		return member, nil
+	} else if !errors.Is(err, db.ErrNoMoreRows) {
+		log.Printf("WorkspaceService: %s", err)
+		return domain.WorkspaceMember{}, err
+	}
+
+	member, err = s.memberRepo.Save(domain.WorkspaceMember{
+		WorkspaceId: i.WorkspaceId,
+		UserId:      user.Id,
+		Role:        i.Role,
+	})
+	if err != nil {
+		log.Printf("WorkspaceService: %s", err)
+		return domain.WorkspaceMember{}, err
+	}
+
+	return member, nil
+}
+
+func (s workspaceService) findMember(workspaceId, userId uint64) (domain.WorkspaceMember, error) {
+	member, err := s.memberRepo.Find(workspaceId, userId)
+	if err != nil {
+		if errors.Is(err, db.ErrNoMoreRows) {
+			return domain.WorkspaceMember{}, ErrMemberNotFound
+		}
+		log.Printf("WorkspaceService: %s", err)
+		return domain.WorkspaceMember{}, err
+	}
+
+	return member, nil
+}
+
+// checkMemberManagement enforces that the owner role is never moved
+// and that only the owner can manage admins
+func checkMemberManagement(actorRole, currentRole, newRole domain.WorkspaceRole) error {
+	if currentRole == domain.WorkspaceOwnerRole || newRole == domain.WorkspaceOwnerRole {
+		return ErrOwnerRoleImmutable
+	}
+
+	if actorRole != domain.WorkspaceOwnerRole &&
+		(currentRole == domain.WorkspaceAdminRole || newRole == domain.WorkspaceAdminRole) {
+		return ErrAccessDenied
+	}
+
+	return nil
+}
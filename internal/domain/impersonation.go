@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ImpersonationAction string
+
+const (
+	ImpersonationStarted ImpersonationAction = "start"
+	ImpersonationRequest ImpersonationAction = "request"
+	ImpersonationBlocked ImpersonationAction = "blocked"
+	ImpersonationStopped ImpersonationAction = "stop"
+)
+
+// Impersonation is what an admin asks for when signing in as another user
+type Impersonation struct {
+	AllowDestructive bool
+	Reason           string
+}
+
+// ImpersonationAudit records one step of an impersonation session,
+// Method, Path and Status are set only for requests
+type ImpersonationAudit struct {
+	Id          uint64
+	SessionUUID uuid.UUID
+	ActorId     uint64
+	UserId      uint64
+	Action      ImpersonationAction
+	Method      string
+	Path        string
+	Status      int
+	Reason      string
+	Ip          string
+	CreatedDate time.Time
+}
@@ -0,0 +1,21 @@
+package domain
+
+import "time"
+
+// LoginAttempt counts recent failures for one account or one IP,
+// Key is prefixed with the kind, e.g. "account:jane@example.com" or "ip:10.0.0.1"
+type LoginAttempt struct {
+	Key           string
+	Failures      int
+	LastFailureAt time.Time
+	BlockedUntil  *time.Time
+}
+
+type LockoutEvent struct {
+	Id          uint64
+	Key         string
+	Ip          string
+	Failures    int
+	LockedUntil time.Time
+	CreatedDate time.Time
+}
@@ -0,0 +1,14 @@
+package domain
+
+import "time"
+
+// MagicLink is a one-time login link, NonceHash binds it to the device which requested it
+type MagicLink struct {
+	Id          uint64
+	UserId      uint64
+	TokenHash   string
+	NonceHash   string
+	ExpiresAt   time.Time
+	UsedDate    *time.Time
+	CreatedDate time.Time
+}
@@ -0,0 +1,12 @@
+package domain
+
+import "time"
+
+type PasswordReset struct {
+	Id          uint64
+	UserId      uint64
+	TokenHash   string
+	ExpiresAt   time.Time
+	UsedDate    *time.Time
+	CreatedDate time.Time
+}
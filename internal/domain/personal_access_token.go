@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"slices"
+	"time"
+)
+
+const PersonalAccessTokenPrefix = "pat_"
+
+type Scope string
+
+const (
+	TasksReadScope  Scope = "tasks:read"
+	TasksWriteScope Scope = "tasks:write"
+	UsersReadScope  Scope = "users:read"
+)
+
+type PersonalAccessToken struct {
+	Id          uint64
+	UserId      uint64
+	Name        string
+	Token       string
+	TokenHash   string
+	Scopes      []Scope
+	ExpiresAt   *time.Time
+	LastUsedAt  *time.Time
+	RevokedDate *time.Time
+	CreatedDate time.Time
+}
+
+func (t PersonalAccessToken) IsActive() bool {
+	return t.RevokedDate == nil && (t.ExpiresAt == nil || time.Now().Before(*t.ExpiresAt))
+}
+
+func (t PersonalAccessToken) HasScope(s Scope) bool {
+	return slices.Contains(t.Scopes, s)
+}
@@ -0,0 +1,21 @@
+package domain
+
+import "time"
+
+type PublicLink struct {
+	Id             uint64
+	UserId         uint64
+	TaskId         *uint64
+	WorkspaceId    *uint64
+	Token          string
+	TokenHash      string
+	ExpiresAt      *time.Time
+	RevokedDate    *time.Time
+	AccessCount    uint64
+	LastAccessedAt *time.Time
+	CreatedDate    time.Time
+}
+
+func (l PublicLink) IsActive() bool {
+	return l.RevokedDate == nil && (l.ExpiresAt == nil || time.Now().Before(*l.ExpiresAt))
+}
@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type SecurityEventType string
+
+const (
+	SecurityEventRegister       SecurityEventType = "register"
+	SecurityEventLoginSuccess   SecurityEventType = "login_success"
+	SecurityEventLoginFailure   SecurityEventType = "login_failure"
+	SecurityEventLogout         SecurityEventType = "logout"
+	SecurityEventPasswordChange SecurityEventType = "password_change"
+	SecurityEventTokenRefresh   SecurityEventType = "token_refresh"
+	SecurityEventLockout        SecurityEventType = "lockout"
+	SecurityEventRoleChange     SecurityEventType = "role_change"
+)
+
+var SecurityEventTypes = []SecurityEventType{
+	SecurityEventRegister,
+	SecurityEventLoginSuccess,
+	SecurityEventLoginFailure,
+	SecurityEventLogout,
+	SecurityEventPasswordChange,
+	SecurityEventTokenRefresh,
+	SecurityEventLockout,
+	SecurityEventRoleChange,
+}
+
+// LoginMethod tells how a login was completed,
+// LoginMethodMfa is the second factor following any of the others
+type LoginMethod string
+
+const (
+	LoginMethodPassword  LoginMethod = "password"
+	LoginMethodMagicLink LoginMethod = "magic_link"
+	LoginMethodOidc      LoginMethod = "oidc"
+	LoginMethodMfa       LoginMethod = "mfa"
+)
+
+// SecurityEvent is an entry of the authentication audit log,
+// UserId is nil when a login failed for an unknown email
+type SecurityEvent struct {
+	Id          uint64
+	UserId      *uint64
+	Type        SecurityEventType
+	Email       string
+	Ip          string
+	UserAgent   string
+	SessionUUID *uuid.UUID
+	// Details is a short machine readable note, e.g. the failure reason or the new role
+	Details     string
+	CreatedDate time.Time
+}
+
+type SecurityEventFilters struct {
+	UserId *uint64
+	Type   *SecurityEventType
+	Email  string
+	Ip     string
+	From   *time.Time
+	To     *time.Time
+	Pagination
+}
+
+type SecurityEvents struct {
+	Items []SecurityEvent
+	Total uint64
+	Pages uint
+}
@@ -1,10 +1,49 @@
 package domain
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 )
 
 type Session struct {
-	UserId uint64
-	UUID   uuid.UUID
+	UserId      uint64
+	UUID        uuid.UUID
+	UserAgent   string
+	Ip          string
+	CreatedDate time.Time
+	LastSeenAt  *time.Time
+	ExpiresAt   time.Time
+	// ActorId is the admin acting on behalf of UserId, nil for own sessions
+	ActorId          *uint64
+	AllowDestructive bool
+}
+
+func (s Session) IsImpersonation() bool {
+	return s.ActorId != nil
+}
+
+// Device describes the client a session is created for
+type Device struct {
+	UserAgent string
+	Ip        string
+}
+
+// AuthTokens holds only MfaToken while the second factor is not passed yet
+type AuthTokens struct {
+	AccessToken  string
+	RefreshToken string
+	MfaToken     string
+}
+
+// RefreshToken belongs to a single session, all tokens rotated
+// from the same login form one family identified by the session UUID
+type RefreshToken struct {
+	Id          uint64
+	UserId      uint64
+	SessionUUID uuid.UUID
+	TokenHash   string
+	ExpiresAt   time.Time
+	UsedDate    *time.Time
+	CreatedDate time.Time
 }
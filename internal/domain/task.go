@@ -5,6 +5,9 @@ import "time"
 type Task struct {
 	Id          uint64
 	UserId      uint64
+	WorkspaceId *uint64
+	AssigneeId  *uint64
+	Assignee    *User
 	Title       string
 	Description *string
 	Date        *time.Time
@@ -21,3 +24,14 @@ const (
 	TaskInProgress TaskStatus = "IN_PROGRESS"
 	TaskComplete   TaskStatus = "COMPLETE"
 )
+
+type TaskFilters struct {
+	UserId uint64
+	// WorkspaceId selects workspace tasks instead of personal ones
+	WorkspaceId *uint64
+	Status      *TaskStatus
+	Date        *time.Time
+	Shared      bool
+	// Assigned limits the result to tasks assigned to UserId
+	Assigned bool
+}
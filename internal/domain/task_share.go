@@ -0,0 +1,31 @@
+package domain
+
+import "time"
+
+type TaskShare struct {
+	Id          uint64
+	TaskId      uint64
+	UserId      uint64
+	Permission  Permission
+	CreatedDate time.Time
+	UpdatedDate time.Time
+}
+
+type Permission string
+
+const (
+	ViewerPermission Permission = "VIEWER"
+	EditorPermission Permission = "EDITOR"
+	OwnerPermission  Permission = "OWNER"
+)
+
+var permissionLevels = map[Permission]int{
+	ViewerPermission: 1,
+	EditorPermission: 2,
+	OwnerPermission:  3,
+}
+
+// Allows reports whether p grants at least the rights of the required permission
+func (p Permission) Allows(required Permission) bool {
+	return permissionLevels[p] >= permissionLevels[required] && permissionLevels[p] > 0
+}
@@ -0,0 +1,17 @@
+package domain
+
+import "time"
+
+type TwoFactorEnrollment struct {
+	Secret string
+	Uri    string
+	QrCode []byte
+}
+
+type RecoveryCode struct {
+	Id          uint64
+	UserId      uint64
+	CodeHash    string
+	UsedDate    *time.Time
+	CreatedDate time.Time
+}
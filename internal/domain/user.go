@@ -1,19 +1,34 @@
 package domain
 
 import (
+	"fmt"
+	"path"
 	"time"
 )
 
+var AvatarSizes = []int{64, 128, 256}
+
 type User struct {
-	Id          uint64
-	Email       string
-	Password    string
-	FirstName   string
-	SecondName  string
-	Role        Role
-	CreatedDate time.Time
-	UpdatedDate time.Time
-	DeletedDate *time.Time
+	Id              uint64
+	Email           string
+	Password        string
+	FirstName       string
+	SecondName      string
+	Role            Role
+	Avatar          *string
+	EmailVerifiedAt *time.Time
+	TotpSecret      *string // encrypted with the app key, only TwoFactorService reads it
+	TotpEnabledAt   *time.Time
+	SuspendedAt     *time.Time
+	CreatedDate     time.Time
+	UpdatedDate     time.Time
+	DeletedDate     *time.Time
+	// DeletionRequestedAt is set when the owner or an admin deletes the account,
+	// unlike a deactivation such a deletion ends with the anonymization
+	DeletionRequestedAt *time.Time
+	// AnonymizedAt is set once the grace period of a deleted account is over
+	// and its personal data is erased, such an account can not be restored
+	AnonymizedAt *time.Time
 }
 
 type Role string
@@ -23,6 +38,24 @@ const (
 	CustomerRole Role = "CUSTOMER"
 )
 
+type UserFilters struct {
+	// Search matches a part of the email or the name
+	Search    string
+	Role      *Role
+	Suspended *bool
+	// Email matches the whole email ignoring the case
+	Email string
+	// StartIndex is the 1-based position of the first item, it replaces Page when set (SCIM addresses items, not pages)
+	StartIndex uint64
+	Pagination
+}
+
+type Users struct {
+	Items []User
+	Total uint64
+	Pages uint
+}
+
 type ChangePassword struct {
 	OldPassword string
 	NewPassword string
@@ -31,3 +64,20 @@ type ChangePassword struct {
 func (u User) GetUserId() uint64 {
 	return u.Id
 }
+
+func (u User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+func (u User) IsSuspended() bool {
+	return u.SuspendedAt != nil
+}
+
+func (u User) IsTwoFactorEnabled() bool {
+	return u.TotpEnabledAt != nil
+}
+
+// AvatarPath returns the storage path of the avatar thumbnail with the given size
+func AvatarPath(avatar string, size int) string {
+	return path.Join("avatars", fmt.Sprintf("%s_%d.png", avatar, size))
+}
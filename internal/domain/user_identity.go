@@ -0,0 +1,23 @@
+package domain
+
+import "time"
+
+// UserIdentity links an account of an external OpenID provider to a user
+type UserIdentity struct {
+	Id          uint64
+	UserId      uint64
+	Issuer      string
+	Subject     string
+	Email       string
+	CreatedDate time.Time
+}
+
+// OidcLogin is a started login, kept until the provider redirects back with the code
+type OidcLogin struct {
+	StateHash    string
+	CodeVerifier string
+	Nonce        string
+	// ClientNonceHash binds the login to the client which started it
+	ClientNonceHash string
+	ExpiresAt       time.Time
+}
@@ -0,0 +1,67 @@
+package domain
+
+import "time"
+
+type Workspace struct {
+	Id          uint64
+	Name        string
+	OwnerId     uint64
+	CreatedDate time.Time
+	UpdatedDate time.Time
+	DeletedDate *time.Time
+}
+
+type WorkspaceMember struct {
+	WorkspaceId uint64
+	UserId      uint64
+	User        *User
+	Role        WorkspaceRole
+	CreatedDate time.Time
+	UpdatedDate time.Time
+}
+
+type WorkspaceInvitation struct {
+	Id           uint64
+	WorkspaceId  uint64
+	InvitedBy    uint64
+	Email        string
+	Role         WorkspaceRole
+	TokenHash    string
+	ExpiresAt    time.Time
+	AcceptedDate *time.Time
+	CreatedDate  time.Time
+}
+
+type WorkspaceRole string
+
+const (
+	WorkspaceOwnerRole  WorkspaceRole = "OWNER"
+	WorkspaceAdminRole  WorkspaceRole = "ADMIN"
+	WorkspaceMemberRole WorkspaceRole = "MEMBER"
+	WorkspaceGuestRole  WorkspaceRole = "GUEST"
+)
+
+var workspaceRoleLevels = map[WorkspaceRole]int{
+	WorkspaceGuestRole:  1,
+	WorkspaceMemberRole: 2,
+	WorkspaceAdminRole:  3,
+	WorkspaceOwnerRole:  4,
+}
+
+// Allows reports whether r grants at least the rights of the required role
+func (r WorkspaceRole) Allows(required WorkspaceRole) bool {
+	return workspaceRoleLevels[r] >= workspaceRoleLevels[required] && workspaceRoleLevels[r] > 0
+}
+
+// TaskPermission returns the permission the role grants on the workspace tasks
+func (r WorkspaceRole) TaskPermission() Permission {
+	switch r {
+	case WorkspaceOwnerRole, WorkspaceAdminRole:
+		return OwnerPermission
+	case WorkspaceMemberRole:
+		return EditorPermission
+	case WorkspaceGuestRole:
+		return ViewerPermission
+	}
+	return ""
+}
@@ -0,0 +1,155 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/upper/db/v4"
+)
+
+type AccountErasureRepository interface {
+	Erase(anonymized domain.User) error
+}
+
+type accountErasureRepository struct {
+	sess db.Session
+}
+
+func NewAccountErasureRepository(sess db.Session) AccountErasureRepository {
+	return accountErasureRepository{
+		sess: sess,
+	}
+}
+
+// Erase replaces the personal data of a deleted user with the fields of anonymized
+// and removes everything else tied to them in one transaction:
+//   - owned workspaces go to the oldest admin, else the oldest member, and are deleted when nobody is left;
+//   - tasks created in a workspace go to its owner, personal tasks are deleted;
+//   - memberships, shares, links, tokens, sessions and security events are deleted.
+//
+// Returns db.ErrNoMoreRows when the user was restored or anonymized in the meantime,
+// or when the account is only deactivated
+func (r accountErasureRepository) Erase(anonymized domain.User) error {
+	return r.sess.Tx(func(tx db.Session) error {
+		id := anonymized.Id
+
+		// the lock keeps a concurrent restore from racing with the erasure
+		row, err := tx.SQL().QueryRow(`
+			SELECT email FROM `+UsersTableName+`
+			WHERE id = ? AND deleted_date IS NOT NULL AND deletion_requested_at IS NOT NULL AND anonymized_at IS NULL
+			FOR UPDATE`,
+			id,
+		)
+		if err != nil {
+			return err
+		}
+		var email string
+		err = row.Scan(&email)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return db.ErrNoMoreRows
+			}
+			return err
+		}
+
+		err = r.handOverWorkspaces(tx, id)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		statements := []struct {
+			query string
+			args  []interface{}
+		}{
+			{`UPDATE ` + TasksTableName + ` t SET user_id = w.owner_id, updated_date = ?
+				FROM ` + WorkspacesTableName + ` w
+				WHERE t.workspace_id = w.id AND t.user_id = ?`, []interface{}{now, id}},
+			{`DELETE FROM ` + TasksTableName + ` WHERE user_id = ?`, []interface{}{id}},
+			{`UPDATE ` + TasksTableName + ` SET assignee_id = NULL WHERE assignee_id = ?`, []interface{}{id}},
+			{`DELETE FROM ` + WorkspaceMembersTableName + ` WHERE user_id = ?`, []interface{}{id}},
+			{`DELETE FROM ` + WorkspaceInvitationsTableName + ` WHERE invited_by = ? OR lower(email) = lower(?)`, []interface{}{id, email}},
+			{`DELETE FROM ` + TaskSharesTableName + ` WHERE user_id = ?`, []interface{}{id}},
+			{`DELETE FROM ` + PublicLinksTableName + ` WHERE user_id = ?`, []interface{}{id}},
+			{`DELETE FROM ` + PersonalAccessTokensTableName + ` WHERE user_id = ?`, []interface{}{id}},
+			{`DELETE FROM ` + RecoveryCodesTableName + ` WHERE user_id = ?`, []interface{}{id}},
+			{`DELETE FROM ` + MagicLinksTableName + ` WHERE user_id = ?`, []interface{}{id}},
+			{`DELETE FROM ` + PasswordResetsTableName + ` WHERE user_id = ?`, []interface{}{id}},
+			{`DELETE FROM ` + UserIdentitiesTableName + ` WHERE user_id = ?`, []interface{}{id}},
+			{`DELETE FROM ` + SecurityEventsTableName + ` WHERE user_id = ?`, []interface{}{id}},
+			{`DELETE FROM ` + SessionsTableName + ` WHERE user_id = ?`, []interface{}{id}},
+			{`DELETE FROM ` + LoginAttemptsTableName + ` WHERE attempt_key = ?`, []interface{}{"account:" + strings.ToLower(email)}},
+			{`UPDATE ` + UsersTableName + ` SET
+				email = ?, first_name = ?, second_name = ?, password = '',
+				avatar = NULL, email_verified_at = NULL,
+				totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = NULL,
+				anonymized_at = ?, updated_date = ?
+				WHERE id = ?`,
+				[]interface{}{anonymized.Email, anonymized.FirstName, anonymized.SecondName, now, now, id}},
+		}
+		for _, st := range statements {
+			_, err = tx.SQL().Exec(st.query, st.args...)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (r accountErasureRepository) handOverWorkspaces(tx db.Session, userId uint64) error {
+	var owned []workspace
+	err := tx.Collection(WorkspacesTableName).Find(db.Cond{"owner_id": userId}).All(&owned)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, w := range owned {
+		row, err := tx.SQL().QueryRow(`
+			SELECT user_id FROM `+WorkspaceMembersTableName+`
+			WHERE workspace_id = ? AND user_id <> ?
+			ORDER BY "role" = ? DESC, created_date
+			LIMIT 1`,
+			w.Id, userId, domain.WorkspaceAdminRole,
+		)
+		if err != nil {
+			return err
+		}
+
+		var successor uint64
+		err = row.Scan(&successor)
+		if errors.Is(err, sql.ErrNoRows) {
+			// tasks, members, invitations and links go with the workspace
+			_, err = tx.SQL().Exec(`DELETE FROM `+WorkspacesTableName+` WHERE id = ?`, w.Id)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.SQL().Exec(
+			`UPDATE `+WorkspacesTableName+` SET owner_id = ?, updated_date = ? WHERE id = ?`,
+			successor, now, w.Id,
+		)
+		if err != nil {
+			return err
+		}
+		_, err = tx.SQL().Exec(
+			`UPDATE `+WorkspaceMembersTableName+` SET "role" = ?, updated_date = ? WHERE workspace_id = ? AND user_id = ?`,
+			domain.WorkspaceOwnerRole, now, w.Id, successor,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package database
+
+import (
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/google/uuid"
+	"github.com/upper/db/v4"
+)
+
+const ImpersonationAuditTableName = "impersonation_audit"
+
+type impersonationAudit struct {
+	Id          uint64    `db:"id,omitempty"`
+	SessionUUID uuid.UUID `db:"session_uuid"`
+	ActorId     uint64    `db:"actor_id"`
+	UserId      uint64    `db:"user_id"`
+	Action      string    `db:"action"`
+	Method      string    `db:"method"`
+	Path        string    `db:"path"`
+	Status      int       `db:"status"`
+	Reason      string    `db:"reason"`
+	Ip          string    `db:"ip"`
+	CreatedDate time.Time `db:"created_date"`
+}
+
+type ImpersonationAuditRepository interface {
+	Save(a domain.ImpersonationAudit) (domain.ImpersonationAudit, error)
+}
+
+type impersonationAuditRepository struct {
+	coll db.Collection
+}
+
+func NewImpersonationAuditRepository(sess db.Session) ImpersonationAuditRepository {
+	return impersonationAuditRepository{
+		coll: sess.Collection(ImpersonationAuditTableName),
+	}
+}
+
+func (r impersonationAuditRepository) Save(a domain.ImpersonationAudit) (domain.ImpersonationAudit, error) {
+	m := r.mapDomainToModel(a)
+	m.CreatedDate = time.Now()
+	err := r.coll.InsertReturning(&m)
+	if err != nil {
+		return domain.ImpersonationAudit{}, err
+	}
+
+	return r.mapModelToDomain(m), nil
+}
+
+func (r impersonationAuditRepository) mapDomainToModel(d domain.ImpersonationAudit) impersonationAudit {
+	return impersonationAudit{
+		Id:          d.Id,
+		SessionUUID: d.SessionUUID,
+		ActorId:     d.ActorId,
+		UserId:      d.UserId,
+		Action:      string(d.Action),
+		Method:      d.Method,
+		Path:        d.Path,
+		Status:      d.Status,
+		Reason:      d.Reason,
+		Ip:          d.Ip,
+		CreatedDate: d.CreatedDate,
+	}
+}
+
+func (r impersonationAuditRepository) mapModelToDomain(m impersonationAudit) domain.ImpersonationAudit {
+	return domain.ImpersonationAudit{
+		Id:          m.Id,
+		SessionUUID: m.SessionUUID,
+		ActorId:     m.ActorId,
+		UserId:      m.UserId,
+		Action:      domain.ImpersonationAction(m.Action),
+		Method:      m.Method,
+		Path:        m.Path,
+		Status:      m.Status,
+		Reason:      m.Reason,
+		Ip:          m.Ip,
+		CreatedDate: m.CreatedDate,
+	}
+}
@@ -0,0 +1,66 @@
+package database
+
+import (
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/upper/db/v4"
+)
+
+const LockoutEventsTableName = "lockout_events"
+
+type lockoutEvent struct {
+	Id          uint64    `db:"id,omitempty"`
+	Key         string    `db:"attempt_key"`
+	Ip          string    `db:"ip"`
+	Failures    int       `db:"failures"`
+	LockedUntil time.Time `db:"locked_until"`
+	CreatedDate time.Time `db:"created_date"`
+}
+
+type LockoutEventRepository interface {
+	Save(e domain.LockoutEvent) (domain.LockoutEvent, error)
+}
+
+type lockoutEventRepository struct {
+	coll db.Collection
+}
+
+func NewLockoutEventRepository(sess db.Session) LockoutEventRepository {
+	return lockoutEventRepository{
+		coll: sess.Collection(LockoutEventsTableName),
+	}
+}
+
+func (r lockoutEventRepository) Save(e domain.LockoutEvent) (domain.LockoutEvent, error) {
+	m := r.mapDomainToModel(e)
+	m.CreatedDate = time.Now()
+	err := r.coll.InsertReturning(&m)
+	if err != nil {
+		return domain.LockoutEvent{}, err
+	}
+
+	return r.mapModelToDomain(m), nil
+}
+
+func (r lockoutEventRepository) mapDomainToModel(d domain.LockoutEvent) lockoutEvent {
+	return lockoutEvent{
+		Id:          d.Id,
+		Key:         d.Key,
+		Ip:          d.Ip,
+		Failures:    d.Failures,
+		LockedUntil: d.LockedUntil,
+		CreatedDate: d.CreatedDate,
+	}
+}
+
+func (r lockoutEventRepository) mapModelToDomain(m lockoutEvent) domain.LockoutEvent {
+	return domain.LockoutEvent{
+		Id:          m.Id,
+		Key:         m.Key,
+		Ip:          m.Ip,
+		Failures:    m.Failures,
+		LockedUntil: m.LockedUntil,
+		CreatedDate: m.CreatedDate,
+	}
+}
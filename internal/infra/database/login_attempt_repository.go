@@ -0,0 +1,96 @@
+package database
+
+import (
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/upper/db/v4"
+)
+
+const LoginAttemptsTableName = "login_attempts"
+
+type LoginAttemptRepository interface {
+	FindBlockedUntil(keys []string) (*time.Time, error)
+	RegisterFailure(key string, window time.Duration) (domain.LoginAttempt, error)
+	Block(key string, until time.Time) error
+	Reset(key string) error
+	DeleteStale(window time.Duration) error
+}
+
+type loginAttemptRepository struct {
+	coll db.Collection
+	sess db.Session
+}
+
+func NewLoginAttemptRepository(sess db.Session) LoginAttemptRepository {
+	return loginAttemptRepository{
+		coll: sess.Collection(LoginAttemptsTableName),
+		sess: sess,
+	}
+}
+
+// FindBlockedUntil returns the latest block among the keys or nil
+func (r loginAttemptRepository) FindBlockedUntil(keys []string) (*time.Time, error) {
+	var until *time.Time
+	row, err := r.sess.SQL().
+		Select(db.Raw("MAX(blocked_until)")).
+		From(LoginAttemptsTableName).
+		Where(db.Cond{"attempt_key IN": keys, "blocked_until >": time.Now()}).
+		QueryRow()
+	if err != nil {
+		return nil, err
+	}
+
+	err = row.Scan(&until)
+	if err != nil {
+		return nil, err
+	}
+
+	return until, nil
+}
+
+// RegisterFailure increments the counter in one statement, so concurrent
+// requests hitting different instances can't lose an increment.
+// Failures older than the window start the count over
+func (r loginAttemptRepository) RegisterFailure(key string, window time.Duration) (domain.LoginAttempt, error) {
+	now := time.Now()
+	row, err := r.sess.SQL().QueryRow(`
+		INSERT INTO `+LoginAttemptsTableName+` (attempt_key, failures, last_failure_at)
+		VALUES (?, 1, ?)
+		ON CONFLICT (attempt_key) DO UPDATE SET
+			failures = CASE
+				WHEN `+LoginAttemptsTableName+`.last_failure_at < ? THEN 1
+				ELSE `+LoginAttemptsTableName+`.failures + 1
+			END,
+			last_failure_at = EXCLUDED.last_failure_at
+		RETURNING attempt_key, failures, last_failure_at, blocked_until`,
+		key, now, now.Add(-window),
+	)
+	if err != nil {
+		return domain.LoginAttempt{}, err
+	}
+
+	var a domain.LoginAttempt
+	err = row.Scan(&a.Key, &a.Failures, &a.LastFailureAt, &a.BlockedUntil)
+	if err != nil {
+		return domain.LoginAttempt{}, err
+	}
+
+	return a, nil
+}
+
+func (r loginAttemptRepository) Block(key string, until time.Time) error {
+	return r.coll.Find(db.Cond{"attempt_key": key}).Update(map[string]interface{}{"blocked_until": until})
+}
+
+func (r loginAttemptRepository) Reset(key string) error {
+	return r.coll.Find(db.Cond{"attempt_key": key}).Delete()
+}
+
+func (r loginAttemptRepository) DeleteStale(window time.Duration) error {
+	now := time.Now()
+	return r.coll.Find(db.And(
+		db.Cond{"last_failure_at <": now.Add(-window)},
+		db.Or(db.Cond{"blocked_until": nil}, db.Cond{"blocked_until <": now}),
+	)).Delete()
+}
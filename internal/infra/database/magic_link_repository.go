@@ -0,0 +1,112 @@
+package database
+
+import (
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/upper/db/v4"
+)
+
+const MagicLinksTableName = "magic_links"
+
+type magicLink struct {
+	Id          uint64     `db:"id,omitempty"`
+	UserId      uint64     `db:"user_id"`
+	TokenHash   string     `db:"token_hash"`
+	NonceHash   string     `db:"nonce_hash"`
+	ExpiresAt   time.Time  `db:"expires_at"`
+	UsedDate    *time.Time `db:"used_date"`
+	CreatedDate time.Time  `db:"created_date"`
+}
+
+type MagicLinkRepository interface {
+	Save(ml domain.MagicLink) (domain.MagicLink, error)
+	FindByTokenHash(hash string) (domain.MagicLink, error)
+	CountSince(userId uint64, since time.Time) (uint64, error)
+	MarkUsed(id uint64) (bool, error)
+	DeleteExpired() error
+}
+
+type magicLinkRepository struct {
+	coll db.Collection
+	sess db.Session
+}
+
+func NewMagicLinkRepository(sess db.Session) MagicLinkRepository {
+	return magicLinkRepository{
+		coll: sess.Collection(MagicLinksTableName),
+		sess: sess,
+	}
+}
+
+func (r magicLinkRepository) Save(ml domain.MagicLink) (domain.MagicLink, error) {
+	m := r.mapDomainToModel(ml)
+	m.CreatedDate = time.Now()
+	err := r.coll.InsertReturning(&m)
+	if err != nil {
+		return domain.MagicLink{}, err
+	}
+
+	return r.mapModelToDomain(m), nil
+}
+
+func (r magicLinkRepository) FindByTokenHash(hash string) (domain.MagicLink, error) {
+	var m magicLink
+	err := r.coll.Find(db.Cond{"token_hash": hash}).One(&m)
+	if err != nil {
+		return domain.MagicLink{}, err
+	}
+
+	return r.mapModelToDomain(m), nil
+}
+
+func (r magicLinkRepository) CountSince(userId uint64, since time.Time) (uint64, error) {
+	return r.coll.Find(db.Cond{"user_id": userId, "created_date >=": since}).Count()
+}
+
+// MarkUsed reports false when the link was already used
+func (r magicLinkRepository) MarkUsed(id uint64) (bool, error) {
+	res, err := r.sess.SQL().
+		Update(MagicLinksTableName).
+		Set("used_date", time.Now()).
+		Where(db.Cond{"id": id, "used_date": nil}).
+		Exec()
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
+func (r magicLinkRepository) DeleteExpired() error {
+	return r.coll.Find(db.Cond{"expires_at <=": time.Now()}).Delete()
+}
+
+func (r magicLinkRepository) mapDomainToModel(d domain.MagicLink) magicLink {
+	return magicLink{
+		Id:          d.Id,
+		UserId:      d.UserId,
+		TokenHash:   d.TokenHash,
+		NonceHash:   d.NonceHash,
+		ExpiresAt:   d.ExpiresAt,
+		UsedDate:    d.UsedDate,
+		CreatedDate: d.CreatedDate,
+	}
+}
+
+func (r magicLinkRepository) mapModelToDomain(m magicLink) domain.MagicLink {
+	return domain.MagicLink{
+		Id:          m.Id,
+		UserId:      m.UserId,
+		TokenHash:   m.TokenHash,
+		NonceHash:   m.NonceHash,
+		ExpiresAt:   m.ExpiresAt,
+		UsedDate:    m.UsedDate,
+		CreatedDate: m.CreatedDate,
+	}
+}
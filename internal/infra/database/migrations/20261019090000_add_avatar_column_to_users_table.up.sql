@@ -0,0 +1,4 @@
+ALTER TABLE
+    public.users
+ADD
+    COLUMN avatar varchar(50);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS public.task_shares
+(
+    id              serial PRIMARY KEY,
+    task_id         integer NOT NULL REFERENCES public.tasks(id) ON DELETE CASCADE,
+    user_id         integer NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    permission      varchar(50) NOT NULL,
+    created_date    timestamptz NOT NULL,
+    updated_date    timestamptz NOT NULL,
+    CONSTRAINT task_shares_task_user_key UNIQUE (task_id, user_id)
+);
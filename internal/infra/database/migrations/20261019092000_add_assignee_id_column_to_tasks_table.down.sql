@@ -0,0 +1,2 @@
+ALTER TABLE
+    public.tasks DROP COLUMN IF EXISTS assignee_id;
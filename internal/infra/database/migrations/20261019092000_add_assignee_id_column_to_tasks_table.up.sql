@@ -0,0 +1,4 @@
+ALTER TABLE
+    public.tasks
+ADD
+    COLUMN assignee_id integer REFERENCES public.users(id) ON DELETE SET NULL;
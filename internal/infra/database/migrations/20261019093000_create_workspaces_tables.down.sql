@@ -0,0 +1,6 @@
+ALTER TABLE
+    public.tasks DROP COLUMN IF EXISTS workspace_id;
+
+DROP TABLE IF EXISTS public.workspace_invitations;
+DROP TABLE IF EXISTS public.workspace_members;
+DROP TABLE IF EXISTS public.workspaces;
@@ -0,0 +1,37 @@
+CREATE TABLE IF NOT EXISTS public.workspaces
+(
+    id              serial PRIMARY KEY,
+    name            varchar(50) NOT NULL,
+    owner_id        integer NOT NULL REFERENCES public.users(id),
+    created_date    timestamptz NOT NULL,
+    updated_date    timestamptz NOT NULL,
+    deleted_date    timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS public.workspace_members
+(
+    workspace_id    integer NOT NULL REFERENCES public.workspaces(id) ON DELETE CASCADE,
+    user_id         integer NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    "role"          varchar(50) NOT NULL,
+    created_date    timestamptz NOT NULL,
+    updated_date    timestamptz NOT NULL,
+    CONSTRAINT workspace_members_pkey PRIMARY KEY (workspace_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS public.workspace_invitations
+(
+    id              serial PRIMARY KEY,
+    workspace_id    integer NOT NULL REFERENCES public.workspaces(id) ON DELETE CASCADE,
+    invited_by      integer NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    email           varchar(255) NOT NULL,
+    "role"          varchar(50) NOT NULL,
+    token_hash      varchar(64) NOT NULL UNIQUE,
+    expires_at      timestamptz NOT NULL,
+    accepted_date   timestamptz,
+    created_date    timestamptz NOT NULL
+);
+
+ALTER TABLE
+    public.tasks
+ADD
+    COLUMN workspace_id integer REFERENCES public.workspaces(id) ON DELETE CASCADE;
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS public.public_links;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS public.public_links
+(
+    id                  serial PRIMARY KEY,
+    user_id             integer NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    task_id             integer REFERENCES public.tasks(id) ON DELETE CASCADE,
+    workspace_id        integer REFERENCES public.workspaces(id) ON DELETE CASCADE,
+    token_hash          varchar(64) NOT NULL UNIQUE,
+    expires_at          timestamptz,
+    revoked_date        timestamptz,
+    access_count        bigint NOT NULL DEFAULT 0,
+    last_accessed_at    timestamptz,
+    created_date        timestamptz NOT NULL,
+    CONSTRAINT public_links_target_check CHECK ((task_id IS NULL) <> (workspace_id IS NULL))
+);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS public.refresh_tokens
+(
+    id              serial PRIMARY KEY,
+    user_id         int         NOT NULL,
+    session_uuid    varchar(50) NOT NULL,
+    token_hash      varchar(64) NOT NULL UNIQUE,
+    expires_at      timestamptz NOT NULL,
+    used_date       timestamptz,
+    created_date    timestamptz NOT NULL,
+    CONSTRAINT refresh_tokens_session_fkey FOREIGN KEY (user_id, session_uuid)
+        REFERENCES public.sessions (user_id, uuid) ON DELETE CASCADE
+);
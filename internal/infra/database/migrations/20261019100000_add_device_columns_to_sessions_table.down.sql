@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS public.sessions_expires_at_idx;
+
+ALTER TABLE
+    public.sessions DROP COLUMN IF EXISTS created_date,
+    DROP COLUMN IF EXISTS last_seen_at,
+    DROP COLUMN IF EXISTS expires_at,
+    DROP COLUMN IF EXISTS user_agent,
+    DROP COLUMN IF EXISTS ip;
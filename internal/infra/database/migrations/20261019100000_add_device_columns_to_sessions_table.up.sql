@@ -0,0 +1,19 @@
+ALTER TABLE
+    public.sessions
+ADD
+    COLUMN created_date timestamptz NOT NULL DEFAULT now(),
+ADD
+    COLUMN last_seen_at timestamptz,
+ADD
+    COLUMN expires_at timestamptz NOT NULL DEFAULT now() + interval '30 days',
+ADD
+    COLUMN user_agent varchar(255) NOT NULL DEFAULT '',
+ADD
+    COLUMN ip varchar(50) NOT NULL DEFAULT '';
+
+ALTER TABLE
+    public.sessions
+ALTER COLUMN created_date DROP DEFAULT,
+ALTER COLUMN expires_at DROP DEFAULT;
+
+CREATE INDEX IF NOT EXISTS sessions_expires_at_idx ON public.sessions (expires_at);
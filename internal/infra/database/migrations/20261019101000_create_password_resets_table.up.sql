@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS public.password_resets
+(
+    id              serial PRIMARY KEY,
+    user_id         integer NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    token_hash      varchar(64) NOT NULL UNIQUE,
+    expires_at      timestamptz NOT NULL,
+    used_date       timestamptz,
+    created_date    timestamptz NOT NULL
+);
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS public.users_email_unique_idx;
+
+ALTER TABLE
+    public.users
+ALTER COLUMN
+    email TYPE varchar(50);
+
+ALTER TABLE
+    public.users DROP COLUMN IF EXISTS email_verified_at;
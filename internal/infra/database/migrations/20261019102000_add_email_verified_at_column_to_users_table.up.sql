@@ -0,0 +1,20 @@
+ALTER TABLE
+    public.users
+ADD
+    COLUMN email_verified_at timestamptz;
+
+-- accounts created before verification existed are trusted as is
+UPDATE
+    public.users
+SET
+    email_verified_at = created_date;
+
+-- the email is the login, so it is unique regardless of the case,
+-- a deleted account doesn't hold its email
+ALTER TABLE
+    public.users
+ALTER COLUMN
+    email TYPE varchar(255);
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_unique_idx ON public.users (lower(email))
+    WHERE deleted_date IS NULL;
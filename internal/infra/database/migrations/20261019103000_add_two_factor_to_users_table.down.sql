@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS public.recovery_codes;
+
+ALTER TABLE
+    public.users DROP COLUMN IF EXISTS totp_secret,
+    DROP COLUMN IF EXISTS totp_enabled_at,
+    DROP COLUMN IF EXISTS totp_last_step;
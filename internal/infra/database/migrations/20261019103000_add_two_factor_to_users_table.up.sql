@@ -0,0 +1,18 @@
+ALTER TABLE
+    public.users
+ADD
+    COLUMN totp_secret varchar(255),
+ADD
+    COLUMN totp_enabled_at timestamptz,
+ADD
+    COLUMN totp_last_step bigint;
+
+CREATE TABLE IF NOT EXISTS public.recovery_codes
+(
+    id              serial PRIMARY KEY,
+    user_id         integer NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    code_hash       varchar(64) NOT NULL,
+    used_date       timestamptz,
+    created_date    timestamptz NOT NULL,
+    UNIQUE (user_id, code_hash)
+);
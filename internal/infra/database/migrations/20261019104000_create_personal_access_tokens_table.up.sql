@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS public.personal_access_tokens
+(
+    id              serial PRIMARY KEY,
+    user_id         integer NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    name            varchar(100) NOT NULL,
+    token_hash      varchar(64) NOT NULL UNIQUE,
+    scopes          varchar(255) NOT NULL,
+    expires_at      timestamptz,
+    last_used_at    timestamptz,
+    revoked_date    timestamptz,
+    created_date    timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS personal_access_tokens_user_id_idx ON public.personal_access_tokens (user_id);
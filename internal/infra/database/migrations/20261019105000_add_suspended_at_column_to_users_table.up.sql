@@ -0,0 +1,12 @@
+ALTER TABLE
+    public.users
+ADD
+    COLUMN suspended_at timestamptz;
+
+-- registration used to leave the role empty
+UPDATE
+    public.users
+SET
+    "role" = 'CUSTOMER'
+WHERE
+    "role" = '';
@@ -0,0 +1,4 @@
+ALTER TABLE
+    public.users
+ALTER COLUMN
+    password TYPE varchar(255);
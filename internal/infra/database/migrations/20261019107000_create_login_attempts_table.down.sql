@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS public.lockout_events;
+DROP TABLE IF EXISTS public.login_attempts;
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS public.login_attempts
+(
+    attempt_key     varchar(150) PRIMARY KEY,
+    failures        integer NOT NULL,
+    last_failure_at timestamptz NOT NULL,
+    blocked_until   timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS public.lockout_events
+(
+    id              serial PRIMARY KEY,
+    attempt_key     varchar(150) NOT NULL,
+    ip              varchar(45) NOT NULL,
+    failures        integer NOT NULL,
+    locked_until    timestamptz NOT NULL,
+    created_date    timestamptz NOT NULL
+);
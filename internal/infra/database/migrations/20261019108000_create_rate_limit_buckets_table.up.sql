@@ -0,0 +1,9 @@
+CREATE UNLOGGED TABLE IF NOT EXISTS public.rate_limit_buckets
+(
+    bucket_key  varchar(150) PRIMARY KEY,
+    tokens      double precision NOT NULL,
+    updated_at  timestamptz NOT NULL,
+    full_at     timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS rate_limit_buckets_full_at_idx ON public.rate_limit_buckets (full_at);
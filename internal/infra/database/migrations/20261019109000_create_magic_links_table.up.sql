@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS public.magic_links
+(
+    id              serial PRIMARY KEY,
+    user_id         integer NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    token_hash      varchar(64) NOT NULL UNIQUE,
+    nonce_hash      varchar(64) NOT NULL,
+    expires_at      timestamptz NOT NULL,
+    used_date       timestamptz,
+    created_date    timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS magic_links_user_id_created_date_idx ON public.magic_links (user_id, created_date);
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS public.user_identities
+(
+    id              serial PRIMARY KEY,
+    user_id         integer NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    issuer          varchar(255) NOT NULL,
+    subject         varchar(255) NOT NULL,
+    email           varchar(255) NOT NULL,
+    created_date    timestamptz NOT NULL,
+    UNIQUE (issuer, subject)
+);
+
+CREATE TABLE IF NOT EXISTS public.oidc_logins
+(
+    state_hash          varchar(64) PRIMARY KEY,
+    client_nonce_hash   varchar(64) NOT NULL,
+    code_verifier       varchar(100) NOT NULL,
+    nonce               varchar(100) NOT NULL,
+    expires_at          timestamptz NOT NULL
+);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS public.impersonation_audit;
+
+ALTER TABLE
+    public.sessions DROP COLUMN IF EXISTS actor_id,
+    DROP COLUMN IF EXISTS allow_destructive;
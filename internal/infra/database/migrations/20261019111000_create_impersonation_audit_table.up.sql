@@ -0,0 +1,24 @@
+ALTER TABLE
+    public.sessions
+ADD
+    COLUMN actor_id integer REFERENCES public.users (id) ON DELETE CASCADE,
+ADD
+    COLUMN allow_destructive boolean NOT NULL DEFAULT false;
+
+CREATE TABLE IF NOT EXISTS public.impersonation_audit
+(
+    id              serial PRIMARY KEY,
+    session_uuid    uuid NOT NULL,
+    actor_id        integer NOT NULL REFERENCES public.users (id) ON DELETE CASCADE,
+    user_id         integer NOT NULL REFERENCES public.users (id) ON DELETE CASCADE,
+    action          varchar(20) NOT NULL,
+    method          varchar(10) NOT NULL DEFAULT '',
+    path            text NOT NULL DEFAULT '',
+    status          integer NOT NULL DEFAULT 0,
+    reason          varchar(255) NOT NULL DEFAULT '',
+    ip              varchar(50) NOT NULL DEFAULT '',
+    created_date    timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS impersonation_audit_session_uuid_idx ON public.impersonation_audit (session_uuid);
+CREATE INDEX IF NOT EXISTS impersonation_audit_actor_id_idx ON public.impersonation_audit (actor_id);
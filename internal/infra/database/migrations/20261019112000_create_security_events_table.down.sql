@@ -0,0 +1 @@
+DROP TABLE IF EXISTS public.security_events;
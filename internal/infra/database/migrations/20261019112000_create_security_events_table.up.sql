@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS public.security_events
+(
+    id              serial PRIMARY KEY,
+    user_id         integer REFERENCES public.users (id) ON DELETE CASCADE,
+    type            varchar(30) NOT NULL,
+    email           varchar(255) NOT NULL DEFAULT '',
+    ip              varchar(50) NOT NULL DEFAULT '',
+    user_agent      varchar(255) NOT NULL DEFAULT '',
+    session_uuid    uuid,
+    details         varchar(255) NOT NULL DEFAULT '',
+    created_date    timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS security_events_user_id_created_date_idx ON public.security_events (user_id, created_date);
+CREATE INDEX IF NOT EXISTS security_events_created_date_idx ON public.security_events (created_date);
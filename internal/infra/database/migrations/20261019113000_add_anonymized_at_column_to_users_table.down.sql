@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS public.users_pending_anonymization_idx;
+
+ALTER TABLE
+    public.users DROP COLUMN IF EXISTS anonymized_at,
+    DROP COLUMN IF EXISTS deletion_requested_at;
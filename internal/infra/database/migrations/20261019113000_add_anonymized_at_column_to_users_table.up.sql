@@ -0,0 +1,10 @@
+ALTER TABLE
+    public.users
+ADD
+    COLUMN deletion_requested_at timestamptz,
+ADD
+    COLUMN anonymized_at timestamptz;
+
+-- only the deletions requested by the user are anonymized, a deactivation keeps the data
+CREATE INDEX IF NOT EXISTS users_pending_anonymization_idx ON public.users (deletion_requested_at)
+    WHERE deletion_requested_at IS NOT NULL AND anonymized_at IS NULL;
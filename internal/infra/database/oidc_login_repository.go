@@ -0,0 +1,92 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/upper/db/v4"
+)
+
+const OidcLoginsTableName = "oidc_logins"
+
+type oidcLogin struct {
+	StateHash       string    `db:"state_hash"`
+	CodeVerifier    string    `db:"code_verifier"`
+	Nonce           string    `db:"nonce"`
+	ClientNonceHash string    `db:"client_nonce_hash"`
+	ExpiresAt       time.Time `db:"expires_at"`
+}
+
+type OidcLoginRepository interface {
+	Save(l domain.OidcLogin) error
+	Take(stateHash string) (domain.OidcLogin, error)
+	DeleteExpired() error
+}
+
+type oidcLoginRepository struct {
+	coll db.Collection
+	sess db.Session
+}
+
+func NewOidcLoginRepository(sess db.Session) OidcLoginRepository {
+	return oidcLoginRepository{
+		coll: sess.Collection(OidcLoginsTableName),
+		sess: sess,
+	}
+}
+
+func (r oidcLoginRepository) Save(l domain.OidcLogin) error {
+	_, err := r.coll.Insert(r.mapDomainToModel(l))
+	return err
+}
+
+// Take deletes the login and returns it, so a state can be used only once
+// even when two callbacks race. db.ErrNoMoreRows is returned for unknown or expired states
+func (r oidcLoginRepository) Take(stateHash string) (domain.OidcLogin, error) {
+	row, err := r.sess.SQL().QueryRow(`
+		DELETE FROM `+OidcLoginsTableName+`
+		WHERE state_hash = ? AND expires_at > ?
+		RETURNING state_hash, code_verifier, nonce, client_nonce_hash, expires_at`,
+		stateHash, time.Now(),
+	)
+	if err != nil {
+		return domain.OidcLogin{}, err
+	}
+
+	var m oidcLogin
+	err = row.Scan(&m.StateHash, &m.CodeVerifier, &m.Nonce, &m.ClientNonceHash, &m.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.OidcLogin{}, db.ErrNoMoreRows
+		}
+		return domain.OidcLogin{}, err
+	}
+
+	return r.mapModelToDomain(m), nil
+}
+
+func (r oidcLoginRepository) DeleteExpired() error {
+	return r.coll.Find(db.Cond{"expires_at <=": time.Now()}).Delete()
+}
+
+func (r oidcLoginRepository) mapDomainToModel(d domain.OidcLogin) oidcLogin {
+	return oidcLogin{
+		StateHash:       d.StateHash,
+		CodeVerifier:    d.CodeVerifier,
+		Nonce:           d.Nonce,
+		ClientNonceHash: d.ClientNonceHash,
+		ExpiresAt:       d.ExpiresAt,
+	}
+}
+
+func (r oidcLoginRepository) mapModelToDomain(m oidcLogin) domain.OidcLogin {
+	return domain.OidcLogin{
+		StateHash:       m.StateHash,
+		CodeVerifier:    m.CodeVerifier,
+		Nonce:           m.Nonce,
+		ClientNonceHash: m.ClientNonceHash,
+		ExpiresAt:       m.ExpiresAt,
+	}
+}
@@ -0,0 +1,109 @@
+package database
+
+import (
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/upper/db/v4"
+)
+
+const PasswordResetsTableName = "password_resets"
+
+type passwordReset struct {
+	Id          uint64     `db:"id,omitempty"`
+	UserId      uint64     `db:"user_id"`
+	TokenHash   string     `db:"token_hash"`
+	ExpiresAt   time.Time  `db:"expires_at"`
+	UsedDate    *time.Time `db:"used_date"`
+	CreatedDate time.Time  `db:"created_date"`
+}
+
+type PasswordResetRepository interface {
+	Save(pr domain.PasswordReset) (domain.PasswordReset, error)
+	FindByTokenHash(hash string) (domain.PasswordReset, error)
+	MarkUsed(id uint64) (bool, error)
+	InvalidateByUser(userId uint64) error
+	DeleteExpired() error
+}
+
+type passwordResetRepository struct {
+	coll db.Collection
+	sess db.Session
+}
+
+func NewPasswordResetRepository(sess db.Session) PasswordResetRepository {
+	return passwordResetRepository{
+		coll: sess.Collection(PasswordResetsTableName),
+		sess: sess,
+	}
+}
+
+func (r passwordResetRepository) Save(pr domain.PasswordReset) (domain.PasswordReset, error) {
+	m := r.mapDomainToModel(pr)
+	m.CreatedDate = time.Now()
+	err := r.coll.InsertReturning(&m)
+	if err != nil {
+		return domain.PasswordReset{}, err
+	}
+
+	return r.mapModelToDomain(m), nil
+}
+
+func (r passwordResetRepository) FindByTokenHash(hash string) (domain.PasswordReset, error) {
+	var m passwordReset
+	err := r.coll.Find(db.Cond{"token_hash": hash}).One(&m)
+	if err != nil {
+		return domain.PasswordReset{}, err
+	}
+
+	return r.mapModelToDomain(m), nil
+}
+
+// MarkUsed reports false when the token was already used
+func (r passwordResetRepository) MarkUsed(id uint64) (bool, error) {
+	res, err := r.sess.SQL().
+		Update(PasswordResetsTableName).
+		Set("used_date", time.Now()).
+		Where(db.Cond{"id": id, "used_date": nil}).
+		Exec()
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
+func (r passwordResetRepository) InvalidateByUser(userId uint64) error {
+	return r.coll.Find(db.Cond{"user_id": userId, "used_date": nil}).Update(map[string]interface{}{"used_date": time.Now()})
+}
+
+func (r passwordResetRepository) DeleteExpired() error {
+	return r.coll.Find(db.Cond{"expires_at <=": time.Now()}).Delete()
+}
+
+func (r passwordResetRepository) mapDomainToModel(d domain.PasswordReset) passwordReset {
+	return passwordReset{
+		Id:          d.Id,
+		UserId:      d.UserId,
+		TokenHash:   d.TokenHash,
+		ExpiresAt:   d.ExpiresAt,
+		UsedDate:    d.UsedDate,
+		CreatedDate: d.CreatedDate,
+	}
+}
+
+func (r passwordResetRepository) mapModelToDomain(m passwordReset) domain.PasswordReset {
+	return domain.PasswordReset{
+		Id:          m.Id,
+		UserId:      m.UserId,
+		TokenHash:   m.TokenHash,
+		ExpiresAt:   m.ExpiresAt,
+		UsedDate:    m.UsedDate,
+		CreatedDate: m.CreatedDate,
+	}
+}
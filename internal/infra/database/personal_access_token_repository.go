@@ -0,0 +1,154 @@
+package database
+
+import (
+	"strings"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/upper/db/v4"
+)
+
+const (
+	PersonalAccessTokensTableName = "personal_access_tokens"
+	lastUsedPrecision             = time.Minute
+)
+
+type personalAccessToken struct {
+	Id          uint64     `db:"id,omitempty"`
+	UserId      uint64     `db:"user_id"`
+	Name        string     `db:"name"`
+	TokenHash   string     `db:"token_hash"`
+	Scopes      string     `db:"scopes"`
+	ExpiresAt   *time.Time `db:"expires_at"`
+	LastUsedAt  *time.Time `db:"last_used_at"`
+	RevokedDate *time.Time `db:"revoked_date"`
+	CreatedDate time.Time  `db:"created_date"`
+}
+
+type PersonalAccessTokenRepository interface {
+	Save(t domain.PersonalAccessToken) (domain.PersonalAccessToken, error)
+	Find(id uint64) (domain.PersonalAccessToken, error)
+	FindByTokenHash(hash string) (domain.PersonalAccessToken, error)
+	FindByUser(userId uint64) ([]domain.PersonalAccessToken, error)
+	Touch(id uint64) error
+	Revoke(id uint64) error
+	RevokeByUser(userId uint64) error
+}
+
+type personalAccessTokenRepository struct {
+	coll db.Collection
+}
+
+func NewPersonalAccessTokenRepository(sess db.Session) PersonalAccessTokenRepository {
+	return personalAccessTokenRepository{
+		coll: sess.Collection(PersonalAccessTokensTableName),
+	}
+}
+
+func (r personalAccessTokenRepository) Save(t domain.PersonalAccessToken) (domain.PersonalAccessToken, error) {
+	m := r.mapDomainToModel(t)
+	m.CreatedDate = time.Now()
+	err := r.coll.InsertReturning(&m)
+	if err != nil {
+		return domain.PersonalAccessToken{}, err
+	}
+
+	return r.mapModelToDomain(m), nil
+}
+
+func (r personalAccessTokenRepository) Find(id uint64) (domain.PersonalAccessToken, error) {
+	var m personalAccessToken
+	err := r.coll.Find(db.Cond{"id": id}).One(&m)
+	if err != nil {
+		return domain.PersonalAccessToken{}, err
+	}
+
+	return r.mapModelToDomain(m), nil
+}
+
+func (r personalAccessTokenRepository) FindByTokenHash(hash string) (domain.PersonalAccessToken, error) {
+	var m personalAccessToken
+	err := r.coll.Find(db.Cond{"token_hash": hash}).One(&m)
+	if err != nil {
+		return domain.PersonalAccessToken{}, err
+	}
+
+	return r.mapModelToDomain(m), nil
+}
+
+func (r personalAccessTokenRepository) FindByUser(userId uint64) ([]domain.PersonalAccessToken, error) {
+	var ms []personalAccessToken
+	err := r.coll.Find(db.Cond{"user_id": userId}).OrderBy("-id").All(&ms)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.mapModelToDomainCollection(ms), nil
+}
+
+// Touch updates last_used_at at most once a minute,
+// scripts may fire many requests per second
+func (r personalAccessTokenRepository) Touch(id uint64) error {
+	now := time.Now()
+	return r.coll.Find(db.And(
+		db.Cond{"id": id},
+		db.Or(
+			db.Cond{"last_used_at": nil},
+			db.Cond{"last_used_at <": now.Add(-lastUsedPrecision)},
+		),
+	)).Update(map[string]interface{}{"last_used_at": now})
+}
+
+func (r personalAccessTokenRepository) Revoke(id uint64) error {
+	return r.coll.Find(db.Cond{"id": id, "revoked_date": nil}).Update(map[string]interface{}{"revoked_date": time.Now()})
+}
+
+func (r personalAccessTokenRepository) RevokeByUser(userId uint64) error {
+	return r.coll.Find(db.Cond{"user_id": userId, "revoked_date": nil}).Update(map[string]interface{}{"revoked_date": time.Now()})
+}
+
+func (r personalAccessTokenRepository) mapDomainToModel(d domain.PersonalAccessToken) personalAccessToken {
+	scopes := make([]string, len(d.Scopes))
+	for i, s := range d.Scopes {
+		scopes[i] = string(s)
+	}
+
+	return personalAccessToken{
+		Id:          d.Id,
+		UserId:      d.UserId,
+		Name:        d.Name,
+		TokenHash:   d.TokenHash,
+		Scopes:      strings.Join(scopes, " "),
+		ExpiresAt:   d.ExpiresAt,
+		LastUsedAt:  d.LastUsedAt,
+		RevokedDate: d.RevokedDate,
+		CreatedDate: d.CreatedDate,
+	}
+}
+
+func (r personalAccessTokenRepository) mapModelToDomain(m personalAccessToken) domain.PersonalAccessToken {
+	var scopes []domain.Scope
+	for _, s := range strings.Fields(m.Scopes) {
+		scopes = append(scopes, domain.Scope(s))
+	}
+
+	return domain.PersonalAccessToken{
+		Id:          m.Id,
+		UserId:      m.UserId,
+		Name:        m.Name,
+		TokenHash:   m.TokenHash,
+		Scopes:      scopes,
+		ExpiresAt:   m.ExpiresAt,
+		LastUsedAt:  m.LastUsedAt,
+		RevokedDate: m.RevokedDate,
+		CreatedDate: m.CreatedDate,
+	}
+}
+
+func (r personalAccessTokenRepository) mapModelToDomainCollection(ms []personalAccessToken) []domain.PersonalAccessToken {
+	tokens := make([]domain.PersonalAccessToken, len(ms))
+	for i, m := range ms {
+		tokens[i] = r.mapModelToDomain(m)
+	}
+	return tokens
+}
@@ -0,0 +1,154 @@
+package database
+
+import (
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/upper/db/v4"
+)
+
+const PublicLinksTableName = "public_links"
+
+type publicLink struct {
+	Id             uint64     `db:"id,omitempty"`
+	UserId         uint64     `db:"user_id"`
+	TaskId         *uint64    `db:"task_id"`
+	WorkspaceId    *uint64    `db:"workspace_id"`
+	TokenHash      string     `db:"token_hash"`
+	ExpiresAt      *time.Time `db:"expires_at"`
+	RevokedDate    *time.Time `db:"revoked_date"`
+	AccessCount    uint64     `db:"access_count"`
+	LastAccessedAt *time.Time `db:"last_accessed_at"`
+	CreatedDate    time.Time  `db:"created_date"`
+}
+
+type PublicLinkRepository interface {
+	Save(l domain.PublicLink) (domain.PublicLink, error)
+	Find(id uint64) (domain.PublicLink, error)
+	FindByTokenHash(hash string) (domain.PublicLink, error)
+	FindByUser(userId uint64) ([]domain.PublicLink, error)
+	FindByTask(taskId uint64) ([]domain.PublicLink, error)
+	FindByWorkspace(workspaceId uint64) ([]domain.PublicLink, error)
+	RegisterAccess(id uint64) error
+	Revoke(id uint64) error
+}
+
+type publicLinkRepository struct {
+	coll db.Collection
+}
+
+func NewPublicLinkRepository(sess db.Session) PublicLinkRepository {
+	return publicLinkRepository{
+		coll: sess.Collection(PublicLinksTableName),
+	}
+}
+
+func (r publicLinkRepository) Save(l domain.PublicLink) (domain.PublicLink, error) {
+	pl := r.mapDomainToModel(l)
+	pl.CreatedDate = time.Now()
+	err := r.coll.InsertReturning(&pl)
+	if err != nil {
+		return domain.PublicLink{}, err
+	}
+
+	return r.mapModelToDomain(pl), nil
+}
+
+func (r publicLinkRepository) Find(id uint64) (domain.PublicLink, error) {
+	var pl publicLink
+	err := r.coll.Find(db.Cond{"id": id}).One(&pl)
+	if err != nil {
+		return domain.PublicLink{}, err
+	}
+
+	return r.mapModelToDomain(pl), nil
+}
+
+func (r publicLinkRepository) FindByTokenHash(hash string) (domain.PublicLink, error) {
+	var pl publicLink
+	err := r.coll.Find(db.Cond{"token_hash": hash}).One(&pl)
+	if err != nil {
+		return domain.PublicLink{}, err
+	}
+
+	return r.mapModelToDomain(pl), nil
+}
+
+func (r publicLinkRepository) FindByUser(userId uint64) ([]domain.PublicLink, error) {
+	var pls []publicLink
+	err := r.coll.Find(db.Cond{"user_id": userId}).OrderBy("-id").All(&pls)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.mapModelToDomainCollection(pls), nil
+}
+
+func (r publicLinkRepository) FindByTask(taskId uint64) ([]domain.PublicLink, error) {
+	var pls []publicLink
+	err := r.coll.Find(db.Cond{"task_id": taskId}).OrderBy("-id").All(&pls)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.mapModelToDomainCollection(pls), nil
+}
+
+func (r publicLinkRepository) FindByWorkspace(workspaceId uint64) ([]domain.PublicLink, error) {
+	var pls []publicLink
+	err := r.coll.Find(db.Cond{"workspace_id": workspaceId}).OrderBy("-id").All(&pls)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.mapModelToDomainCollection(pls), nil
+}
+
+func (r publicLinkRepository) RegisterAccess(id uint64) error {
+	return r.coll.Find(db.Cond{"id": id}).Update(map[string]interface{}{
+		"access_count":     db.Raw("access_count + 1"),
+		"last_accessed_at": time.Now(),
+	})
+}
+
+func (r publicLinkRepository) Revoke(id uint64) error {
+	return r.coll.Find(db.Cond{"id": id, "revoked_date": nil}).Update(map[string]interface{}{"revoked_date": time.Now()})
+}
+
+func (r publicLinkRepository) mapDomainToModel(d domain.PublicLink) publicLink {
+	return publicLink{
+		Id:             d.Id,
+		UserId:         d.UserId,
+		TaskId:         d.TaskId,
+		WorkspaceId:    d.WorkspaceId,
+		TokenHash:      d.TokenHash,
+		ExpiresAt:      d.ExpiresAt,
+		RevokedDate:    d.RevokedDate,
+		AccessCount:    d.AccessCount,
+		LastAccessedAt: d.LastAccessedAt,
+		CreatedDate:    d.CreatedDate,
+	}
+}
+
+func (r publicLinkRepository) mapModelToDomain(m publicLink) domain.PublicLink {
+	return domain.PublicLink{
+		Id:             m.Id,
+		UserId:         m.UserId,
+		TaskId:         m.TaskId,
+		WorkspaceId:    m.WorkspaceId,
+		TokenHash:      m.TokenHash,
+		ExpiresAt:      m.ExpiresAt,
+		RevokedDate:    m.RevokedDate,
+		AccessCount:    m.AccessCount,
+		LastAccessedAt: m.LastAccessedAt,
+		CreatedDate:    m.CreatedDate,
+	}
+}
+
+func (r publicLinkRepository) mapModelToDomainCollection(ms []publicLink) []domain.PublicLink {
+	links := make([]domain.PublicLink, len(ms))
+	for i, m := range ms {
+		links[i] = r.mapModelToDomain(m)
+	}
+	return links
+}
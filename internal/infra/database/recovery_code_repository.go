@@ -0,0 +1,82 @@
+package database
+
+import (
+	"time"
+
+	"github.com/upper/db/v4"
+)
+
+const RecoveryCodesTableName = "recovery_codes"
+
+type recoveryCode struct {
+	Id          uint64     `db:"id,omitempty"`
+	UserId      uint64     `db:"user_id"`
+	CodeHash    string     `db:"code_hash"`
+	UsedDate    *time.Time `db:"used_date"`
+	CreatedDate time.Time  `db:"created_date"`
+}
+
+type RecoveryCodeRepository interface {
+	Replace(userId uint64, hashes []string) error
+	Use(userId uint64, hash string) (bool, error)
+	DeleteByUser(userId uint64) error
+}
+
+type recoveryCodeRepository struct {
+	coll db.Collection
+	sess db.Session
+}
+
+func NewRecoveryCodeRepository(sess db.Session) RecoveryCodeRepository {
+	return recoveryCodeRepository{
+		coll: sess.Collection(RecoveryCodesTableName),
+		sess: sess,
+	}
+}
+
+// Replace drops all previous codes of the user, so only the latest set is valid
+func (r recoveryCodeRepository) Replace(userId uint64, hashes []string) error {
+	return r.sess.Tx(func(tx db.Session) error {
+		coll := tx.Collection(RecoveryCodesTableName)
+		err := coll.Find(db.Cond{"user_id": userId}).Delete()
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for _, h := range hashes {
+			_, err = coll.Insert(recoveryCode{
+				UserId:      userId,
+				CodeHash:    h,
+				CreatedDate: now,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Use reports false when there is no such unused code
+func (r recoveryCodeRepository) Use(userId uint64, hash string) (bool, error) {
+	res, err := r.sess.SQL().
+		Update(RecoveryCodesTableName).
+		Set("used_date", time.Now()).
+		Where(db.Cond{"user_id": userId, "code_hash": hash, "used_date": nil}).
+		Exec()
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
+func (r recoveryCodeRepository) DeleteByUser(userId uint64) error {
+	return r.coll.Find(db.Cond{"user_id": userId}).Delete()
+}
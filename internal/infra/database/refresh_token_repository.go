@@ -0,0 +1,104 @@
+package database
+
+import (
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/google/uuid"
+	"github.com/upper/db/v4"
+)
+
+const RefreshTokensTableName = "refresh_tokens"
+
+type refreshToken struct {
+	Id          uint64     `db:"id,omitempty"`
+	UserId      uint64     `db:"user_id"`
+	SessionUUID uuid.UUID  `db:"session_uuid"`
+	TokenHash   string     `db:"token_hash"`
+	ExpiresAt   time.Time  `db:"expires_at"`
+	UsedDate    *time.Time `db:"used_date"`
+	CreatedDate time.Time  `db:"created_date"`
+}
+
+type RefreshTokenRepository interface {
+	Save(t domain.RefreshToken) (domain.RefreshToken, error)
+	FindByTokenHash(hash string) (domain.RefreshToken, error)
+	MarkUsed(id uint64) (bool, error)
+}
+
+type refreshTokenRepository struct {
+	coll db.Collection
+	sess db.Session
+}
+
+func NewRefreshTokenRepository(sess db.Session) RefreshTokenRepository {
+	return refreshTokenRepository{
+		coll: sess.Collection(RefreshTokensTableName),
+		sess: sess,
+	}
+}
+
+func (r refreshTokenRepository) Save(t domain.RefreshToken) (domain.RefreshToken, error) {
+	rt := r.mapDomainToModel(t)
+	rt.CreatedDate = time.Now()
+	err := r.coll.InsertReturning(&rt)
+	if err != nil {
+		return domain.RefreshToken{}, err
+	}
+
+	return r.mapModelToDomain(rt), nil
+}
+
+func (r refreshTokenRepository) FindByTokenHash(hash string) (domain.RefreshToken, error) {
+	var rt refreshToken
+	err := r.coll.Find(db.Cond{"token_hash": hash}).One(&rt)
+	if err != nil {
+		return domain.RefreshToken{}, err
+	}
+
+	return r.mapModelToDomain(rt), nil
+}
+
+// MarkUsed reports false when the token was already used, so that
+// two concurrent refreshes with the same token can't both succeed
+func (r refreshTokenRepository) MarkUsed(id uint64) (bool, error) {
+	res, err := r.sess.SQL().
+		Update(RefreshTokensTableName).
+		Set("used_date", time.Now()).
+		Where(db.Cond{"id": id, "used_date": nil}).
+		Exec()
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
+func (r refreshTokenRepository) mapDomainToModel(d domain.RefreshToken) refreshToken {
+	return refreshToken{
+		Id:          d.Id,
+		UserId:      d.UserId,
+		SessionUUID: d.SessionUUID,
+		TokenHash:   d.TokenHash,
+		ExpiresAt:   d.ExpiresAt,
+		UsedDate:    d.UsedDate,
+		CreatedDate: d.CreatedDate,
+	}
+}
+
+func (r refreshTokenRepository) mapModelToDomain(m refreshToken) domain.RefreshToken {
+	return domain.RefreshToken{
+		Id:          m.Id,
+		UserId:      m.UserId,
+		SessionUUID: m.SessionUUID,
+		TokenHash:   m.TokenHash,
+		ExpiresAt:   m.ExpiresAt,
+		UsedDate:    m.UsedDate,
+		CreatedDate: m.CreatedDate,
+	}
+}
@@ -0,0 +1,138 @@
+package database
+
+import (
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/google/uuid"
+	"github.com/upper/db/v4"
+)
+
+const SecurityEventsTableName = "security_events"
+
+type securityEvent struct {
+	Id          uint64     `db:"id,omitempty"`
+	UserId      *uint64    `db:"user_id"`
+	Type        string     `db:"type"`
+	Email       string     `db:"email"`
+	Ip          string     `db:"ip"`
+	UserAgent   string     `db:"user_agent"`
+	SessionUUID *uuid.UUID `db:"session_uuid"`
+	Details     string     `db:"details"`
+	CreatedDate time.Time  `db:"created_date"`
+}
+
+type SecurityEventRepository interface {
+	Save(e domain.SecurityEvent) (domain.SecurityEvent, error)
+	FindAll(f domain.SecurityEventFilters) (domain.SecurityEvents, error)
+	DeleteOlderThan(t time.Time) error
+}
+
+type securityEventRepository struct {
+	coll db.Collection
+}
+
+func NewSecurityEventRepository(sess db.Session) SecurityEventRepository {
+	return securityEventRepository{
+		coll: sess.Collection(SecurityEventsTableName),
+	}
+}
+
+func (r securityEventRepository) Save(e domain.SecurityEvent) (domain.SecurityEvent, error) {
+	m := r.mapDomainToModel(e)
+	m.CreatedDate = time.Now()
+	err := r.coll.InsertReturning(&m)
+	if err != nil {
+		return domain.SecurityEvent{}, err
+	}
+
+	return r.mapModelToDomain(m), nil
+}
+
+// FindAll returns the newest events first
+func (r securityEventRepository) FindAll(f domain.SecurityEventFilters) (domain.SecurityEvents, error) {
+	var conds []db.LogicalExpr
+
+	if f.UserId != nil {
+		conds = append(conds, db.Cond{"user_id": *f.UserId})
+	}
+	if f.Type != nil {
+		conds = append(conds, db.Cond{"type": *f.Type})
+	}
+	if f.Email != "" {
+		conds = append(conds, db.Cond{"email ILIKE": escapeLike(f.Email)})
+	}
+	if f.Ip != "" {
+		conds = append(conds, db.Cond{"ip": f.Ip})
+	}
+	if f.From != nil {
+		conds = append(conds, db.Cond{"created_date >=": *f.From})
+	}
+	if f.To != nil {
+		conds = append(conds, db.Cond{"created_date <": *f.To})
+	}
+
+	res := r.coll.Find(db.And(conds...)).OrderBy("-created_date", "-id")
+	total, err := res.Count()
+	if err != nil {
+		return domain.SecurityEvents{}, err
+	}
+
+	res = res.Paginate(uint(f.CountPerPage))
+	var es []securityEvent
+	err = res.Page(uint(f.Page)).All(&es)
+	if err != nil {
+		return domain.SecurityEvents{}, err
+	}
+
+	pages, err := res.TotalPages()
+	if err != nil {
+		return domain.SecurityEvents{}, err
+	}
+
+	return domain.SecurityEvents{
+		Items: r.mapModelToDomainCollection(es),
+		Total: total,
+		Pages: pages,
+	}, nil
+}
+
+func (r securityEventRepository) DeleteOlderThan(t time.Time) error {
+	return r.coll.Find(db.Cond{"created_date <": t}).Delete()
+}
+
+func (r securityEventRepository) mapDomainToModel(d domain.SecurityEvent) securityEvent {
+	return securityEvent{
+		Id:          d.Id,
+		UserId:      d.UserId,
+		Type:        string(d.Type),
+		Email:       d.Email,
+		Ip:          d.Ip,
+		UserAgent:   d.UserAgent,
+		SessionUUID: d.SessionUUID,
+		Details:     d.Details,
+		CreatedDate: d.CreatedDate,
+	}
+}
+
+func (r securityEventRepository) mapModelToDomain(m securityEvent) domain.SecurityEvent {
+	return domain.SecurityEvent{
+		Id:          m.Id,
+		UserId:      m.UserId,
+		Type:        domain.SecurityEventType(m.Type),
+		Email:       m.Email,
+		Ip:          m.Ip,
+		UserAgent:   m.UserAgent,
+		SessionUUID: m.SessionUUID,
+		Details:     m.Details,
+		CreatedDate: m.CreatedDate,
+	}
+}
+
+func (r securityEventRepository) mapModelToDomainCollection(ms []securityEvent) []domain.SecurityEvent {
+	es := make([]domain.SecurityEvent, len(ms))
+	for i, m := range ms {
+		es[i] = r.mapModelToDomain(m)
+	}
+	return es
+}
@@ -2,6 +2,8 @@ package database
 
 import (
 	"fmt"
+	"time"
+
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
 	"github.com/google/uuid"
 	"github.com/upper/db/v4"
@@ -9,15 +11,31 @@ import (
 
 const SessionsTableName = "sessions"
 
+// lastSeenPrecision limits how often an active session is written to
+const lastSeenPrecision = time.Minute
+
 type sessions struct {
-	UserId uint64    `db:"user_id"`
-	UUID   uuid.UUID `db:"uuid"`
+	UserId           uint64     `db:"user_id"`
+	UUID             uuid.UUID  `db:"uuid"`
+	UserAgent        string     `db:"user_agent"`
+	Ip               string     `db:"ip"`
+	CreatedDate      time.Time  `db:"created_date"`
+	LastSeenAt       *time.Time `db:"last_seen_at"`
+	ExpiresAt        time.Time  `db:"expires_at"`
+	ActorId          *uint64    `db:"actor_id"`
+	AllowDestructive bool       `db:"allow_destructive"`
 }
 
 type SessionRepository interface {
 	Save(sess domain.Session) error
 	Exists(sess domain.Session) error
+	FindByUser(userId uint64) ([]domain.Session, error)
+	Touch(sess domain.Session) error
+	Prolong(sess domain.Session, expiresAt time.Time) error
 	Delete(sess domain.Session) error
+	DeleteOthers(sess domain.Session) error
+	DeleteByUser(userId uint64) error
+	DeleteExpired() error
 }
 
 type sessionRepository struct {
@@ -32,6 +50,7 @@ func NewSessRepository(dbSession db.Session) SessionRepository {
 
 func (r sessionRepository) Save(sess domain.Session) error {
 	a := r.mapDomainToModel(sess)
+	a.CreatedDate = time.Now()
 	err := r.coll.InsertReturning(&a)
 	if err != nil {
 		return err
@@ -41,20 +60,90 @@ func (r sessionRepository) Save(sess domain.Session) error {
 }
 
 func (r sessionRepository) Exists(sess domain.Session) error {
-	exists, err := r.coll.Find(db.Cond{"user_id": sess.UserId, "uuid": sess.UUID}).Exists()
+	exists, err := r.coll.Find(db.Cond{
+		"user_id":      sess.UserId,
+		"uuid":         sess.UUID,
+		"expires_at >": time.Now(),
+	}).Exists()
 	if !exists {
 		err = fmt.Errorf("sess not found")
 	}
 	return err
 }
 
+func (r sessionRepository) FindByUser(userId uint64) ([]domain.Session, error) {
+	var ss []sessions
+	err := r.coll.Find(db.Cond{"user_id": userId, "expires_at >": time.Now()}).OrderBy("-created_date").All(&ss)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.mapModelToDomainCollection(ss), nil
+}
+
+func (r sessionRepository) Touch(sess domain.Session) error {
+	now := time.Now()
+	return r.coll.Find(db.And(
+		db.Cond{"user_id": sess.UserId, "uuid": sess.UUID},
+		db.Or(
+			db.Cond{"last_seen_at": nil},
+			db.Cond{"last_seen_at <": now.Add(-lastSeenPrecision)},
+		),
+	)).Update(map[string]interface{}{"last_seen_at": now})
+}
+
+func (r sessionRepository) Prolong(sess domain.Session, expiresAt time.Time) error {
+	return r.coll.Find(db.Cond{"user_id": sess.UserId, "uuid": sess.UUID}).Update(map[string]interface{}{"expires_at": expiresAt})
+}
+
 func (r sessionRepository) Delete(sess domain.Session) error {
 	return r.coll.Find(db.Cond{"user_id": sess.UserId, "uuid": sess.UUID}).Delete()
 }
 
+func (r sessionRepository) DeleteOthers(sess domain.Session) error {
+	return r.coll.Find(db.Cond{"user_id": sess.UserId, "uuid !=": sess.UUID}).Delete()
+}
+
+func (r sessionRepository) DeleteByUser(userId uint64) error {
+	return r.coll.Find(db.Cond{"user_id": userId}).Delete()
+}
+
+func (r sessionRepository) DeleteExpired() error {
+	return r.coll.Find(db.Cond{"expires_at <=": time.Now()}).Delete()
+}
+
 func (r sessionRepository) mapDomainToModel(d domain.Session) sessions {
 	return sessions{
-		UserId: d.UserId,
-		UUID:   d.UUID,
+		UserId:           d.UserId,
+		UUID:             d.UUID,
+		UserAgent:        d.UserAgent,
+		Ip:               d.Ip,
+		CreatedDate:      d.CreatedDate,
+		LastSeenAt:       d.LastSeenAt,
+		ExpiresAt:        d.ExpiresAt,
+		ActorId:          d.ActorId,
+		AllowDestructive: d.AllowDestructive,
+	}
+}
+
+func (r sessionRepository) mapModelToDomain(m sessions) domain.Session {
+	return domain.Session{
+		UserId:           m.UserId,
+		UUID:             m.UUID,
+		UserAgent:        m.UserAgent,
+		Ip:               m.Ip,
+		CreatedDate:      m.CreatedDate,
+		LastSeenAt:       m.LastSeenAt,
+		ExpiresAt:        m.ExpiresAt,
+		ActorId:          m.ActorId,
+		AllowDestructive: m.AllowDestructive,
+	}
+}
+
+func (r sessionRepository) mapModelToDomainCollection(ms []sessions) []domain.Session {
+	ss := make([]domain.Session, len(ms))
+	for i, m := range ms {
+		ss[i] = r.mapModelToDomain(m)
 	}
+	return ss
 }
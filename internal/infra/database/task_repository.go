@@ -12,6 +12,8 @@ const TasksTableName = "tasks"
 type task struct {
 	Id          uint64            `db:"id,omitempty"`
 	UserId      uint64            `db:"user_id"`
+	WorkspaceId *uint64           `db:"workspace_id"`
+	AssigneeId  *uint64           `db:"assignee_id"`
 	Title       string            `db:"title"`
 	Description *string           `db:"description"`
 	Date        *time.Time        `db:"date"`
@@ -24,9 +26,10 @@ type task struct {
 type TaskRepository interface {
 	Save(t domain.Task) (domain.Task, error)
 	Find(id uint64) (domain.Task, error)
-	FindAllTasks(uId uint64, status *domain.TaskStatus, date *time.Time) ([]domain.Task, error)
+	FindAllTasks(f domain.TaskFilters) ([]domain.Task, error)
 	Update(t domain.Task) (domain.Task, error)
 	Delete(id uint64) error
+	DeleteByWorkspace(workspaceId uint64) error
 
 	UpdateStatus(id uint64, status domain.TaskStatus) (domain.Task, error)
 }
@@ -69,30 +72,45 @@ func (r taskRepository) Find(id uint64) (domain.Task, error) {
 	return r.mapModelToDomain(t), nil
 }
 
-func (r taskRepository) FindAllTasks(uId uint64, status *domain.TaskStatus, date *time.Time) ([]domain.Task, error) {
+func (r taskRepository) FindAllTasks(f domain.TaskFilters) ([]domain.Task, error) {
 	var ts []task
 
 	// Базові умови
 	cond := db.Cond{
-		"user_id":      uId,
 		"deleted_date": nil,
 	}
 
+	// Власні задачі, задачі якими поділилися з користувачем або призначені йому
+	var access []db.LogicalExpr
+	if f.Shared {
+		access = append(access, db.Raw("id IN (SELECT task_id FROM "+TaskSharesTableName+" WHERE user_id = ?)", f.UserId))
+	}
+	if f.Assigned {
+		access = append(access, db.Cond{"assignee_id": f.UserId})
+	}
+
+	// Задачі робочого простору або особисті задачі
+	if f.WorkspaceId != nil {
+		cond["workspace_id"] = *f.WorkspaceId
+	} else if len(access) == 0 {
+		access = append(access, db.Cond{"user_id": f.UserId, "workspace_id": nil})
+	}
+
 	// Додатковий фільтр по статусу
-	if status != nil {
-		cond["status"] = *status
+	if f.Status != nil {
+		cond["status"] = *f.Status
 	}
 
 	// Додатковий фільтр по даті
-	if date != nil {
-		start := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	if f.Date != nil {
+		start := time.Date(f.Date.Year(), f.Date.Month(), f.Date.Day(), 0, 0, 0, 0, time.UTC)
 		end := start.Add(24 * time.Hour)
 		cond["date >="] = start
 		cond["date <"] = end
 	}
 
 	// Запит до бази з умовами
-	err := r.coll.Find(cond).All(&ts)
+	err := r.coll.Find(db.And(append(access, cond)...)).All(&ts)
 	if err != nil {
 		return nil, err
 	}
@@ -114,6 +132,10 @@ func (r taskRepository) Delete(id uint64) error {
 	return r.coll.Find(db.Cond{"id": id, "deleted_date": nil}).Update(map[string]interface{}{"deleted_date": time.Now()})
 }
 
+func (r taskRepository) DeleteByWorkspace(workspaceId uint64) error {
+	return r.coll.Find(db.Cond{"workspace_id": workspaceId, "deleted_date": nil}).Update(map[string]interface{}{"deleted_date": time.Now()})
+}
+
 func (r taskRepository) UpdateStatus(id uint64, status domain.TaskStatus) (domain.Task, error) {
 	// Оновлюємо тільки статус і дату оновлення
 	err := r.coll.Find(db.Cond{"id": id, "deleted_date": nil}).Update(map[string]interface{}{
@@ -132,6 +154,8 @@ func (r taskRepository) mapDomainToModel(t domain.Task) task {
 	return task{
 		Id:          t.Id,
 		UserId:      t.UserId,
+		WorkspaceId: t.WorkspaceId,
+		AssigneeId:  t.AssigneeId,
 		Title:       t.Title,
 		Description: t.Description,
 		Date:        t.Date,
@@ -146,6 +170,8 @@ func (r taskRepository) mapModelToDomain(t task) domain.Task {
 	return domain.Task{
 		Id:          t.Id,
 		UserId:      t.UserId,
+		WorkspaceId: t.WorkspaceId,
+		AssigneeId:  t.AssigneeId,
 		Title:       t.Title,
 		Description: t.Description,
 		Date:        t.Date,
@@ -0,0 +1,113 @@
+package database
+
+import (
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/upper/db/v4"
+)
+
+const TaskSharesTableName = "task_shares"
+
+type taskShare struct {
+	Id          uint64            `db:"id,omitempty"`
+	TaskId      uint64            `db:"task_id"`
+	UserId      uint64            `db:"user_id"`
+	Permission  domain.Permission `db:"permission"`
+	CreatedDate time.Time         `db:"created_date"`
+	UpdatedDate time.Time         `db:"updated_date"`
+}
+
+type TaskShareRepository interface {
+	Save(s domain.TaskShare) (domain.TaskShare, error)
+	Find(taskId, userId uint64) (domain.TaskShare, error)
+	FindByTask(taskId uint64) ([]domain.TaskShare, error)
+	Update(s domain.TaskShare) (domain.TaskShare, error)
+	Delete(taskId, userId uint64) error
+}
+
+type taskShareRepository struct {
+	coll db.Collection
+}
+
+func NewTaskShareRepository(sess db.Session) TaskShareRepository {
+	return taskShareRepository{
+		coll: sess.Collection(TaskSharesTableName),
+	}
+}
+
+func (r taskShareRepository) Save(s domain.TaskShare) (domain.TaskShare, error) {
+	ts := r.mapDomainToModel(s)
+	ts.CreatedDate, ts.UpdatedDate = time.Now(), time.Now()
+	err := r.coll.InsertReturning(&ts)
+	if err != nil {
+		return domain.TaskShare{}, err
+	}
+
+	return r.mapModelToDomain(ts), nil
+}
+
+func (r taskShareRepository) Find(taskId, userId uint64) (domain.TaskShare, error) {
+	var ts taskShare
+	err := r.coll.Find(db.Cond{"task_id": taskId, "user_id": userId}).One(&ts)
+	if err != nil {
+		return domain.TaskShare{}, err
+	}
+
+	return r.mapModelToDomain(ts), nil
+}
+
+func (r taskShareRepository) FindByTask(taskId uint64) ([]domain.TaskShare, error) {
+	var tss []taskShare
+	err := r.coll.Find(db.Cond{"task_id": taskId}).OrderBy("id").All(&tss)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.mapModelToDomainCollection(tss), nil
+}
+
+func (r taskShareRepository) Update(s domain.TaskShare) (domain.TaskShare, error) {
+	ts := r.mapDomainToModel(s)
+	ts.UpdatedDate = time.Now()
+	err := r.coll.Find(db.Cond{"id": ts.Id}).Update(&ts)
+	if err != nil {
+		return domain.TaskShare{}, err
+	}
+
+	return r.mapModelToDomain(ts), nil
+}
+
+func (r taskShareRepository) Delete(taskId, userId uint64) error {
+	return r.coll.Find(db.Cond{"task_id": taskId, "user_id": userId}).Delete()
+}
+
+func (r taskShareRepository) mapDomainToModel(d domain.TaskShare) taskShare {
+	return taskShare{
+		Id:          d.Id,
+		TaskId:      d.TaskId,
+		UserId:      d.UserId,
+		Permission:  d.Permission,
+		CreatedDate: d.CreatedDate,
+		UpdatedDate: d.UpdatedDate,
+	}
+}
+
+func (r taskShareRepository) mapModelToDomain(m taskShare) domain.TaskShare {
+	return domain.TaskShare{
+		Id:          m.Id,
+		TaskId:      m.TaskId,
+		UserId:      m.UserId,
+		Permission:  m.Permission,
+		CreatedDate: m.CreatedDate,
+		UpdatedDate: m.UpdatedDate,
+	}
+}
+
+func (r taskShareRepository) mapModelToDomainCollection(ms []taskShare) []domain.TaskShare {
+	shares := make([]domain.TaskShare, len(ms))
+	for i, m := range ms {
+		shares[i] = r.mapModelToDomain(m)
+	}
+	return shares
+}
@@ -0,0 +1,77 @@
+package database
+
+import (
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/upper/db/v4"
+)
+
+const UserIdentitiesTableName = "user_identities"
+
+type userIdentity struct {
+	Id          uint64    `db:"id,omitempty"`
+	UserId      uint64    `db:"user_id"`
+	Issuer      string    `db:"issuer"`
+	Subject     string    `db:"subject"`
+	Email       string    `db:"email"`
+	CreatedDate time.Time `db:"created_date"`
+}
+
+type UserIdentityRepository interface {
+	Save(ui domain.UserIdentity) (domain.UserIdentity, error)
+	FindBySubject(issuer, subject string) (domain.UserIdentity, error)
+}
+
+type userIdentityRepository struct {
+	coll db.Collection
+}
+
+func NewUserIdentityRepository(sess db.Session) UserIdentityRepository {
+	return userIdentityRepository{
+		coll: sess.Collection(UserIdentitiesTableName),
+	}
+}
+
+func (r userIdentityRepository) Save(ui domain.UserIdentity) (domain.UserIdentity, error) {
+	m := r.mapDomainToModel(ui)
+	m.CreatedDate = time.Now()
+	err := r.coll.InsertReturning(&m)
+	if err != nil {
+		return domain.UserIdentity{}, err
+	}
+
+	return r.mapModelToDomain(m), nil
+}
+
+func (r userIdentityRepository) FindBySubject(issuer, subject string) (domain.UserIdentity, error) {
+	var m userIdentity
+	err := r.coll.Find(db.Cond{"issuer": issuer, "subject": subject}).One(&m)
+	if err != nil {
+		return domain.UserIdentity{}, err
+	}
+
+	return r.mapModelToDomain(m), nil
+}
+
+func (r userIdentityRepository) mapDomainToModel(d domain.UserIdentity) userIdentity {
+	return userIdentity{
+		Id:          d.Id,
+		UserId:      d.UserId,
+		Issuer:      d.Issuer,
+		Subject:     d.Subject,
+		Email:       d.Email,
+		CreatedDate: d.CreatedDate,
+	}
+}
+
+func (r userIdentityRepository) mapModelToDomain(m userIdentity) domain.UserIdentity {
+	return domain.UserIdentity{
+		Id:          m.Id,
+		UserId:      m.UserId,
+		Issuer:      m.Issuer,
+		Subject:     m.Subject,
+		Email:       m.Email,
+		CreatedDate: m.CreatedDate,
+	}
+}
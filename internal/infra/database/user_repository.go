@@ -1,6 +1,7 @@
 package database
 
 import (
+	"strings"
 	"time"
 
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
@@ -10,24 +11,41 @@ import (
 const UsersTableName = "users"
 
 type user struct {
-	Id          uint64      `db:"id,omitempty"`
-	FirstName   string      `db:"first_name"`
-	SecondName  string      `db:"second_name"`
-	Password    string      `db:"password"`
-	Email       string      `db:"email"`
-	Role        domain.Role `db:"role"`
-	CreatedDate time.Time   `db:"created_date,omitempty"`
-	UpdatedDate time.Time   `db:"updated_date,omitempty"`
-	DeletedDate *time.Time  `db:"deleted_date,omitempty"`
+	Id                  uint64      `db:"id,omitempty"`
+	FirstName           string      `db:"first_name"`
+	SecondName          string      `db:"second_name"`
+	Password            string      `db:"password"`
+	Email               string      `db:"email"`
+	Role                domain.Role `db:"role"`
+	Avatar              *string     `db:"avatar"`
+	EmailVerifiedAt     *time.Time  `db:"email_verified_at"`
+	TotpSecret          *string     `db:"totp_secret"`
+	TotpEnabledAt       *time.Time  `db:"totp_enabled_at"`
+	SuspendedAt         *time.Time  `db:"suspended_at"`
+	CreatedDate         time.Time   `db:"created_date,omitempty"`
+	UpdatedDate         time.Time   `db:"updated_date,omitempty"`
+	DeletedDate         *time.Time  `db:"deleted_date,omitempty"`
+	DeletionRequestedAt *time.Time  `db:"deletion_requested_at,omitempty"`
+	AnonymizedAt        *time.Time  `db:"anonymized_at,omitempty"`
 }
 
 type UserRepository interface {
 	FindByEmail(phone string) (domain.User, error)
 	FindById(id uint64) (domain.User, error)
+	FindByIdWithDeleted(id uint64) (domain.User, error)
+	FindDeletionRequestedBefore(t time.Time) ([]domain.User, error)
+	FindByIds(ids []uint64) ([]domain.User, error)
+	FindAll(f domain.UserFilters) (domain.Users, error)
+	Count(f domain.UserFilters) (uint64, error)
 	Find(id uint64) (interface{}, error)
 	Save(user domain.User) (domain.User, error)
 	Update(user domain.User) (domain.User, error)
+	UpdatePassword(id uint64, old, hash string) error
+	MarkEmailVerified(id uint64, email string) (bool, error)
+	UseTotpStep(id uint64, step int64) (bool, error)
 	Delete(id uint64) error
+	RequestDeletion(id uint64) error
+	Restore(id uint64) error
 }
 
 type userRepository struct {
@@ -42,9 +60,10 @@ func NewUserRepository(dbSession db.Session) UserRepository {
 	}
 }
 
+// FindByEmail ignores the case, the same way the unique index of the emails does
 func (r userRepository) FindByEmail(email string) (domain.User, error) {
 	var u user
-	err := r.coll.Find(db.Cond{"email": email, "deleted_date": nil}).One(&u)
+	err := r.coll.Find(db.Raw("lower(email) = lower(?)", email), db.Cond{"deleted_date": nil}).One(&u)
 	if err != nil {
 		return domain.User{}, err
 	}
@@ -53,6 +72,17 @@ func (r userRepository) FindByEmail(email string) (domain.User, error) {
 }
 
 func (r userRepository) FindById(id uint64) (domain.User, error) {
+	var usr user
+	err := r.coll.Find(db.Cond{"id": id, "deleted_date": nil}).One(&usr)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	return r.mapModelToDomain(usr), nil
+}
+
+// FindByIdWithDeleted is for the few callers which manage deleted accounts themselves
+func (r userRepository) FindByIdWithDeleted(id uint64) (domain.User, error) {
 	var usr user
 	err := r.coll.Find(db.Cond{"id": id}).One(&usr)
 	if err != nil {
@@ -62,9 +92,106 @@ func (r userRepository) FindById(id uint64) (domain.User, error) {
 	return r.mapModelToDomain(usr), nil
 }
 
+// FindDeletionRequestedBefore returns the users deleted on request which are not anonymized yet,
+// deactivated users are left alone
+func (r userRepository) FindDeletionRequestedBefore(t time.Time) ([]domain.User, error) {
+	var usrs []user
+	err := r.coll.Find(db.Cond{
+		"deletion_requested_at <": t,
+		"deleted_date IS NOT":     nil,
+		"anonymized_at":           nil,
+	}).OrderBy("deletion_requested_at").All(&usrs)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.mapModelToDomainCollection(usrs), nil
+}
+
+func (r userRepository) FindByIds(ids []uint64) ([]domain.User, error) {
+	var usrs []user
+	err := r.coll.Find(db.Cond{"id IN": ids}).All(&usrs)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.mapModelToDomainCollection(usrs), nil
+}
+
+func (r userRepository) FindAll(f domain.UserFilters) (domain.Users, error) {
+	res := r.coll.Find(r.filterCond(f)).OrderBy("id")
+	total, err := res.Count()
+	if err != nil {
+		return domain.Users{}, err
+	}
+	if f.CountPerPage == 0 {
+		return domain.Users{Total: total}, nil
+	}
+
+	var usrs []user
+	var pages uint
+	if f.StartIndex > 0 {
+		err = res.Offset(int(f.StartIndex - 1)).Limit(int(f.CountPerPage)).All(&usrs)
+		if err != nil {
+			return domain.Users{}, err
+		}
+		pages = uint((total + f.CountPerPage - 1) / f.CountPerPage)
+	} else {
+		res = res.Paginate(uint(f.CountPerPage))
+		err = res.Page(uint(f.Page)).All(&usrs)
+		if err != nil {
+			return domain.Users{}, err
+		}
+
+		pages, err = res.TotalPages()
+		if err != nil {
+			return domain.Users{}, err
+		}
+	}
+
+	return domain.Users{
+		Items: r.mapModelToDomainCollection(usrs),
+		Total: total,
+		Pages: pages,
+	}, nil
+}
+
+func (r userRepository) Count(f domain.UserFilters) (uint64, error) {
+	return r.coll.Find(r.filterCond(f)).Count()
+}
+
+func (r userRepository) filterCond(f domain.UserFilters) db.LogicalExpr {
+	// anonymized accounts are only tombstones kept for foreign keys
+	conds := []db.LogicalExpr{db.Cond{"anonymized_at": nil}}
+
+	if f.Search != "" {
+		pattern := "%" + escapeLike(f.Search) + "%"
+		conds = append(conds, db.Or(
+			db.Cond{"email ILIKE": pattern},
+			db.Cond{"first_name ILIKE": pattern},
+			db.Cond{"second_name ILIKE": pattern},
+		))
+	}
+	if f.Email != "" {
+		conds = append(conds, db.Cond{"email ILIKE": escapeLike(f.Email)})
+	}
+	if f.Role != nil {
+		conds = append(conds, db.Cond{"role": *f.Role})
+	}
+	if f.Suspended != nil {
+		if *f.Suspended {
+			conds = append(conds, db.Cond{"suspended_at IS NOT": nil})
+		} else {
+			conds = append(conds, db.Cond{"suspended_at": nil})
+		}
+	}
+
+	return db.And(conds...)
+}
+
 func (r userRepository) Find(id uint64) (interface{}, error) {
 	var usr user
-	err := r.coll.Find(db.Cond{"id": id}).One(&usr)
+	err := r.coll.Find(db.Cond{"id": id, "deleted_date": nil}).One(&usr)
 	if err != nil {
 		return domain.User{}, err
 	}
@@ -92,34 +219,124 @@ func (r userRepository) Update(user domain.User) (domain.User, error) {
 	return r.mapModelToDomain(u), nil
 }
 
+// UpdatePassword changes the hash only if it is still old,
+// so a concurrent password change is never overwritten
+func (r userRepository) UpdatePassword(id uint64, old, hash string) error {
+	_, err := r.sess.SQL().
+		Update(UsersTableName).
+		Set("password", hash, "updated_date", time.Now()).
+		Where(db.Cond{"id": id, "password": old, "deleted_date": nil}).
+		Exec()
+	return err
+}
+
+// MarkEmailVerified confirms the email only if it is still the current one,
+// returns false when the user has changed it in the meantime
+func (r userRepository) MarkEmailVerified(id uint64, email string) (bool, error) {
+	res, err := r.sess.SQL().
+		Update(UsersTableName).
+		Set("email_verified_at", time.Now()).
+		Where(db.Cond{"id": id, "email": email, "deleted_date": nil}).
+		Exec()
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// UseTotpStep remembers the time step of an accepted code,
+// returns false when this or a later step was already used
+func (r userRepository) UseTotpStep(id uint64, step int64) (bool, error) {
+	res, err := r.sess.SQL().
+		Update(UsersTableName).
+		Set("totp_last_step", step).
+		Where(
+			db.Cond{"id": id},
+			db.Or(db.Cond{"totp_last_step": nil}, db.Cond{"totp_last_step <": step}),
+		).
+		Exec()
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
 func (r userRepository) Delete(id uint64) error {
 	return r.coll.Find(db.Cond{"id": id, "deleted_date": nil}).Update(map[string]interface{}{"deleted_date": time.Now()})
 }
 
+// RequestDeletion soft deletes the user and schedules the anonymization,
+// Delete alone keeps the data, e.g. for a deactivated account
+func (r userRepository) RequestDeletion(id uint64) error {
+	now := time.Now()
+	return r.coll.Find(db.Cond{"id": id, "deleted_date": nil}).Update(map[string]interface{}{"deleted_date": now, "deletion_requested_at": now})
+}
+
+// Restore brings back a soft deleted user unless its data is already anonymized
+func (r userRepository) Restore(id uint64) error {
+	return r.coll.Find(db.Cond{"id": id, "deleted_date IS NOT": nil, "anonymized_at": nil}).Update(map[string]interface{}{"deleted_date": nil, "deletion_requested_at": nil})
+}
+
 func (r userRepository) mapDomainToModel(d domain.User) user {
 	return user{
-		Id:          d.Id,
-		Email:       d.Email,
-		Password:    d.Password,
-		FirstName:   d.FirstName,
-		SecondName:  d.SecondName,
-		Role:        d.Role,
-		CreatedDate: d.CreatedDate,
-		UpdatedDate: d.UpdatedDate,
-		DeletedDate: d.DeletedDate,
+		Id:                  d.Id,
+		Email:               d.Email,
+		Password:            d.Password,
+		FirstName:           d.FirstName,
+		SecondName:          d.SecondName,
+		Role:                d.Role,
+		Avatar:              d.Avatar,
+		EmailVerifiedAt:     d.EmailVerifiedAt,
+		TotpSecret:          d.TotpSecret,
+		TotpEnabledAt:       d.TotpEnabledAt,
+		SuspendedAt:         d.SuspendedAt,
+		CreatedDate:         d.CreatedDate,
+		UpdatedDate:         d.UpdatedDate,
+		DeletedDate:         d.DeletedDate,
+		DeletionRequestedAt: d.DeletionRequestedAt,
+		AnonymizedAt:        d.AnonymizedAt,
 	}
 }
 
 func (r userRepository) mapModelToDomain(m user) domain.User {
 	return domain.User{
-		Id:          m.Id,
-		Email:       m.Email,
-		Password:    m.Password,
-		FirstName:   m.FirstName,
-		SecondName:  m.SecondName,
-		Role:        m.Role,
-		CreatedDate: m.CreatedDate,
-		UpdatedDate: m.UpdatedDate,
-		DeletedDate: m.DeletedDate,
+		Id:                  m.Id,
+		Email:               m.Email,
+		Password:            m.Password,
+		FirstName:           m.FirstName,
+		SecondName:          m.SecondName,
+		Role:                m.Role,
+		Avatar:              m.Avatar,
+		EmailVerifiedAt:     m.EmailVerifiedAt,
+		TotpSecret:          m.TotpSecret,
+		TotpEnabledAt:       m.TotpEnabledAt,
+		SuspendedAt:         m.SuspendedAt,
+		CreatedDate:         m.CreatedDate,
+		UpdatedDate:         m.UpdatedDate,
+		DeletedDate:         m.DeletedDate,
+		DeletionRequestedAt: m.DeletionRequestedAt,
+		AnonymizedAt:        m.AnonymizedAt,
 	}
 }
+
+func (r userRepository) mapModelToDomainCollection(ms []user) []domain.User {
+	users := make([]domain.User, len(ms))
+	for i, m := range ms {
+		users[i] = r.mapModelToDomain(m)
+	}
+	return users
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
@@ -0,0 +1,108 @@
+package database
+
+import (
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/upper/db/v4"
+)
+
+const WorkspaceInvitationsTableName = "workspace_invitations"
+
+type workspaceInvitation struct {
+	Id           uint64               `db:"id,omitempty"`
+	WorkspaceId  uint64               `db:"workspace_id"`
+	InvitedBy    uint64               `db:"invited_by"`
+	Email        string               `db:"email"`
+	Role         domain.WorkspaceRole `db:"role"`
+	TokenHash    string               `db:"token_hash"`
+	ExpiresAt    time.Time            `db:"expires_at"`
+	AcceptedDate *time.Time           `db:"accepted_date"`
+	CreatedDate  time.Time            `db:"created_date"`
+}
+
+type WorkspaceInvitationRepository interface {
+	Save(i domain.WorkspaceInvitation) (domain.WorkspaceInvitation, error)
+	FindByTokenHash(hash string) (domain.WorkspaceInvitation, error)
+	Accept(id uint64) (bool, error)
+}
+
+type workspaceInvitationRepository struct {
+	coll db.Collection
+	sess db.Session
+}
+
+func NewWorkspaceInvitationRepository(sess db.Session) WorkspaceInvitationRepository {
+	return workspaceInvitationRepository{
+		coll: sess.Collection(WorkspaceInvitationsTableName),
+		sess: sess,
+	}
+}
+
+func (r workspaceInvitationRepository) Save(i domain.WorkspaceInvitation) (domain.WorkspaceInvitation, error) {
+	wi := r.mapDomainToModel(i)
+	wi.CreatedDate = time.Now()
+	err := r.coll.InsertReturning(&wi)
+	if err != nil {
+		return domain.WorkspaceInvitation{}, err
+	}
+
+	return r.mapModelToDomain(wi), nil
+}
+
+func (r workspaceInvitationRepository) FindByTokenHash(hash string) (domain.WorkspaceInvitation, error) {
+	var wi workspaceInvitation
+	err := r.coll.Find(db.Cond{"token_hash": hash}).One(&wi)
+	if err != nil {
+		return domain.WorkspaceInvitation{}, err
+	}
+
+	return r.mapModelToDomain(wi), nil
+}
+
+// Accept reports false when the invitation was already accepted
+func (r workspaceInvitationRepository) Accept(id uint64) (bool, error) {
+	res, err := r.sess.SQL().
+		Update(WorkspaceInvitationsTableName).
+		Set("accepted_date", time.Now()).
+		Where(db.Cond{"id": id, "accepted_date": nil}).
+		Exec()
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
+func (r workspaceInvitationRepository) mapDomainToModel(d domain.WorkspaceInvitation) workspaceInvitation {
+	return workspaceInvitation{
+		Id:           d.Id,
+		WorkspaceId:  d.WorkspaceId,
+		InvitedBy:    d.InvitedBy,
+		Email:        d.Email,
+		Role:         d.Role,
+		TokenHash:    d.TokenHash,
+		ExpiresAt:    d.ExpiresAt,
+		AcceptedDate: d.AcceptedDate,
+		CreatedDate:  d.CreatedDate,
+	}
+}
+
+func (r workspaceInvitationRepository) mapModelToDomain(m workspaceInvitation) domain.WorkspaceInvitation {
+	return domain.WorkspaceInvitation{
+		Id:           m.Id,
+		WorkspaceId:  m.WorkspaceId,
+		InvitedBy:    m.InvitedBy,
+		Email:        m.Email,
+		Role:         m.Role,
+		TokenHash:    m.TokenHash,
+		ExpiresAt:    m.ExpiresAt,
+		AcceptedDate: m.AcceptedDate,
+		CreatedDate:  m.CreatedDate,
+	}
+}
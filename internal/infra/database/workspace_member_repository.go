@@ -0,0 +1,115 @@
+package database
+
+import (
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/upper/db/v4"
+)
+
+const WorkspaceMembersTableName = "workspace_members"
+
+type workspaceMember struct {
+	WorkspaceId uint64               `db:"workspace_id"`
+	UserId      uint64               `db:"user_id"`
+	Role        domain.WorkspaceRole `db:"role"`
+	CreatedDate time.Time            `db:"created_date"`
+	UpdatedDate time.Time            `db:"updated_date"`
+}
+
+type WorkspaceMemberRepository interface {
+	Save(m domain.WorkspaceMember) (domain.WorkspaceMember, error)
+	Find(workspaceId, userId uint64) (domain.WorkspaceMember, error)
+	FindByWorkspace(workspaceId uint64) ([]domain.WorkspaceMember, error)
+	Update(m domain.WorkspaceMember) (domain.WorkspaceMember, error)
+	Delete(workspaceId, userId uint64) error
+	DeleteByWorkspace(workspaceId uint64) error
+}
+
+type workspaceMemberRepository struct {
+	coll db.Collection
+}
+
+func NewWorkspaceMemberRepository(sess db.Session) WorkspaceMemberRepository {
+	return workspaceMemberRepository{
+		coll: sess.Collection(WorkspaceMembersTableName),
+	}
+}
+
+func (r workspaceMemberRepository) Save(m domain.WorkspaceMember) (domain.WorkspaceMember, error) {
+	wm := r.mapDomainToModel(m)
+	wm.CreatedDate, wm.UpdatedDate = time.Now(), time.Now()
+	_, err := r.coll.Insert(&wm)
+	if err != nil {
+		return domain.WorkspaceMember{}, err
+	}
+
+	return r.mapModelToDomain(wm), nil
+}
+
+func (r workspaceMemberRepository) Find(workspaceId, userId uint64) (domain.WorkspaceMember, error) {
+	var wm workspaceMember
+	err := r.coll.Find(db.Cond{"workspace_id": workspaceId, "user_id": userId}).One(&wm)
+	if err != nil {
+		return domain.WorkspaceMember{}, err
+	}
+
+	return r.mapModelToDomain(wm), nil
+}
+
+func (r workspaceMemberRepository) FindByWorkspace(workspaceId uint64) ([]domain.WorkspaceMember, error) {
+	var wms []workspaceMember
+	err := r.coll.Find(db.Cond{"workspace_id": workspaceId}).OrderBy("created_date").All(&wms)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.mapModelToDomainCollection(wms), nil
+}
+
+func (r workspaceMemberRepository) Update(m domain.WorkspaceMember) (domain.WorkspaceMember, error) {
+	wm := r.mapDomainToModel(m)
+	wm.UpdatedDate = time.Now()
+	err := r.coll.Find(db.Cond{"workspace_id": wm.WorkspaceId, "user_id": wm.UserId}).Update(&wm)
+	if err != nil {
+		return domain.WorkspaceMember{}, err
+	}
+
+	return r.mapModelToDomain(wm), nil
+}
+
+func (r workspaceMemberRepository) Delete(workspaceId, userId uint64) error {
+	return r.coll.Find(db.Cond{"workspace_id": workspaceId, "user_id": userId}).Delete()
+}
+
+func (r workspaceMemberRepository) DeleteByWorkspace(workspaceId uint64) error {
+	return r.coll.Find(db.Cond{"workspace_id": workspaceId}).Delete()
+}
+
+func (r workspaceMemberRepository) mapDomainToModel(d domain.WorkspaceMember) workspaceMember {
+	return workspaceMember{
+		WorkspaceId: d.WorkspaceId,
+		UserId:      d.UserId,
+		Role:        d.Role,
+		CreatedDate: d.CreatedDate,
+		UpdatedDate: d.UpdatedDate,
+	}
+}
+
+func (r workspaceMemberRepository) mapModelToDomain(m workspaceMember) domain.WorkspaceMember {
+	return domain.WorkspaceMember{
+		WorkspaceId: m.WorkspaceId,
+		UserId:      m.UserId,
+		Role:        m.Role,
+		CreatedDate: m.CreatedDate,
+		UpdatedDate: m.UpdatedDate,
+	}
+}
+
+func (r workspaceMemberRepository) mapModelToDomainCollection(ms []workspaceMember) []domain.WorkspaceMember {
+	members := make([]domain.WorkspaceMember, len(ms))
+	for i, m := range ms {
+		members[i] = r.mapModelToDomain(m)
+	}
+	return members
+}
@@ -0,0 +1,116 @@
+package database
+
+import (
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/upper/db/v4"
+)
+
+const WorkspacesTableName = "workspaces"
+
+type workspace struct {
+	Id          uint64     `db:"id,omitempty"`
+	Name        string     `db:"name"`
+	OwnerId     uint64     `db:"owner_id"`
+	CreatedDate time.Time  `db:"created_date"`
+	UpdatedDate time.Time  `db:"updated_date"`
+	DeletedDate *time.Time `db:"deleted_date"`
+}
+
+type WorkspaceRepository interface {
+	Save(w domain.Workspace) (domain.Workspace, error)
+	Find(id uint64) (domain.Workspace, error)
+	FindByMember(userId uint64) ([]domain.Workspace, error)
+	Update(w domain.Workspace) (domain.Workspace, error)
+	Delete(id uint64) error
+}
+
+type workspaceRepository struct {
+	coll db.Collection
+}
+
+func NewWorkspaceRepository(sess db.Session) WorkspaceRepository {
+	return workspaceRepository{
+		coll: sess.Collection(WorkspacesTableName),
+	}
+}
+
+func (r workspaceRepository) Save(w domain.Workspace) (domain.Workspace, error) {
+	ws := r.mapDomainToModel(w)
+	ws.CreatedDate, ws.UpdatedDate = time.Now(), time.Now()
+	err := r.coll.InsertReturning(&ws)
+	if err != nil {
+		return domain.Workspace{}, err
+	}
+
+	return r.mapModelToDomain(ws), nil
+}
+
+func (r workspaceRepository) Find(id uint64) (domain.Workspace, error) {
+	var ws workspace
+	err := r.coll.Find(db.Cond{"id": id, "deleted_date": nil}).One(&ws)
+	if err != nil {
+		return domain.Workspace{}, err
+	}
+
+	return r.mapModelToDomain(ws), nil
+}
+
+func (r workspaceRepository) FindByMember(userId uint64) ([]domain.Workspace, error) {
+	var wss []workspace
+	err := r.coll.Find(db.And(
+		db.Cond{"deleted_date": nil},
+		db.Raw("id IN (SELECT workspace_id FROM "+WorkspaceMembersTableName+" WHERE user_id = ?)", userId),
+	)).OrderBy("id").All(&wss)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.mapModelToDomainCollection(wss), nil
+}
+
+func (r workspaceRepository) Update(w domain.Workspace) (domain.Workspace, error) {
+	ws := r.mapDomainToModel(w)
+	ws.UpdatedDate = time.Now()
+	err := r.coll.Find(db.Cond{"id": ws.Id, "deleted_date": nil}).Update(&ws)
+	if err != nil {
+		return domain.Workspace{}, err
+	}
+
+	return r.mapModelToDomain(ws), nil
+}
+
+func (r workspaceRepository) Delete(id uint64) error {
+	return r.coll.Find(db.Cond{"id": id, "deleted_date": nil}).Update(map[string]interface{}{"deleted_date": time.Now()})
+}
+
+func (r workspaceRepository) mapDomainToModel(d domain.Workspace) workspace {
+	return workspace{
+		Id:          d.Id,
+		Name:        d.Name,
+		OwnerId:     d.OwnerId,
+		CreatedDate: d.CreatedDate,
+		UpdatedDate: d.UpdatedDate,
+		DeletedDate: d.DeletedDate,
+	}
+}
+
+func (r workspaceRepository) mapModelToDomain(m workspace) domain.Workspace {
+	return domain.Workspace{
+		Id:          m.Id,
+		Name:        m.Name,
+		OwnerId:     m.OwnerId,
+		CreatedDate: m.CreatedDate,
+		UpdatedDate: m.UpdatedDate,
+		DeletedDate: m.DeletedDate,
+	}
+}
+
+func (r workspaceRepository) mapModelToDomainCollection(ms []workspace) []domain.Workspace {
+	workspaces := make([]domain.Workspace, len(ms))
+	for i, m := range ms {
+		workspaces[i] = r.mapModelToDomain(m)
+	}
+	return workspaces
+}
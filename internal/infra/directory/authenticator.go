@@ -0,0 +1,156 @@
+package directory
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+const timeout = 5 * time.Second
+
+var ErrInvalidCredentials = errors.New("directory rejected the credentials")
+
+type Config struct {
+	Url          string
+	StartTls     bool
+	BindDn       string
+	BindPassword string
+	BaseDn       string
+	// UserFilter has one %s which is replaced with the escaped login, e.g. (&(objectClass=person)(mail=%s))
+	UserFilter     string
+	EmailAttr      string
+	FirstNameAttr  string
+	SecondNameAttr string
+	GroupsAttr     string
+}
+
+// Entry is the directory account the user logged in with
+type Entry struct {
+	Dn         string
+	Email      string
+	FirstName  string
+	SecondName string
+	Groups     []string
+}
+
+type Authenticator interface {
+	Authenticate(login, password string) (Entry, error)
+}
+
+// Conn is the part of *ldap.Conn which is used, tests can replace it with a stand-in
+type Conn interface {
+	Bind(username, password string) error
+	Search(req *ldap.SearchRequest) (*ldap.SearchResult, error)
+	Close() error
+}
+
+type authenticator struct {
+	config Config
+	dial   func() (Conn, error)
+}
+
+func NewAuthenticator(c Config) Authenticator {
+	return NewAuthenticatorWithDialer(c, func() (Conn, error) {
+		conn, err := ldap.DialURL(c.Url, ldap.DialWithDialer(&net.Dialer{Timeout: timeout}))
+		if err != nil {
+			return nil, err
+		}
+		conn.SetTimeout(timeout)
+
+		if c.StartTls {
+			u, err := url.Parse(c.Url)
+			if err != nil {
+				conn.Close()
+				return nil, err
+			}
+			err = conn.StartTLS(&tls.Config{ServerName: u.Hostname(), MinVersion: tls.VersionTLS12})
+			if err != nil {
+				conn.Close()
+				return nil, err
+			}
+		}
+		return conn, nil
+	})
+}
+
+func NewAuthenticatorWithDialer(c Config, dial func() (Conn, error)) Authenticator {
+	return authenticator{
+		config: c,
+		dial:   dial,
+	}
+}
+
+// Authenticate finds the entry with the service account and then binds as
+// that entry with the password, which is the usual search-and-bind scheme
+func (a authenticator) Authenticate(login, password string) (Entry, error) {
+	// an empty password is an unauthenticated bind, which many servers accept (RFC 4513 5.1.2)
+	if password == "" {
+		return Entry{}, ErrInvalidCredentials
+	}
+
+	conn, err := a.dial()
+	if err != nil {
+		return Entry{}, fmt.Errorf("ldap: %w", err)
+	}
+	defer conn.Close()
+
+	if a.config.BindDn != "" {
+		err = conn.Bind(a.config.BindDn, a.config.BindPassword)
+		if err != nil {
+			return Entry{}, fmt.Errorf("ldap service bind: %w", err)
+		}
+	}
+
+	attrs := []string{a.config.EmailAttr, a.config.FirstNameAttr, a.config.SecondNameAttr}
+	if a.config.GroupsAttr != "" {
+		attrs = append(attrs, a.config.GroupsAttr)
+	}
+
+	res, err := conn.Search(ldap.NewSearchRequest(
+		a.config.BaseDn,
+		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
+		2, int(timeout.Seconds()), false,
+		fmt.Sprintf(a.config.UserFilter, ldap.EscapeFilter(login)),
+		attrs,
+		nil,
+	))
+	if err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+			return Entry{}, ErrInvalidCredentials
+		}
+		return Entry{}, fmt.Errorf("ldap search: %w", err)
+	}
+	// several matches mean the filter is ambiguous, logging in as any of them would be wrong
+	if len(res.Entries) != 1 {
+		return Entry{}, ErrInvalidCredentials
+	}
+	e := res.Entries[0]
+
+	err = conn.Bind(e.DN, password)
+	if err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return Entry{}, ErrInvalidCredentials
+		}
+		return Entry{}, fmt.Errorf("ldap bind: %w", err)
+	}
+
+	entry := Entry{
+		Dn:         e.DN,
+		Email:      e.GetAttributeValue(a.config.EmailAttr),
+		FirstName:  e.GetAttributeValue(a.config.FirstNameAttr),
+		SecondName: e.GetAttributeValue(a.config.SecondNameAttr),
+	}
+	if a.config.GroupsAttr != "" {
+		entry.Groups = e.GetAttributeValues(a.config.GroupsAttr)
+	}
+	if entry.Email == "" {
+		return Entry{}, fmt.Errorf("ldap: entry %s has no %s attribute", e.DN, a.config.EmailAttr)
+	}
+
+	return entry, nil
+}
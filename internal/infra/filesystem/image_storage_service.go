@@ -0,0 +1,41 @@
+package filesystem
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+type ImageStorageService interface {
+	SaveImage(filename string, content []byte) error
+	RemoveImage(filename string) error
+}
+
+type imageStorageService struct {
+	loc string
+}
+
+func NewImageStorageService(location string) ImageStorageService {
+	return imageStorageService{
+		loc: location,
+	}
+}
+
+func (s imageStorageService) SaveImage(filename string, content []byte) error {
+	location := filepath.Join(s.loc, filename)
+	err := os.MkdirAll(filepath.Dir(location), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(location, content, 0644)
+}
+
+func (s imageStorageService) RemoveImage(filename string) error {
+	err := os.Remove(filepath.Join(s.loc, filename))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
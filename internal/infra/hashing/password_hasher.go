@@ -0,0 +1,67 @@
+package hashing
+
+import "errors"
+
+var ErrUnknownHashFormat = errors.New("password hash format is not recognized")
+
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Verify(password, hash string) (bool, error)
+	// NeedsRehash reports that the hash was made by another algorithm or with other parameters
+	NeedsRehash(hash string) bool
+}
+
+// Algorithm is a single hashing scheme which knows its own hash format
+type Algorithm interface {
+	PasswordHasher
+	Recognizes(hash string) bool
+}
+
+type passwordHasher struct {
+	preferred Algorithm
+	legacy    []Algorithm
+}
+
+// NewPasswordHasher hashes new passwords with the preferred algorithm
+// and still verifies hashes made by the legacy ones
+func NewPasswordHasher(preferred Algorithm, legacy ...Algorithm) PasswordHasher {
+	return passwordHasher{
+		preferred: preferred,
+		legacy:    legacy,
+	}
+}
+
+func (h passwordHasher) Hash(password string) (string, error) {
+	return h.preferred.Hash(password)
+}
+
+func (h passwordHasher) Verify(password, hash string) (bool, error) {
+	alg, err := h.algorithmOf(hash)
+	if err != nil {
+		return false, err
+	}
+
+	return alg.Verify(password, hash)
+}
+
+func (h passwordHasher) NeedsRehash(hash string) bool {
+	if !h.preferred.Recognizes(hash) {
+		return true
+	}
+
+	return h.preferred.NeedsRehash(hash)
+}
+
+func (h passwordHasher) algorithmOf(hash string) (Algorithm, error) {
+	if h.preferred.Recognizes(hash) {
+		return h.preferred, nil
+	}
+
+	for _, alg := range h.legacy {
+		if alg.Recognizes(hash) {
+			return alg, nil
+		}
+	}
+
+	return nil, ErrUnknownHashFormat
+}
@@ -0,0 +1,204 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/app"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/requests"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/resources"
+)
+
+const (
+	defaultCountPerPage = 20
+	maxCountPerPage     = 100
+)
+
+type AdminController struct {
+	adminService app.AdminService
+}
+
+func NewAdminController(ads app.AdminService) AdminController {
+	return AdminController{
+		adminService: ads,
+	}
+}
+
+func (c AdminController) FindUsers() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pagination, err := paginationFromRequest(r)
+		if err != nil {
+			BadRequest(w, err)
+			return
+		}
+
+		filters := domain.UserFilters{
+			Search:     r.URL.Query().Get("search"),
+			Pagination: pagination,
+		}
+
+		roleStr := r.URL.Query().Get("role")
+		if roleStr != "" {
+			role := domain.Role(roleStr)
+			if role != domain.AdminRole && role != domain.CustomerRole {
+				BadRequest(w, errors.New("invalid role filter (use ADMIN or CUSTOMER)"))
+				return
+			}
+			filters.Role = &role
+		}
+
+		suspendedStr := r.URL.Query().Get("suspended")
+		if suspendedStr != "" {
+			suspended, err := strconv.ParseBool(suspendedStr)
+			if err != nil {
+				BadRequest(w, errors.New("invalid suspended filter (use true or false)"))
+				return
+			}
+			filters.Suspended = &suspended
+		}
+
+		users, err := c.adminService.FindUsers(filters)
+		if err != nil {
+			log.Printf("AdminController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		var usersDto resources.UsersDto
+		Success(w, usersDto.DomainToDto(users))
+	}
+}
+
+func (c AdminController) FindUser() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(PathUserKey).(domain.User)
+		Success(w, resources.UserDto{}.DomainToDto(user))
+	}
+}
+
+func (c AdminController) Suspend() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		admin := r.Context().Value(UserKey).(domain.User)
+		user := r.Context().Value(PathUserKey).(domain.User)
+
+		user, err := c.adminService.Suspend(admin, user)
+		if err != nil {
+			log.Printf("AdminController: %s", err)
+			adminError(w, err)
+			return
+		}
+
+		Success(w, resources.UserDto{}.DomainToDto(user))
+	}
+}
+
+func (c AdminController) Restore() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(PathUserKey).(domain.User)
+
+		user, err := c.adminService.Restore(user)
+		if err != nil {
+			log.Printf("AdminController: %s", err)
+			adminError(w, err)
+			return
+		}
+
+		Success(w, resources.UserDto{}.DomainToDto(user))
+	}
+}
+
+func (c AdminController) Delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		admin := r.Context().Value(UserKey).(domain.User)
+		user := r.Context().Value(PathUserKey).(domain.User)
+
+		err := c.adminService.Delete(admin, user)
+		if err != nil {
+			log.Printf("AdminController: %s", err)
+			adminError(w, err)
+			return
+		}
+
+		noContent(w)
+	}
+}
+
+func (c AdminController) ForceLogout() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(PathUserKey).(domain.User)
+
+		err := c.adminService.ForceLogout(user)
+		if err != nil {
+			log.Printf("AdminController: %s", err)
+			adminError(w, err)
+			return
+		}
+
+		noContent(w)
+	}
+}
+
+func (c AdminController) ChangeRole() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req, err := requests.Bind(r, requests.ChangeRoleRequest{}, domain.User{})
+		if err != nil {
+			log.Printf("AdminController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		admin := r.Context().Value(UserKey).(domain.User)
+		user := r.Context().Value(PathUserKey).(domain.User)
+
+		user, err = c.adminService.ChangeRole(admin, user, req.Role, deviceFromRequest(r))
+		if err != nil {
+			log.Printf("AdminController: %s", err)
+			adminError(w, err)
+			return
+		}
+
+		Success(w, resources.UserDto{}.DomainToDto(user))
+	}
+}
+
+func paginationFromRequest(r *http.Request) (domain.Pagination, error) {
+	p := domain.Pagination{
+		Page:         1,
+		CountPerPage: defaultCountPerPage,
+	}
+
+	pageStr := r.URL.Query().Get("page")
+	if pageStr != "" {
+		page, err := strconv.ParseUint(pageStr, 10, 64)
+		if err != nil || page == 0 {
+			return domain.Pagination{}, errors.New("invalid page parameter (only positive integers)")
+		}
+		p.Page = page
+	}
+
+	limitStr := r.URL.Query().Get("limit")
+	if limitStr != "" {
+		limit, err := strconv.ParseUint(limitStr, 10, 64)
+		if err != nil || limit == 0 || limit > maxCountPerPage {
+			return domain.Pagination{}, errors.New("invalid limit parameter (1-100)")
+		}
+		p.CountPerPage = limit
+	}
+
+	return p, nil
+}
+
+func adminError(w http.ResponseWriter, err error) {
+	if errors.Is(err, app.ErrSelfModification) {
+		BadRequest(w, err)
+		return
+	}
+	if errors.Is(err, app.ErrUserDeleted) || errors.Is(err, app.ErrEmailTaken) {
+		Conflict(w, err)
+		return
+	}
+	InternalServerError(w, err)
+}
@@ -2,23 +2,44 @@ package controllers
 
 import (
 	"errors"
+	"log"
+	"net/http"
+
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/app"
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/requests"
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/resources"
-	"log"
-	"net/http"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
 )
 
 type AuthController struct {
-	authService app.AuthService
-	userService app.UserService
+	authService              app.AuthService
+	userService              app.UserService
+	passwordResetService     app.PasswordResetService
+	emailVerificationService app.EmailVerificationService
+	magicLinkService         app.MagicLinkService
+	accountDeletionService   app.AccountDeletionService
+	authCookies              AuthCookies
 }
 
-func NewAuthController(as app.AuthService, us app.UserService) AuthController {
+func NewAuthController(
+	as app.AuthService,
+	us app.UserService,
+	prs app.PasswordResetService,
+	evs app.EmailVerificationService,
+	mls app.MagicLinkService,
+	ads app.AccountDeletionService,
+	ac AuthCookies,
+) AuthController {
 	return AuthController{
-		authService: as,
-		userService: us,
+		authService:              as,
+		userService:              us,
+		passwordResetService:     prs,
+		emailVerificationService: evs,
+		magicLinkService:         mls,
+		accountDeletionService:   ads,
+		authCookies:              ac,
 	}
 }
 
@@ -31,15 +52,28 @@ func (c AuthController) Register() http.HandlerFunc {
 			return
 		}
 
-		user, token, err := c.authService.Register(user)
+		user, tokens, err := c.authService.Register(user, deviceFromRequest(r))
 		if err != nil {
 			log.Printf("AuthController: %s", err)
 			BadRequest(w, err)
 			return
 		}
 
+		// the account is already created, the user can ask for the link again
+		err = c.emailVerificationService.Send(user)
+		if err != nil {
+			log.Printf("AuthController: %s", err)
+		}
+
+		tokens, err = c.authCookies.Write(w, tokens)
+		if err != nil {
+			log.Printf("AuthController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
 		var authDto resources.AuthDto
-		Success(w, authDto.DomainToDto(token, user))
+		Success(w, authDto.DomainToDto(tokens, user))
 	}
 }
 
@@ -52,7 +86,121 @@ func (c AuthController) Login() http.HandlerFunc {
 			return
 		}
 
-		u, token, err := c.authService.Login(user)
+		u, tokens, err := c.authService.Login(user, deviceFromRequest(r))
+		if err != nil {
+			log.Printf("AuthController: %s", err)
+			if tooManyAttempts(w, err) {
+				return
+			}
+			if errors.Is(err, app.ErrInvalidCredentials) {
+				Unauthorized(w, err)
+				return
+			}
+			if errors.Is(err, app.ErrUserSuspended) {
+				Forbidden(w, err)
+				return
+			}
+			if errors.Is(err, app.ErrLdapAccountConflict) {
+				Conflict(w, err)
+				return
+			}
+			InternalServerError(w, err)
+			return
+		}
+
+		if tokens.MfaToken != "" {
+			Success(w, resources.MfaChallengeDto{MfaRequired: true, MfaToken: tokens.MfaToken})
+			return
+		}
+
+		tokens, err = c.authCookies.Write(w, tokens)
+		if err != nil {
+			log.Printf("AuthController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		var authDto resources.AuthDto
+		Success(w, authDto.DomainToDto(tokens, u))
+	}
+}
+
+func (c AuthController) LoginMfa() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req requests.LoginMfaRequest
+		code, err := requests.Bind(r, &req, "")
+		if err != nil {
+			log.Printf("AuthController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		u, tokens, err := c.authService.LoginMfa(req.MfaToken, code, deviceFromRequest(r))
+		if err != nil {
+			log.Printf("AuthController: %s", err)
+			if tooManyAttempts(w, err) {
+				return
+			}
+			if errors.Is(err, app.ErrInvalidMfaToken) || errors.Is(err, app.ErrInvalidTwoFactorCode) {
+				Unauthorized(w, err)
+				return
+			}
+			if errors.Is(err, app.ErrUserSuspended) {
+				Forbidden(w, err)
+				return
+			}
+			InternalServerError(w, err)
+			return
+		}
+
+		tokens, err = c.authCookies.Write(w, tokens)
+		if err != nil {
+			log.Printf("AuthController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		var authDto resources.AuthDto
+		Success(w, authDto.DomainToDto(tokens, u))
+	}
+}
+
+func (c AuthController) Refresh() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// browsers in the cookie mode send no body, the token comes from the cookie
+		refreshToken := c.authCookies.RefreshToken(r)
+		if refreshToken != "" && r.ContentLength == 0 {
+			if !c.authCookies.ValidCsrf(r) {
+				Forbidden(w, ErrInvalidCsrfToken)
+				return
+			}
+		} else {
+			var req requests.RefreshRequest
+			_, err := requests.Bind(r, &req, domain.AuthTokens{})
+			if err != nil {
+				log.Printf("AuthController: %s", err)
+				BadRequest(w, err)
+				return
+			}
+			refreshToken = req.RefreshToken
+		}
+
+		u, tokens, err := c.authService.Refresh(refreshToken, deviceFromRequest(r))
+		if err != nil {
+			log.Printf("AuthController: %s", err)
+			if errors.Is(err, app.ErrInvalidRefreshToken) {
+				Unauthorized(w, err)
+				return
+			}
+			if errors.Is(err, app.ErrUserSuspended) {
+				Forbidden(w, err)
+				return
+			}
+			InternalServerError(w, err)
+			return
+		}
+
+		tokens, err = c.authCookies.Write(w, tokens)
 		if err != nil {
 			log.Printf("AuthController: %s", err)
 			InternalServerError(w, err)
@@ -60,16 +208,185 @@ func (c AuthController) Login() http.HandlerFunc {
 		}
 
 		var authDto resources.AuthDto
-		Success(w, authDto.DomainToDto(token, u))
+		Success(w, authDto.DomainToDto(tokens, u))
 	}
 }
 
 func (c AuthController) Logout() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		sess := r.Context().Value(SessKey).(domain.Session)
-		err := c.authService.Logout(sess)
+		err := c.authService.Logout(sess, deviceFromRequest(r))
+		if err != nil {
+			log.Printf("AuthController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		c.authCookies.Clear(w)
+		noContent(w)
+	}
+}
+
+func (c AuthController) FindSessions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sess := r.Context().Value(SessKey).(domain.Session)
+		ss, err := c.authService.FindSessions(sess.UserId)
+		if err != nil {
+			log.Printf("AuthController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		var sessionDto resources.SessionDto
+		Success(w, sessionDto.DomainToDtoCollection(ss, sess))
+	}
+}
+
+func (c AuthController) RevokeSession() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sessUUID, err := uuid.Parse(chi.URLParam(r, "uuid"))
+		if err != nil {
+			BadRequest(w, errors.New("invalid uuid parameter"))
+			return
+		}
+
+		sess := r.Context().Value(SessKey).(domain.Session)
+		err = c.authService.RevokeSession(domain.Session{UserId: sess.UserId, UUID: sessUUID})
+		if err != nil {
+			log.Printf("AuthController: %s", err)
+			if errors.Is(err, app.ErrSessionNotFound) {
+				NotFound(w, err)
+				return
+			}
+			InternalServerError(w, err)
+			return
+		}
+
+		noContent(w)
+	}
+}
+
+func (c AuthController) RevokeOtherSessions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sess := r.Context().Value(SessKey).(domain.Session)
+		err := c.authService.RevokeOtherSessions(sess)
+		if err != nil {
+			log.Printf("AuthController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		noContent(w)
+	}
+}
+
+func (c AuthController) ForgotPassword() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := requests.Bind(r, requests.ForgotPasswordRequest{}, domain.User{})
+		if err != nil {
+			log.Printf("AuthController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		err = c.passwordResetService.Forgot(user.Email)
+		if err != nil {
+			log.Printf("AuthController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Success(w, "If the email is registered, a reset link has been sent to it")
+	}
+}
+
+func (c AuthController) ResetPassword() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req requests.ResetPasswordRequest
+		user, err := requests.Bind(r, &req, domain.User{})
+		if err != nil {
+			log.Printf("AuthController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		err = c.passwordResetService.Reset(req.Token, user.Password, deviceFromRequest(r))
+		if err != nil {
+			log.Printf("AuthController: %s", err)
+			if errors.Is(err, app.ErrInvalidResetToken) {
+				BadRequest(w, err)
+				return
+			}
+			InternalServerError(w, err)
+			return
+		}
+
+		noContent(w)
+	}
+}
+
+func (c AuthController) RestoreAccount() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, err := requests.Bind(r, requests.RestoreAccountRequest{}, "")
+		if err != nil {
+			log.Printf("AuthController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		user, err := c.accountDeletionService.Restore(token)
+		if err != nil {
+			log.Printf("AuthController: %s", err)
+			switch {
+			case errors.Is(err, app.ErrInvalidRestoreToken):
+				BadRequest(w, err)
+			case errors.Is(err, app.ErrAccountEmailTaken):
+				Conflict(w, err)
+			default:
+				InternalServerError(w, err)
+			}
+			return
+		}
+
+		Success(w, resources.UserDto{}.DomainToDto(user))
+	}
+}
+
+func (c AuthController) VerifyEmail() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := r.URL.Query().Get("token")
+		if token == "" {
+			BadRequest(w, errors.New("token is required"))
+			return
+		}
+
+		user, err := c.emailVerificationService.Verify(token)
+		if err != nil {
+			log.Printf("AuthController: %s", err)
+			if errors.Is(err, app.ErrInvalidVerificationToken) {
+				BadRequest(w, err)
+				return
+			}
+			InternalServerError(w, err)
+			return
+		}
+
+		var userDto resources.UserDto
+		Success(w, userDto.DomainToDto(user))
+	}
+}
+
+func (c AuthController) ResendVerification() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+
+		err := c.emailVerificationService.Send(user)
 		if err != nil {
 			log.Printf("AuthController: %s", err)
+			if errors.Is(err, app.ErrEmailAlreadyVerified) {
+				BadRequest(w, err)
+				return
+			}
 			InternalServerError(w, err)
 			return
 		}
@@ -77,3 +394,68 @@ func (c AuthController) Logout() http.HandlerFunc {
 		noContent(w)
 	}
 }
+
+func (c AuthController) RequestMagicLink() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := requests.Bind(r, requests.MagicLinkRequest{}, domain.User{})
+		if err != nil {
+			log.Printf("AuthController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		nonce, err := c.magicLinkService.Request(user.Email)
+		if err != nil {
+			log.Printf("AuthController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		Success(w, resources.MagicLinkDto{
+			Message: "If the email is registered, a login link has been sent to it",
+			Nonce:   nonce,
+		})
+	}
+}
+
+func (c AuthController) ConsumeMagicLink() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req requests.ConsumeMagicLinkRequest
+		token, err := requests.Bind(r, &req, "")
+		if err != nil {
+			log.Printf("AuthController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		u, tokens, err := c.magicLinkService.Consume(token, req.Nonce, deviceFromRequest(r))
+		if err != nil {
+			log.Printf("AuthController: %s", err)
+			if errors.Is(err, app.ErrInvalidMagicLink) {
+				Unauthorized(w, err)
+				return
+			}
+			if errors.Is(err, app.ErrUserSuspended) {
+				Forbidden(w, err)
+				return
+			}
+			InternalServerError(w, err)
+			return
+		}
+
+		if tokens.MfaToken != "" {
+			Success(w, resources.MfaChallengeDto{MfaRequired: true, MfaToken: tokens.MfaToken})
+			return
+		}
+
+		tokens, err = c.authCookies.Write(w, tokens)
+		if err != nil {
+			log.Printf("AuthController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		var authDto resources.AuthDto
+		Success(w, authDto.DomainToDto(tokens, u))
+	}
+}
@@ -0,0 +1,132 @@
+package controllers
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+)
+
+const (
+	AccessTokenCookie  = "access_token"
+	RefreshTokenCookie = "refresh_token"
+	// CsrfTokenCookie is readable by scripts, the client copies it to CsrfHeader
+	CsrfTokenCookie = "csrf_token"
+	CsrfHeader      = "X-CSRF-Token"
+
+	// the refresh token is sent only to the auth endpoints
+	refreshTokenCookiePath = "/api/v1/auth"
+)
+
+var ErrInvalidCsrfToken = errors.New("missing or invalid CSRF token")
+
+// AuthCookies is the browser mode of authentication: the tokens are kept
+// in HttpOnly cookies and unsafe requests are protected by a double-submit
+// CSRF token. When it is disabled every method is a no-op
+type AuthCookies struct {
+	enabled    bool
+	domain     string
+	secure     bool
+	sameSite   http.SameSite
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+func NewAuthCookies(enabled bool, domain string, secure bool, sameSite http.SameSite, accessTtl, refreshTtl time.Duration) AuthCookies {
+	return AuthCookies{
+		enabled:    enabled,
+		domain:     domain,
+		secure:     secure,
+		sameSite:   sameSite,
+		accessTTL:  accessTtl,
+		refreshTTL: refreshTtl,
+	}
+}
+
+// Write sets the cookies for freshly issued tokens, an mfa challenge has none yet.
+// It returns the tokens for the response body, the ones put into HttpOnly cookies
+// are left out, otherwise scripts could read them anyway
+func (c AuthCookies) Write(w http.ResponseWriter, tokens domain.AuthTokens) (domain.AuthTokens, error) {
+	if !c.enabled || tokens.AccessToken == "" {
+		return tokens, nil
+	}
+
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return domain.AuthTokens{}, err
+	}
+
+	http.SetCookie(w, c.cookie(AccessTokenCookie, tokens.AccessToken, "/", c.accessTTL, true))
+	http.SetCookie(w, c.cookie(RefreshTokenCookie, tokens.RefreshToken, refreshTokenCookiePath, c.refreshTTL, true))
+	http.SetCookie(w, c.cookie(CsrfTokenCookie, base64.RawURLEncoding.EncodeToString(b), "/", c.refreshTTL, false))
+
+	tokens.AccessToken = ""
+	tokens.RefreshToken = ""
+	return tokens, nil
+}
+
+func (c AuthCookies) Clear(w http.ResponseWriter) {
+	if !c.enabled {
+		return
+	}
+
+	http.SetCookie(w, c.cookie(AccessTokenCookie, "", "/", -1, true))
+	http.SetCookie(w, c.cookie(RefreshTokenCookie, "", refreshTokenCookiePath, -1, true))
+	http.SetCookie(w, c.cookie(CsrfTokenCookie, "", "/", -1, false))
+}
+
+func (c AuthCookies) AccessToken(r *http.Request) string {
+	return c.value(r, AccessTokenCookie)
+}
+
+func (c AuthCookies) RefreshToken(r *http.Request) string {
+	return c.value(r, RefreshTokenCookie)
+}
+
+// ValidCsrf must be checked for every request authenticated by a cookie,
+// a cross-site page can send the cookies but can't read them to set the header
+func (c AuthCookies) ValidCsrf(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+
+	cookie := c.value(r, CsrfTokenCookie)
+	header := r.Header.Get(CsrfHeader)
+	return cookie != "" && subtle.ConstantTimeCompare([]byte(cookie), []byte(header)) == 1
+}
+
+func (c AuthCookies) value(r *http.Request, name string) string {
+	if !c.enabled {
+		return ""
+	}
+
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return ""
+	}
+	return cookie.Value
+}
+
+func (c AuthCookies) cookie(name, value, path string, ttl time.Duration, httpOnly bool) *http.Cookie {
+	maxAge := int(ttl.Seconds())
+	if ttl < 0 {
+		maxAge = -1
+	}
+
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   c.domain,
+		MaxAge:   maxAge,
+		Secure:   c.secure,
+		HttpOnly: httpOnly,
+		SameSite: c.sameSite,
+	}
+}
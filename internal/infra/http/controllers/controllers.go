@@ -2,9 +2,17 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"math"
+	"net"
 	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/app"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
 )
 
 /* should not use built-in type string as key for value;
@@ -15,9 +23,17 @@ type CtxKey struct {
 }
 
 var (
-	UserKey = CtxKey{Name: "user"}
-	SessKey = CtxKey{Name: "sess"}
-	TaskKey = CtxKey{Name: "taks"}
+	UserKey       = CtxKey{Name: "user"}
+	SessKey       = CtxKey{Name: "sess"}
+	TaskKey       = CtxKey{Name: "taks"}
+	WorkspaceKey  = CtxKey{Name: "workspace"}
+	PublicLinkKey = CtxKey{Name: "publicLink"}
+	PathUserKey   = CtxKey{Name: "pathUser"}
+	// AuthTokenKey is set only when the request is authenticated with a personal access token
+	AuthTokenKey           = CtxKey{Name: "authToken"}
+	PersonalAccessTokenKey = CtxKey{Name: "personalAccessToken"}
+	// ActorKey is set only while an admin impersonates the user under UserKey
+	ActorKey = CtxKey{Name: "actor"}
 )
 
 func Ok(w http.ResponseWriter) {
@@ -65,6 +81,13 @@ func Forbidden(w http.ResponseWriter, err error) {
 	encodeErrorBody(w, err)
 }
 
+func Conflict(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+
+	encodeErrorBody(w, err)
+}
+
 func InternalServerError(w http.ResponseWriter, err error) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusInternalServerError)
@@ -117,3 +140,49 @@ func Unauthorized(w http.ResponseWriter, err error) {
 
 	encodeErrorBody(w, err)
 }
+
+func TooManyRequests(w http.ResponseWriter, err error, retryAfter time.Duration) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	w.WriteHeader(http.StatusTooManyRequests)
+
+	encodeErrorBody(w, err)
+}
+
+// tooManyAttempts responds with 429 when the login is throttled and reports whether it did
+func tooManyAttempts(w http.ResponseWriter, err error) bool {
+	var tma app.TooManyAttemptsError
+	if !errors.As(err, &tma) {
+		return false
+	}
+
+	TooManyRequests(w, err, tma.RetryAfter)
+	return true
+}
+
+// accessError responds with 403 for authorization failures and 500 for everything else
+func accessError(w http.ResponseWriter, err error) {
+	if errors.Is(err, app.ErrAccessDenied) {
+		Forbidden(w, err)
+		return
+	}
+
+	log.Print(err)
+	InternalServerError(w, err)
+}
+
+func deviceFromRequest(r *http.Request) domain.Device {
+	return domain.Device{
+		UserAgent: r.UserAgent(),
+		Ip:        ClientIp(r),
+	}
+}
+
+// ClientIp returns the address of the client without the port
+func ClientIp(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,74 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/app"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/requests"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/resources"
+)
+
+type ImpersonationController struct {
+	impersonationService app.ImpersonationService
+}
+
+func NewImpersonationController(is app.ImpersonationService) ImpersonationController {
+	return ImpersonationController{
+		impersonationService: is,
+	}
+}
+
+// Start does not touch the auth cookies, they stay with the admin's own session
+func (c ImpersonationController) Start() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		imp, err := requests.Bind(r, requests.ImpersonationRequest{}, domain.Impersonation{})
+		if err != nil {
+			log.Printf("ImpersonationController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		admin := r.Context().Value(UserKey).(domain.User)
+		user := r.Context().Value(PathUserKey).(domain.User)
+
+		sess, token, err := c.impersonationService.Start(admin, user, imp, deviceFromRequest(r))
+		if err != nil {
+			log.Printf("ImpersonationController: %s", err)
+			impersonationError(w, err)
+			return
+		}
+
+		var impDto resources.ImpersonationDto
+		Created(w, impDto.DomainToDto(token, sess, user))
+	}
+}
+
+func (c ImpersonationController) Stop() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sess := r.Context().Value(SessKey).(domain.Session)
+
+		err := c.impersonationService.Stop(sess, deviceFromRequest(r))
+		if err != nil {
+			log.Printf("ImpersonationController: %s", err)
+			impersonationError(w, err)
+			return
+		}
+
+		noContent(w)
+	}
+}
+
+func impersonationError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, app.ErrImpersonationNotAllowed),
+		errors.Is(err, app.ErrUserSuspended):
+		Forbidden(w, err)
+	case errors.Is(err, app.ErrNotImpersonating):
+		BadRequest(w, err)
+	default:
+		InternalServerError(w, err)
+	}
+}
@@ -0,0 +1,25 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/jwtkeys"
+)
+
+type JwksController struct {
+	jwtKeys jwtkeys.Manager
+}
+
+func NewJwksController(jk jwtkeys.Manager) JwksController {
+	return JwksController{
+		jwtKeys: jk,
+	}
+}
+
+func (c JwksController) Find() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// keys change only on restart, verifiers may cache them for a while
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		Success(w, c.jwtKeys.PublicKeys())
+	}
+}
@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/app"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/requests"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/resources"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/oidc"
+)
+
+type OidcController struct {
+	oidcService app.OidcService
+	authCookies AuthCookies
+}
+
+func NewOidcController(os app.OidcService, ac AuthCookies) OidcController {
+	return OidcController{
+		oidcService: os,
+		authCookies: ac,
+	}
+}
+
+func (c OidcController) Start() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		url, clientNonce, err := c.oidcService.Start()
+		if err != nil {
+			log.Printf("OidcController: %s", err)
+			oidcError(w, err)
+			return
+		}
+
+		Success(w, resources.OidcAuthorizationDto{AuthorizationUrl: url, ClientNonce: clientNonce})
+	}
+}
+
+// Callback is called by the client with the code and state from the redirect of the provider
+func (c OidcController) Callback() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req requests.OidcCallbackRequest
+		code, err := requests.Bind(r, &req, "")
+		if err != nil {
+			log.Printf("OidcController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		u, tokens, err := c.oidcService.Callback(code, req.State, req.ClientNonce, deviceFromRequest(r))
+		if err != nil {
+			log.Printf("OidcController: %s", err)
+			oidcError(w, err)
+			return
+		}
+
+		if tokens.MfaToken != "" {
+			Success(w, resources.MfaChallengeDto{MfaRequired: true, MfaToken: tokens.MfaToken})
+			return
+		}
+
+		tokens, err = c.authCookies.Write(w, tokens)
+		if err != nil {
+			log.Printf("OidcController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		var authDto resources.AuthDto
+		Success(w, authDto.DomainToDto(tokens, u))
+	}
+}
+
+func oidcError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, app.ErrOidcDisabled):
+		NotFound(w, err)
+	case errors.Is(err, app.ErrInvalidOidcState),
+		errors.Is(err, oidc.ErrExchangeFailed),
+		errors.Is(err, oidc.ErrInvalidIdToken):
+		Unauthorized(w, err)
+	case errors.Is(err, app.ErrOidcEmailNotVerified),
+		errors.Is(err, app.ErrOidcAccountConflict),
+		errors.Is(err, app.ErrUserSuspended),
+		errors.Is(err, app.ErrAccountDeleted):
+		Forbidden(w, err)
+	default:
+		InternalServerError(w, err)
+	}
+}
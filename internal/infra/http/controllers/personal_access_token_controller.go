@@ -0,0 +1,79 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/app"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/requests"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/resources"
+)
+
+type PersonalAccessTokenController struct {
+	tokenService app.PersonalAccessTokenService
+}
+
+func NewPersonalAccessTokenController(pats app.PersonalAccessTokenService) PersonalAccessTokenController {
+	return PersonalAccessTokenController{
+		tokenService: pats,
+	}
+}
+
+func (c PersonalAccessTokenController) Save() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, err := requests.Bind(r, requests.PersonalAccessTokenRequest{}, domain.PersonalAccessToken{})
+		if err != nil {
+			log.Printf("PersonalAccessTokenController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		user := r.Context().Value(UserKey).(domain.User)
+		token.UserId = user.Id
+		token, err = c.tokenService.Save(token)
+		if err != nil {
+			log.Printf("PersonalAccessTokenController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		var tokenDto resources.PersonalAccessTokenDto
+		Created(w, tokenDto.DomainToDto(token))
+	}
+}
+
+func (c PersonalAccessTokenController) FindAll() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		tokens, err := c.tokenService.FindByUser(user.Id)
+		if err != nil {
+			log.Printf("PersonalAccessTokenController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		var tokenDto resources.PersonalAccessTokenDto
+		Success(w, tokenDto.DomainToDtoCollection(tokens))
+	}
+}
+
+func (c PersonalAccessTokenController) Revoke() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := r.Context().Value(PersonalAccessTokenKey).(domain.PersonalAccessToken)
+		user := r.Context().Value(UserKey).(domain.User)
+		if token.UserId != user.Id {
+			Forbidden(w, app.ErrAccessDenied)
+			return
+		}
+
+		err := c.tokenService.Revoke(token.Id)
+		if err != nil {
+			log.Printf("PersonalAccessTokenController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		noContent(w)
+	}
+}
@@ -0,0 +1,203 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/app"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/requests"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/resources"
+)
+
+type PublicLinkController struct {
+	publicLinkService app.PublicLinkService
+	authService       app.AuthorizationService
+}
+
+func NewPublicLinkController(pls app.PublicLinkService, as app.AuthorizationService) PublicLinkController {
+	return PublicLinkController{
+		publicLinkService: pls,
+		authService:       as,
+	}
+}
+
+func (c PublicLinkController) SaveForTask() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		link, err := requests.Bind(r, requests.PublicLinkRequest{}, domain.PublicLink{})
+		if err != nil {
+			log.Printf("PublicLinkController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		task := r.Context().Value(TaskKey).(domain.Task)
+		user := r.Context().Value(UserKey).(domain.User)
+		err = c.authService.CanManageTask(user.Id, task)
+		if err != nil {
+			accessError(w, err)
+			return
+		}
+
+		link.UserId = user.Id
+		link.TaskId = &task.Id
+		c.save(w, link)
+	}
+}
+
+func (c PublicLinkController) SaveForWorkspace() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		link, err := requests.Bind(r, requests.PublicLinkRequest{}, domain.PublicLink{})
+		if err != nil {
+			log.Printf("PublicLinkController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		ws := r.Context().Value(WorkspaceKey).(domain.Workspace)
+		user := r.Context().Value(UserKey).(domain.User)
+		err = c.authService.CanManageWorkspace(user.Id, ws.Id)
+		if err != nil {
+			accessError(w, err)
+			return
+		}
+
+		link.UserId = user.Id
+		link.WorkspaceId = &ws.Id
+		c.save(w, link)
+	}
+}
+
+func (c PublicLinkController) FindAll() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		links, err := c.publicLinkService.FindByUser(user.Id)
+		if err != nil {
+			log.Printf("PublicLinkController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		var linkDto resources.PublicLinkDto
+		Success(w, linkDto.DomainToDtoCollection(links))
+	}
+}
+
+func (c PublicLinkController) FindForTask() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		task := r.Context().Value(TaskKey).(domain.Task)
+		user := r.Context().Value(UserKey).(domain.User)
+		err := c.authService.CanManageTask(user.Id, task)
+		if err != nil {
+			accessError(w, err)
+			return
+		}
+
+		links, err := c.publicLinkService.FindByTask(task.Id)
+		if err != nil {
+			log.Printf("PublicLinkController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		var linkDto resources.PublicLinkDto
+		Success(w, linkDto.DomainToDtoCollection(links))
+	}
+}
+
+func (c PublicLinkController) FindForWorkspace() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ws := r.Context().Value(WorkspaceKey).(domain.Workspace)
+		user := r.Context().Value(UserKey).(domain.User)
+		err := c.authService.CanManageWorkspace(user.Id, ws.Id)
+		if err != nil {
+			accessError(w, err)
+			return
+		}
+
+		links, err := c.publicLinkService.FindByWorkspace(ws.Id)
+		if err != nil {
+			log.Printf("PublicLinkController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		var linkDto resources.PublicLinkDto
+		Success(w, linkDto.DomainToDtoCollection(links))
+	}
+}
+
+func (c PublicLinkController) Revoke() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		link := r.Context().Value(PublicLinkKey).(domain.PublicLink)
+		user := r.Context().Value(UserKey).(domain.User)
+		err := c.publicLinkService.CanManage(user.Id, link)
+		if err != nil {
+			if errors.Is(err, app.ErrPublicLinkNotFound) {
+				publicLinkError(w, err)
+				return
+			}
+			accessError(w, err)
+			return
+		}
+
+		err = c.publicLinkService.Revoke(link.Id)
+		if err != nil {
+			log.Printf("PublicLinkController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		noContent(w)
+	}
+}
+
+func (c PublicLinkController) PublicTask() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		link := r.Context().Value(PublicLinkKey).(domain.PublicLink)
+		task, err := c.publicLinkService.FindTask(link)
+		if err != nil {
+			publicLinkError(w, err)
+			return
+		}
+
+		var taskDto resources.PublicTaskDto
+		Success(w, taskDto.DomainToDto(task))
+	}
+}
+
+func (c PublicLinkController) PublicList() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		link := r.Context().Value(PublicLinkKey).(domain.PublicLink)
+		ws, tasks, err := c.publicLinkService.FindList(link)
+		if err != nil {
+			publicLinkError(w, err)
+			return
+		}
+
+		var listDto resources.PublicListDto
+		Success(w, listDto.DomainToDto(ws, tasks))
+	}
+}
+
+func (c PublicLinkController) save(w http.ResponseWriter, link domain.PublicLink) {
+	link, err := c.publicLinkService.Save(link)
+	if err != nil {
+		log.Printf("PublicLinkController: %s", err)
+		InternalServerError(w, err)
+		return
+	}
+
+	var linkDto resources.PublicLinkDto
+	Created(w, linkDto.DomainToDto(link))
+}
+
+func publicLinkError(w http.ResponseWriter, err error) {
+	log.Printf("PublicLinkController: %s", err)
+	if errors.Is(err, app.ErrPublicLinkNotFound) {
+		NotFound(w, err)
+		return
+	}
+	InternalServerError(w, err)
+}
@@ -0,0 +1,272 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/app"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/requests"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/resources"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	defaultScimCount = 100
+	maxScimCount     = 200
+)
+
+// attribute eq "value", the only filter identity providers send when provisioning
+var scimFilterRegexp = regexp.MustCompile(`(?i)^\s*(userName|emails(?:\.value)?)\s+eq\s+("(?:[^"\\]|\\.)*")\s*$`)
+
+type ScimController struct {
+	scimService app.ScimService
+	baseUrl     string
+}
+
+func NewScimController(ss app.ScimService, baseUrl string) ScimController {
+	return ScimController{
+		scimService: ss,
+		baseUrl:     baseUrl,
+	}
+}
+
+func (c ScimController) FindUsers() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		f, err := scimFiltersFromRequest(r)
+		if err != nil {
+			ScimError(w, http.StatusBadRequest, "invalidFilter", err)
+			return
+		}
+
+		users, err := c.scimService.FindUsers(f)
+		if err != nil {
+			log.Printf("ScimController: %s", err)
+			ScimError(w, http.StatusInternalServerError, "", err)
+			return
+		}
+
+		scimJson(w, http.StatusOK, resources.ScimListDto{}.DomainToDto(users, f.StartIndex, c.baseUrl))
+	}
+}
+
+func (c ScimController) Find() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := c.findUser(w, r)
+		if !ok {
+			return
+		}
+
+		scimJson(w, http.StatusOK, resources.ScimUserDto{}.DomainToDto(user, c.baseUrl))
+	}
+}
+
+func (c ScimController) Create() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req requests.ScimUserRequest
+		user, err := requests.Bind(r, &req, domain.User{})
+		if err != nil {
+			log.Printf("ScimController: %s", err)
+			ScimError(w, http.StatusBadRequest, "invalidValue", err)
+			return
+		}
+
+		user, err = c.scimService.Create(user, req.IsActive())
+		if err != nil {
+			log.Printf("ScimController: %s", err)
+			scimServiceError(w, err)
+			return
+		}
+
+		scimJson(w, http.StatusCreated, resources.ScimUserDto{}.DomainToDto(user, c.baseUrl))
+	}
+}
+
+func (c ScimController) Replace() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		current, ok := c.findUser(w, r)
+		if !ok {
+			return
+		}
+
+		var req requests.ScimUserRequest
+		user, err := requests.Bind(r, &req, domain.User{})
+		if err != nil {
+			log.Printf("ScimController: %s", err)
+			ScimError(w, http.StatusBadRequest, "invalidValue", err)
+			return
+		}
+
+		user.Id = current.Id
+		user, err = c.scimService.Update(user, req.IsActive())
+		if err != nil {
+			log.Printf("ScimController: %s", err)
+			scimServiceError(w, err)
+			return
+		}
+
+		scimJson(w, http.StatusOK, resources.ScimUserDto{}.DomainToDto(user, c.baseUrl))
+	}
+}
+
+func (c ScimController) Patch() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		current, ok := c.findUser(w, r)
+		if !ok {
+			return
+		}
+
+		patch, err := requests.Bind(r, requests.ScimPatchRequest{}, requests.ScimPatchRequest{})
+		if err != nil {
+			log.Printf("ScimController: %s", err)
+			ScimError(w, http.StatusBadRequest, "invalidSyntax", err)
+			return
+		}
+
+		active := current.DeletedDate == nil
+		req, err := patch.Apply(requests.ScimUserRequest{
+			UserName: current.Email,
+			Name: requests.ScimNameRequest{
+				GivenName:  current.FirstName,
+				FamilyName: current.SecondName,
+			},
+			Active: &active,
+		})
+		if err != nil {
+			log.Printf("ScimController: %s", err)
+			scimType := "invalidValue"
+			if errors.Is(err, requests.ErrScimInvalidPatch) {
+				scimType = "invalidPath"
+			}
+			ScimError(w, http.StatusBadRequest, scimType, err)
+			return
+		}
+
+		d, _ := req.ToDomainModel()
+		user := d.(domain.User)
+		user.Id = current.Id
+		user, err = c.scimService.Update(user, req.IsActive())
+		if err != nil {
+			log.Printf("ScimController: %s", err)
+			scimServiceError(w, err)
+			return
+		}
+
+		scimJson(w, http.StatusOK, resources.ScimUserDto{}.DomainToDto(user, c.baseUrl))
+	}
+}
+
+// Delete deactivates the user, the account is kept as a soft deleted one
+func (c ScimController) Delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := c.findUser(w, r)
+		if !ok {
+			return
+		}
+
+		_, err := c.scimService.Deactivate(user)
+		if err != nil {
+			log.Printf("ScimController: %s", err)
+			scimServiceError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (c ScimController) findUser(w http.ResponseWriter, r *http.Request) (domain.User, bool) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "userId"), 10, 64)
+	if err != nil {
+		ScimError(w, http.StatusNotFound, "", app.ErrScimUserNotFound)
+		return domain.User{}, false
+	}
+
+	user, err := c.scimService.Find(id)
+	if err != nil {
+		scimServiceError(w, err)
+		return domain.User{}, false
+	}
+
+	return user, true
+}
+
+func scimFiltersFromRequest(r *http.Request) (domain.UserFilters, error) {
+	f := domain.UserFilters{
+		StartIndex: 1,
+		Pagination: domain.Pagination{CountPerPage: defaultScimCount},
+	}
+
+	// out of range values are clamped as RFC 7644 3.4.2.4 asks
+	if s := r.URL.Query().Get("startIndex"); s != "" {
+		startIndex, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return domain.UserFilters{}, errors.New("startIndex must be an integer")
+		}
+		if startIndex > 1 {
+			f.StartIndex = uint64(startIndex)
+		}
+	}
+	if s := r.URL.Query().Get("count"); s != "" {
+		count, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return domain.UserFilters{}, errors.New("count must be an integer")
+		}
+		f.CountPerPage = uint64(min(max(count, 0), maxScimCount))
+	}
+
+	if s := r.URL.Query().Get("filter"); s != "" {
+		m := scimFilterRegexp.FindStringSubmatch(s)
+		if m == nil {
+			return domain.UserFilters{}, fmt.Errorf("unsupported filter %q (use userName eq \"...\" or emails.value eq \"...\")", s)
+		}
+		email, err := strconv.Unquote(m[2])
+		if err != nil {
+			return domain.UserFilters{}, fmt.Errorf("invalid filter value %s", m[2])
+		}
+		f.Email = strings.TrimSpace(email)
+	}
+
+	return f, nil
+}
+
+func scimServiceError(w http.ResponseWriter, err error) {
+	var validationErrors validator.ValidationErrors
+	switch {
+	case errors.Is(err, app.ErrScimUserNotFound):
+		ScimError(w, http.StatusNotFound, "", err)
+	case errors.Is(err, app.ErrScimUserExists):
+		ScimError(w, http.StatusConflict, "uniqueness", err)
+	case errors.As(err, &validationErrors):
+		ScimError(w, http.StatusBadRequest, "invalidValue", err)
+	default:
+		ScimError(w, http.StatusInternalServerError, "", err)
+	}
+}
+
+// ScimError responds with the error format of RFC 7644 3.12
+func ScimError(w http.ResponseWriter, status int, scimType string, err error) {
+	scimJson(w, status, resources.ScimErrorDto{
+		Schemas:  []string{resources.ScimErrorSchema},
+		Status:   strconv.Itoa(status),
+		ScimType: scimType,
+		Detail:   err.Error(),
+	})
+}
+
+func scimJson(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/scim+json")
+	w.WriteHeader(status)
+
+	err := json.NewEncoder(w).Encode(body)
+	if err != nil {
+		log.Print(err)
+	}
+}
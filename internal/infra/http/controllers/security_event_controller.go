@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/app"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/resources"
+)
+
+type SecurityEventController struct {
+	securityEventService app.SecurityEventService
+}
+
+func NewSecurityEventController(ses app.SecurityEventService) SecurityEventController {
+	return SecurityEventController{
+		securityEventService: ses,
+	}
+}
+
+// FindMine lists the events of the current user, it accepts the type, from and to filters
+func (c SecurityEventController) FindMine() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filters, err := securityEventFiltersFromRequest(r)
+		if err != nil {
+			BadRequest(w, err)
+			return
+		}
+
+		user := r.Context().Value(UserKey).(domain.User)
+		filters.UserId = &user.Id
+
+		c.findAll(w, filters)
+	}
+}
+
+// FindAll lets admins also filter by userId, email and ip
+func (c SecurityEventController) FindAll() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filters, err := securityEventFiltersFromRequest(r)
+		if err != nil {
+			BadRequest(w, err)
+			return
+		}
+
+		userIdStr := r.URL.Query().Get("userId")
+		if userIdStr != "" {
+			userId, err := strconv.ParseUint(userIdStr, 10, 64)
+			if err != nil {
+				BadRequest(w, errors.New("invalid userId filter (only positive integers)"))
+				return
+			}
+			filters.UserId = &userId
+		}
+		filters.Email = r.URL.Query().Get("email")
+		filters.Ip = r.URL.Query().Get("ip")
+
+		c.findAll(w, filters)
+	}
+}
+
+func (c SecurityEventController) findAll(w http.ResponseWriter, filters domain.SecurityEventFilters) {
+	events, err := c.securityEventService.FindAll(filters)
+	if err != nil {
+		log.Printf("SecurityEventController: %s", err)
+		InternalServerError(w, err)
+		return
+	}
+
+	var eventsDto resources.SecurityEventsDto
+	Success(w, eventsDto.DomainToDto(events))
+}
+
+func securityEventFiltersFromRequest(r *http.Request) (domain.SecurityEventFilters, error) {
+	pagination, err := paginationFromRequest(r)
+	if err != nil {
+		return domain.SecurityEventFilters{}, err
+	}
+	filters := domain.SecurityEventFilters{Pagination: pagination}
+
+	typeStr := r.URL.Query().Get("type")
+	if typeStr != "" {
+		t := domain.SecurityEventType(typeStr)
+		if !slices.Contains(domain.SecurityEventTypes, t) {
+			return domain.SecurityEventFilters{}, errors.New("invalid type filter")
+		}
+		filters.Type = &t
+	}
+
+	filters.From, err = timeFromQuery(r, "from")
+	if err != nil {
+		return domain.SecurityEventFilters{}, err
+	}
+	filters.To, err = timeFromQuery(r, "to")
+	if err != nil {
+		return domain.SecurityEventFilters{}, err
+	}
+
+	return filters, nil
+}
+
+func timeFromQuery(r *http.Request, name string) (*time.Time, error) {
+	str := r.URL.Query().Get(name)
+	if str == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, str)
+	if err != nil {
+		return nil, errors.New("invalid " + name + " filter (use RFC 3339, e.g. 2026-01-02T15:04:05Z)")
+	}
+	return &t, nil
+}
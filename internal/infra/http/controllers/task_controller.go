@@ -5,6 +5,7 @@ import (
 	"errors"
 	"log"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/app"
@@ -15,11 +16,13 @@ import (
 
 type TaskController struct {
 	taskService app.TaskService
+	authService app.AuthorizationService
 }
 
-func NewTaskController(ts app.TaskService) TaskController {
+func NewTaskController(ts app.TaskService, as app.AuthorizationService) TaskController {
 	return TaskController{
 		taskService: ts,
+		authService: as,
 	}
 }
 
@@ -36,6 +39,14 @@ func (c TaskController) Save() http.HandlerFunc {
 		user := r.Context().Value(UserKey).(domain.User)
 		task.UserId = user.Id
 
+		if task.WorkspaceId != nil {
+			err = c.authService.CanEditWorkspace(user.Id, *task.WorkspaceId)
+			if err != nil {
+				accessError(w, err)
+				return
+			}
+		}
+
 		task, err = c.taskService.Save(task)
 		if err != nil {
 			log.Printf("TaskController: %s", err)
@@ -54,9 +65,9 @@ func (c TaskController) Find() http.HandlerFunc {
 		task := r.Context().Value(TaskKey).(domain.Task)
 		user := r.Context().Value(UserKey).(domain.User)
 
-		if task.UserId != user.Id {
-			err := errors.New("access denied")
-			Forbidden(w, err)
+		err := c.authService.CanViewTask(user.Id, task)
+		if err != nil {
+			accessError(w, err)
 			return
 		}
 
@@ -90,8 +101,46 @@ func (c TaskController) FindAll() http.HandlerFunc {
 			date = &parsedDate
 		}
 
+		// Фільтр по робочому простору (опціональний)
+		var workspaceId *uint64
+		workspaceStr := r.URL.Query().Get("workspace")
+		if workspaceStr != "" {
+			id, err := strconv.ParseUint(workspaceStr, 10, 64)
+			if err != nil {
+				BadRequest(w, errors.New("invalid workspace filter (only non-negative integers)"))
+				return
+			}
+			err = c.authService.CanViewWorkspace(user.Id, id)
+			if err != nil {
+				accessError(w, err)
+				return
+			}
+			workspaceId = &id
+		}
+
+		// Задачі, якими поділилися інші користувачі (опціонально)
+		shared := r.URL.Query().Get("shared") == "true"
+
+		// Задачі, призначені користувачу (опціонально)
+		var assigned bool
+		assigneeStr := r.URL.Query().Get("assignee")
+		if assigneeStr != "" {
+			if assigneeStr != "me" {
+				BadRequest(w, errors.New("invalid assignee filter (only 'me' is supported)"))
+				return
+			}
+			assigned = true
+		}
+
 		// Виклик сервісу з фільтрами
-		tasks, err := c.taskService.FindAll(user.Id, status, date)
+		tasks, err := c.taskService.FindAll(domain.TaskFilters{
+			UserId:      user.Id,
+			WorkspaceId: workspaceId,
+			Status:      status,
+			Date:        date,
+			Shared:      shared,
+			Assigned:    assigned,
+		})
 		if err != nil {
 			log.Printf("TaskController.FindAll(c.taskService.FindAll): %s", err)
 			InternalServerError(w, err)
@@ -115,9 +164,9 @@ func (c TaskController) Update() http.HandlerFunc {
 
 		user := r.Context().Value(UserKey).(domain.User)
 		taskExists := r.Context().Value(TaskKey).(domain.Task)
-		if taskExists.UserId != user.Id {
-			err = errors.New("access denied")
-			Forbidden(w, err)
+		err = c.authService.CanEditTask(user.Id, taskExists)
+		if err != nil {
+			accessError(w, err)
 			return
 		}
 
@@ -143,13 +192,13 @@ func (c TaskController) Delete() http.HandlerFunc {
 		task := r.Context().Value(TaskKey).(domain.Task)
 		user := r.Context().Value(UserKey).(domain.User)
 
-		if task.UserId != user.Id {
-			err := errors.New("access denied")
-			Forbidden(w, err)
+		err := c.authService.CanManageTask(user.Id, task)
+		if err != nil {
+			accessError(w, err)
 			return
 		}
 
-		err := c.taskService.Delete(task.Id)
+		err = c.taskService.Delete(task.Id)
 		if err != nil {
 			log.Printf("TaskController: %s", err)
 			InternalServerError(w, err)
@@ -178,8 +227,43 @@ func (c TaskController) UpdateStatus() http.HandlerFunc {
 		// Викликаємо сервіс з частковим оновленням ресурсу
 		updatedTask, err := c.taskService.UpdateStatus(task.Id, user.Id, body.Status)
 		if err != nil {
-			if err.Error() == "access denied" {
-				Forbidden(w, err)
+			accessError(w, err)
+			return
+		}
+
+		var taskDto resources.TaskDto
+		taskDto = taskDto.DomainToDto(updatedTask)
+		Success(w, taskDto)
+	}
+}
+
+func (c TaskController) Assign() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req requests.AssignTaskRequest
+		_, err := requests.Bind(r, &req, domain.Task{})
+		if err != nil {
+			log.Printf("TaskController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		task := r.Context().Value(TaskKey).(domain.Task)
+		user := r.Context().Value(UserKey).(domain.User)
+		err = c.authService.CanEditTask(user.Id, task)
+		if err != nil {
+			accessError(w, err)
+			return
+		}
+
+		task, err = c.taskService.Assign(task, req.AssigneeId)
+		if err != nil {
+			log.Printf("TaskController: %s", err)
+			if errors.Is(err, app.ErrAssigneeNotFound) {
+				NotFound(w, err)
+				return
+			}
+			if errors.Is(err, app.ErrAssigneeNoAccess) {
+				validationError(w, err)
 				return
 			}
 			InternalServerError(w, err)
@@ -187,7 +271,33 @@ func (c TaskController) UpdateStatus() http.HandlerFunc {
 		}
 
 		var taskDto resources.TaskDto
-		taskDto = taskDto.DomainToDto(updatedTask)
-		Success(w, taskDto)
+		Success(w, taskDto.DomainToDto(task))
+	}
+}
+
+func (c TaskController) Unassign() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		task := r.Context().Value(TaskKey).(domain.Task)
+		user := r.Context().Value(UserKey).(domain.User)
+
+		// the assignee is always allowed to hand the task back
+		var err error
+		if task.AssigneeId == nil || *task.AssigneeId != user.Id {
+			err = c.authService.CanEditTask(user.Id, task)
+			if err != nil {
+				accessError(w, err)
+				return
+			}
+		}
+
+		task, err = c.taskService.Unassign(task)
+		if err != nil {
+			log.Printf("TaskController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		var taskDto resources.TaskDto
+		Success(w, taskDto.DomainToDto(task))
 	}
 }
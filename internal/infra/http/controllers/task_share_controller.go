@@ -0,0 +1,113 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/app"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/requests"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/resources"
+	"github.com/go-chi/chi/v5"
+)
+
+type TaskShareController struct {
+	taskShareService app.TaskShareService
+	authService      app.AuthorizationService
+}
+
+func NewTaskShareController(tss app.TaskShareService, as app.AuthorizationService) TaskShareController {
+	return TaskShareController{
+		taskShareService: tss,
+		authService:      as,
+	}
+}
+
+func (c TaskShareController) Share() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req requests.ShareTaskRequest
+		share, err := requests.Bind(r, &req, domain.TaskShare{})
+		if err != nil {
+			log.Printf("TaskShareController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		task := r.Context().Value(TaskKey).(domain.Task)
+		user := r.Context().Value(UserKey).(domain.User)
+		err = c.authService.CanManageTask(user.Id, task)
+		if err != nil {
+			accessError(w, err)
+			return
+		}
+
+		share, err = c.taskShareService.Share(task, req.Email, share.Permission)
+		if err != nil {
+			log.Printf("TaskShareController: %s", err)
+			if errors.Is(err, app.ErrCollaboratorNotFound) {
+				NotFound(w, err)
+				return
+			}
+			BadRequest(w, err)
+			return
+		}
+
+		var shareDto resources.TaskShareDto
+		Success(w, shareDto.DomainToDto(share))
+	}
+}
+
+func (c TaskShareController) FindAll() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		task := r.Context().Value(TaskKey).(domain.Task)
+		user := r.Context().Value(UserKey).(domain.User)
+		err := c.authService.CanViewTask(user.Id, task)
+		if err != nil {
+			accessError(w, err)
+			return
+		}
+
+		shares, err := c.taskShareService.FindByTask(task.Id)
+		if err != nil {
+			log.Printf("TaskShareController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		var shareDto resources.TaskShareDto
+		Success(w, shareDto.DomainToDtoCollection(shares))
+	}
+}
+
+func (c TaskShareController) Revoke() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		collaboratorId, err := strconv.ParseUint(chi.URLParam(r, "userId"), 10, 64)
+		if err != nil {
+			BadRequest(w, errors.New("invalid userId parameter(only non-negative integers)"))
+			return
+		}
+
+		task := r.Context().Value(TaskKey).(domain.Task)
+		user := r.Context().Value(UserKey).(domain.User)
+
+		// collaborators are always allowed to leave a task
+		if collaboratorId != user.Id {
+			err = c.authService.CanManageTask(user.Id, task)
+			if err != nil {
+				accessError(w, err)
+				return
+			}
+		}
+
+		err = c.taskShareService.Revoke(task.Id, collaboratorId)
+		if err != nil {
+			log.Printf("TaskShareController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		noContent(w)
+	}
+}
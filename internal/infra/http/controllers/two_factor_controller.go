@@ -0,0 +1,113 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/app"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/requests"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/resources"
+)
+
+type TwoFactorController struct {
+	twoFactorService app.TwoFactorService
+}
+
+func NewTwoFactorController(tfs app.TwoFactorService) TwoFactorController {
+	return TwoFactorController{
+		twoFactorService: tfs,
+	}
+}
+
+func (c TwoFactorController) Enroll() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+
+		enrollment, err := c.twoFactorService.Enroll(user)
+		if err != nil {
+			log.Printf("TwoFactorController: %s", err)
+			twoFactorError(w, err)
+			return
+		}
+
+		var enrollmentDto resources.TwoFactorEnrollmentDto
+		Success(w, enrollmentDto.DomainToDto(enrollment))
+	}
+}
+
+func (c TwoFactorController) Enable() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		code, err := requests.Bind(r, requests.TwoFactorCodeRequest{}, "")
+		if err != nil {
+			log.Printf("TwoFactorController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		user := r.Context().Value(UserKey).(domain.User)
+		codes, err := c.twoFactorService.Enable(user, code)
+		if err != nil {
+			log.Printf("TwoFactorController: %s", err)
+			twoFactorError(w, err)
+			return
+		}
+
+		Success(w, resources.RecoveryCodesDto{RecoveryCodes: codes})
+	}
+}
+
+func (c TwoFactorController) Disable() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		code, err := requests.Bind(r, requests.TwoFactorCodeRequest{}, "")
+		if err != nil {
+			log.Printf("TwoFactorController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		user := r.Context().Value(UserKey).(domain.User)
+		err = c.twoFactorService.Disable(user, code)
+		if err != nil {
+			log.Printf("TwoFactorController: %s", err)
+			twoFactorError(w, err)
+			return
+		}
+
+		noContent(w)
+	}
+}
+
+func (c TwoFactorController) RegenerateRecoveryCodes() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		code, err := requests.Bind(r, requests.TwoFactorCodeRequest{}, "")
+		if err != nil {
+			log.Printf("TwoFactorController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		user := r.Context().Value(UserKey).(domain.User)
+		codes, err := c.twoFactorService.RegenerateRecoveryCodes(user, code)
+		if err != nil {
+			log.Printf("TwoFactorController: %s", err)
+			twoFactorError(w, err)
+			return
+		}
+
+		Success(w, resources.RecoveryCodesDto{RecoveryCodes: codes})
+	}
+}
+
+func twoFactorError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, app.ErrTwoFactorEnabled),
+		errors.Is(err, app.ErrTwoFactorDisabled),
+		errors.Is(err, app.ErrTwoFactorNotEnrolled),
+		errors.Is(err, app.ErrInvalidTwoFactorCode):
+		BadRequest(w, err)
+	default:
+		InternalServerError(w, err)
+	}
+}
@@ -1,23 +1,43 @@
 package controllers
 
 import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/app"
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/requests"
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/resources"
-	"log"
-	"net/http"
 )
 
+const maxAvatarSize = 10 << 20
+
 type UserController struct {
-	userService app.UserService
-	authService app.AuthService
+	userService              app.UserService
+	authService              app.AuthService
+	avatarService            app.AvatarService
+	emailVerificationService app.EmailVerificationService
+	accountDeletionService   app.AccountDeletionService
+	authCookies              AuthCookies
 }
 
-func NewUserController(us app.UserService, as app.AuthService) UserController {
+func NewUserController(
+	us app.UserService,
+	as app.AuthService,
+	avs app.AvatarService,
+	evs app.EmailVerificationService,
+	ads app.AccountDeletionService,
+	ac AuthCookies,
+) UserController {
 	return UserController{
-		userService: us,
-		authService: as,
+		userService:              us,
+		authService:              as,
+		avatarService:            avs,
+		emailVerificationService: evs,
+		accountDeletionService:   ads,
+		authCookies:              ac,
 	}
 }
 
@@ -38,16 +58,31 @@ func (c UserController) Update() http.HandlerFunc {
 		}
 
 		u := r.Context().Value(UserKey).(domain.User)
+		emailChanged := u.Email != user.Email
 		u.FirstName = user.FirstName
 		u.SecondName = user.SecondName
 		u.Email = user.Email
+		if emailChanged {
+			u.EmailVerifiedAt = nil
+		}
 		user, err = c.userService.Update(u)
 		if err != nil {
 			log.Printf("UserController: %s", err)
+			if errors.Is(err, app.ErrEmailTaken) {
+				Conflict(w, err)
+				return
+			}
 			InternalServerError(w, err)
 			return
 		}
 
+		if emailChanged {
+			err = c.emailVerificationService.Send(user)
+			if err != nil {
+				log.Printf("UserController: %s", err)
+			}
+		}
+
 		var userDto resources.UserDto
 		Success(w, userDto.DomainToDto(user))
 	}
@@ -57,13 +92,82 @@ func (c UserController) Delete() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		u := r.Context().Value(UserKey).(domain.User)
 
-		err := c.userService.Delete(u.Id)
+		err := c.accountDeletionService.Delete(u)
 		if err != nil {
 			log.Printf("UserController: %s", err)
 			InternalServerError(w, err)
 			return
 		}
 
+		c.authCookies.Clear(w)
 		Ok(w)
 	}
 }
+
+func (c UserController) ChangePassword() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cp, err := requests.Bind(r, requests.ChangePasswordRequest{}, domain.ChangePassword{})
+		if err != nil {
+			log.Printf("UserController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		u := r.Context().Value(UserKey).(domain.User)
+		tokens, err := c.authService.ChangePassword(u, cp, deviceFromRequest(r))
+		if err != nil {
+			log.Printf("UserController: %s", err)
+			if errors.Is(err, app.ErrInvalidOldPassword) {
+				BadRequest(w, err)
+				return
+			}
+			InternalServerError(w, err)
+			return
+		}
+
+		tokens, err = c.authCookies.Write(w, tokens)
+		if err != nil {
+			log.Printf("UserController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		var authDto resources.AuthDto
+		Success(w, authDto.DomainToDto(tokens, u))
+	}
+}
+
+func (c UserController) UploadAvatar() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, maxAvatarSize)
+		file, _, err := r.FormFile("image")
+		if err != nil {
+			log.Printf("UserController: %s", err)
+			BadRequest(w, errors.New("image file is required (max 10MB)"))
+			return
+		}
+		defer file.Close()
+
+		content, err := io.ReadAll(file)
+		if err != nil {
+			log.Printf("UserController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		u := r.Context().Value(UserKey).(domain.User)
+		u, err = c.avatarService.Upload(u, content)
+		if err != nil {
+			log.Printf("UserController: %s", err)
+			if errors.Is(err, app.ErrUnsupportedImage) {
+				BadRequest(w, err)
+				return
+			}
+			InternalServerError(w, err)
+			return
+		}
+
+		var userDto resources.UserDto
+		Success(w, userDto.DomainToDto(u))
+	}
+}
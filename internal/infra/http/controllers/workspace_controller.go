@@ -0,0 +1,294 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/app"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/requests"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/resources"
+	"github.com/go-chi/chi/v5"
+)
+
+type WorkspaceController struct {
+	workspaceService app.WorkspaceService
+	authService      app.AuthorizationService
+}
+
+func NewWorkspaceController(ws app.WorkspaceService, as app.AuthorizationService) WorkspaceController {
+	return WorkspaceController{
+		workspaceService: ws,
+		authService:      as,
+	}
+}
+
+func (c WorkspaceController) Save() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ws, err := requests.Bind(r, requests.WorkspaceRequest{}, domain.Workspace{})
+		if err != nil {
+			log.Printf("WorkspaceController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		user := r.Context().Value(UserKey).(domain.User)
+		ws.OwnerId = user.Id
+		ws, err = c.workspaceService.Save(ws)
+		if err != nil {
+			log.Printf("WorkspaceController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		var workspaceDto resources.WorkspaceDto
+		Created(w, workspaceDto.DomainToDto(ws))
+	}
+}
+
+func (c WorkspaceController) FindAll() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		ws, err := c.workspaceService.FindByMember(user.Id)
+		if err != nil {
+			log.Printf("WorkspaceController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		var workspaceDto resources.WorkspaceDto
+		Success(w, workspaceDto.DomainToDtoCollection(ws))
+	}
+}
+
+func (c WorkspaceController) Find() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ws := r.Context().Value(WorkspaceKey).(domain.Workspace)
+		user := r.Context().Value(UserKey).(domain.User)
+		err := c.authService.CanViewWorkspace(user.Id, ws.Id)
+		if err != nil {
+			accessError(w, err)
+			return
+		}
+
+		var workspaceDto resources.WorkspaceDto
+		Success(w, workspaceDto.DomainToDto(ws))
+	}
+}
+
+func (c WorkspaceController) Update() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req, err := requests.Bind(r, requests.WorkspaceRequest{}, domain.Workspace{})
+		if err != nil {
+			log.Printf("WorkspaceController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		ws := r.Context().Value(WorkspaceKey).(domain.Workspace)
+		user := r.Context().Value(UserKey).(domain.User)
+		err = c.authService.CanManageWorkspace(user.Id, ws.Id)
+		if err != nil {
+			accessError(w, err)
+			return
+		}
+
+		ws.Name = req.Name
+		ws, err = c.workspaceService.Update(ws)
+		if err != nil {
+			log.Printf("WorkspaceController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		var workspaceDto resources.WorkspaceDto
+		Success(w, workspaceDto.DomainToDto(ws))
+	}
+}
+
+func (c WorkspaceController) Delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ws := r.Context().Value(WorkspaceKey).(domain.Workspace)
+		user := r.Context().Value(UserKey).(domain.User)
+		if ws.OwnerId != user.Id {
+			Forbidden(w, app.ErrAccessDenied)
+			return
+		}
+
+		err := c.workspaceService.Delete(ws.Id)
+		if err != nil {
+			log.Printf("WorkspaceController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		noContent(w)
+	}
+}
+
+func (c WorkspaceController) FindMembers() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ws := r.Context().Value(WorkspaceKey).(domain.Workspace)
+		user := r.Context().Value(UserKey).(domain.User)
+		err := c.authService.CanViewWorkspace(user.Id, ws.Id)
+		if err != nil {
+			accessError(w, err)
+			return
+		}
+
+		members, err := c.workspaceService.FindMembers(ws.Id)
+		if err != nil {
+			log.Printf("WorkspaceController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		var memberDto resources.WorkspaceMemberDto
+		Success(w, memberDto.DomainToDtoCollection(members))
+	}
+}
+
+func (c WorkspaceController) UpdateMember() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		member, err := requests.Bind(r, requests.WorkspaceMemberRequest{}, domain.WorkspaceMember{})
+		if err != nil {
+			log.Printf("WorkspaceController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		memberId, err := strconv.ParseUint(chi.URLParam(r, "userId"), 10, 64)
+		if err != nil {
+			BadRequest(w, errors.New("invalid userId parameter(only non-negative integers)"))
+			return
+		}
+
+		ws := r.Context().Value(WorkspaceKey).(domain.Workspace)
+		user := r.Context().Value(UserKey).(domain.User)
+		role, err := c.managerRole(user.Id, ws.Id)
+		if err != nil {
+			accessError(w, err)
+			return
+		}
+
+		member.WorkspaceId = ws.Id
+		member.UserId = memberId
+		member, err = c.workspaceService.UpdateMemberRole(role, member)
+		if err != nil {
+			c.memberError(w, err)
+			return
+		}
+
+		var memberDto resources.WorkspaceMemberDto
+		Success(w, memberDto.DomainToDto(member))
+	}
+}
+
+func (c WorkspaceController) RemoveMember() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		memberId, err := strconv.ParseUint(chi.URLParam(r, "userId"), 10, 64)
+		if err != nil {
+			BadRequest(w, errors.New("invalid userId parameter(only non-negative integers)"))
+			return
+		}
+
+		ws := r.Context().Value(WorkspaceKey).(domain.Workspace)
+		user := r.Context().Value(UserKey).(domain.User)
+
+		// members are always allowed to leave a workspace, except the owner
+		if memberId == user.Id {
+			err = c.workspaceService.Leave(ws.Id, user.Id)
+		} else {
+			var role domain.WorkspaceRole
+			role, err = c.managerRole(user.Id, ws.Id)
+			if err != nil {
+				accessError(w, err)
+				return
+			}
+			err = c.workspaceService.RemoveMember(role, ws.Id, memberId)
+		}
+		if err != nil {
+			c.memberError(w, err)
+			return
+		}
+
+		noContent(w)
+	}
+}
+
+func (c WorkspaceController) Invite() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		inv, err := requests.Bind(r, requests.WorkspaceInvitationRequest{}, domain.WorkspaceInvitation{})
+		if err != nil {
+			log.Printf("WorkspaceController: %s", err)
+			BadRequest(w, err)
+			return
+		}
+
+		ws := r.Context().Value(WorkspaceKey).(domain.Workspace)
+		user := r.Context().Value(UserKey).(domain.User)
+		role, err := c.managerRole(user.Id, ws.Id)
+		if err != nil {
+			accessError(w, err)
+			return
+		}
+		if inv.Role == domain.WorkspaceAdminRole && role != domain.WorkspaceOwnerRole {
+			Forbidden(w, app.ErrAccessDenied)
+			return
+		}
+
+		inv.WorkspaceId = ws.Id
+		inv.InvitedBy = user.Id
+		inv, err = c.workspaceService.Invite(inv)
+		if err != nil {
+			log.Printf("WorkspaceController: %s", err)
+			InternalServerError(w, err)
+			return
+		}
+
+		var invitationDto resources.WorkspaceInvitationDto
+		Created(w, invitationDto.DomainToDto(inv))
+	}
+}
+
+func (c WorkspaceController) AcceptInvitation() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserKey).(domain.User)
+		member, err := c.workspaceService.AcceptInvitation(chi.URLParam(r, "token"), user)
+		if err != nil {
+			log.Printf("WorkspaceController: %s", err)
+			if errors.Is(err, app.ErrInvalidInvitation) {
+				NotFound(w, err)
+				return
+			}
+			InternalServerError(w, err)
+			return
+		}
+
+		var memberDto resources.WorkspaceMemberDto
+		Success(w, memberDto.DomainToDto(member))
+	}
+}
+
+func (c WorkspaceController) managerRole(userId, workspaceId uint64) (domain.WorkspaceRole, error) {
+	err := c.authService.CanManageWorkspace(userId, workspaceId)
+	if err != nil {
+		return "", err
+	}
+
+	return c.authService.WorkspaceRole(userId, workspaceId)
+}
+
+func (c WorkspaceController) memberError(w http.ResponseWriter, err error) {
+	log.Printf("WorkspaceController: %s", err)
+	switch {
+	case errors.Is(err, app.ErrMemberNotFound):
+		NotFound(w, err)
+	case errors.Is(err, app.ErrOwnerRoleImmutable):
+		BadRequest(w, err)
+	default:
+		accessError(w, err)
+	}
+}
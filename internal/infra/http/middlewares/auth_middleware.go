@@ -6,25 +6,62 @@ import (
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/app"
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/controllers"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/jwtkeys"
 	"github.com/go-chi/jwtauth/v5"
 	"github.com/google/uuid"
-	"github.com/lestrrat-go/jwx/v2/jwt"
 	"github.com/upper/db/v4"
 	"net/http"
+	"strings"
 )
 
-func AuthMiddleware(ja *jwtauth.JWTAuth, as app.AuthService, us app.UserService) func(http.Handler) http.Handler {
+func AuthMiddleware(
+	jk jwtkeys.Manager,
+	as app.AuthService,
+	us app.UserService,
+	pats app.PersonalAccessTokenService,
+	cookies controllers.AuthCookies,
+) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		hfn := func(w http.ResponseWriter, r *http.Request) {
 			ctx := r.Context()
-			token, err := jwtauth.VerifyRequest(ja, r, jwtauth.TokenFromHeader)
+			raw := jwtauth.TokenFromHeader(r)
 
-			if err != nil {
-				controllers.Unauthorized(w, err)
+			if strings.HasPrefix(raw, domain.PersonalAccessTokenPrefix) {
+				pat, err := pats.Authenticate(raw)
+				if err != nil {
+					controllers.Unauthorized(w, err)
+					return
+				}
+
+				user, err := findUser(us, pat.UserId)
+				if err != nil {
+					controllers.Unauthorized(w, err)
+					return
+				}
+
+				ctx = context.WithValue(ctx, controllers.UserKey, user)
+				ctx = context.WithValue(ctx, controllers.AuthTokenKey, pat)
+
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
+			// the header wins, so API clients are never subject to the CSRF check
+			if raw == "" {
+				raw = cookies.AccessToken(r)
+				if raw != "" && !cookies.ValidCsrf(r) {
+					controllers.Forbidden(w, controllers.ErrInvalidCsrfToken)
+					return
+				}
+			}
+
+			if raw == "" {
+				controllers.Unauthorized(w, jwtauth.ErrNoTokenFound)
 				return
 			}
 
-			if token == nil || jwt.Validate(token) != nil {
+			token, err := jk.Verify(raw)
+			if err != nil {
 				controllers.Unauthorized(w, err)
 				return
 			}
@@ -47,15 +84,29 @@ func AuthMiddleware(ja *jwtauth.JWTAuth, as app.AuthService, us app.UserService)
 				return
 			}
 
-			user, err := us.FindById(uId)
+			user, err := findUser(us, uId)
 			if err != nil {
-				if errors.Is(err, db.ErrNoMoreRows) {
-					err = errors.New("unauthorized")
-				}
 				controllers.Unauthorized(w, err)
 				return
 			}
 
+			if actorId, ok := claims["actor_id"].(float64); ok {
+				actor, err := findUser(us, uint64(actorId))
+				if err != nil {
+					controllers.Unauthorized(w, err)
+					return
+				}
+				// a demoted admin loses the sessions opened on behalf of others
+				if actor.Role != domain.AdminRole {
+					controllers.Unauthorized(w, app.ErrImpersonationNotAllowed)
+					return
+				}
+
+				auth.ActorId = &actor.Id
+				auth.AllowDestructive, _ = claims["allow_destructive"].(bool)
+				ctx = context.WithValue(ctx, controllers.ActorKey, actor)
+			}
+
 			ctx = context.WithValue(ctx, controllers.UserKey, user)
 			ctx = context.WithValue(ctx, controllers.SessKey, auth)
 
@@ -64,3 +115,17 @@ func AuthMiddleware(ja *jwtauth.JWTAuth, as app.AuthService, us app.UserService)
 		return http.HandlerFunc(hfn)
 	}
 }
+
+func findUser(us app.UserService, id uint64) (domain.User, error) {
+	user, err := us.FindById(id)
+	if err != nil {
+		if errors.Is(err, db.ErrNoMoreRows) {
+			err = errors.New("unauthorized")
+		}
+		return domain.User{}, err
+	}
+	if user.IsSuspended() {
+		return domain.User{}, app.ErrUserSuspended
+	}
+	return user, nil
+}
@@ -0,0 +1,30 @@
+package middlewares
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/controllers"
+)
+
+// EmailVerifiedMiddleware must run after AuthMiddleware,
+// when verification is not required it lets every request through
+func EmailVerifiedMiddleware(required bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !required {
+			return next
+		}
+
+		hfn := func(w http.ResponseWriter, r *http.Request) {
+			user := r.Context().Value(controllers.UserKey).(domain.User)
+			if !user.IsEmailVerified() {
+				controllers.Forbidden(w, errors.New("email is not verified"))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(hfn)
+	}
+}
@@ -0,0 +1,85 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/app"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/controllers"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+var impersonationAuditKey = controllers.CtxKey{Name: "impersonationAudit"}
+
+// ImpersonationMiddleware audits every request made on behalf of a user by an admin.
+// It must run after AuthMiddleware
+func ImpersonationMiddleware(is app.ImpersonationService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		hfn := func(w http.ResponseWriter, r *http.Request) {
+			sess, ok := r.Context().Value(controllers.SessKey).(domain.Session)
+			if !ok || !sess.IsImpersonation() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			entry := &domain.ImpersonationAudit{
+				SessionUUID: sess.UUID,
+				ActorId:     *sess.ActorId,
+				UserId:      sess.UserId,
+				Action:      domain.ImpersonationRequest,
+				Method:      r.Method,
+				Path:        r.URL.Path,
+				Ip:          controllers.ClientIp(r),
+			}
+
+			// ImpersonationReadOnly marks the entry when it blocks the request further down the chain
+			ctx := context.WithValue(r.Context(), impersonationAuditKey, entry)
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r.WithContext(ctx))
+
+			entry.Status = ww.Status()
+			if entry.Status == 0 {
+				entry.Status = http.StatusOK
+			}
+			is.Record(*entry)
+		}
+		return http.HandlerFunc(hfn)
+	}
+}
+
+// ImpersonationReadOnly lets an impersonating admin only read unless the impersonation
+// was started with changes allowed. Routes which end the impersonation don't use it
+func ImpersonationReadOnly(next http.Handler) http.Handler {
+	hfn := func(w http.ResponseWriter, r *http.Request) {
+		sess, ok := r.Context().Value(controllers.SessKey).(domain.Session)
+		if !ok || !sess.IsImpersonation() || sess.AllowDestructive || isSafeMethod(r.Method) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if entry, ok := r.Context().Value(impersonationAuditKey).(*domain.ImpersonationAudit); ok {
+			entry.Action = domain.ImpersonationBlocked
+		}
+		controllers.Forbidden(w, app.ErrImpersonationReadOnly)
+	}
+	return http.HandlerFunc(hfn)
+}
+
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// NotImpersonated keeps admins away from the credentials and the email of the user they act as,
+// even when changes are allowed, otherwise the account could be taken over through a reset
+func NotImpersonated(next http.Handler) http.Handler {
+	hfn := func(w http.ResponseWriter, r *http.Request) {
+		if sess, ok := r.Context().Value(controllers.SessKey).(domain.Session); ok && sess.IsImpersonation() {
+			controllers.Forbidden(w, app.ErrImpersonationReadOnly)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(hfn)
+}
@@ -0,0 +1,35 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/app"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/controllers"
+	"github.com/go-chi/chi/v5"
+)
+
+// PublicLinkMiddleware resolves the share token from the path instead of authenticating the user
+func PublicLinkMiddleware(pls app.PublicLinkService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		hfn := func(w http.ResponseWriter, r *http.Request) {
+			link, err := pls.Resolve(chi.URLParam(r, "token"))
+			if err != nil {
+				if errors.Is(err, app.ErrPublicLinkNotFound) {
+					controllers.NotFound(w, err)
+					return
+				}
+				log.Print(err)
+				controllers.InternalServerError(w, err)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), controllers.PublicLinkKey, link)
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		}
+		return http.HandlerFunc(hfn)
+	}
+}
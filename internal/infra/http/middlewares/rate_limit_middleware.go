@@ -0,0 +1,54 @@
+package middlewares
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/controllers"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/ratelimit"
+)
+
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+// RateLimitMiddleware counts requests of the group per authenticated user,
+// or per client IP when it runs before AuthMiddleware
+func RateLimitMiddleware(store ratelimit.Store, group string, limit ratelimit.Limit) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		hfn := func(w http.ResponseWriter, r *http.Request) {
+			key := fmt.Sprintf("%s:ip:%s", group, controllers.ClientIp(r))
+			if user, ok := r.Context().Value(controllers.UserKey).(domain.User); ok {
+				key = fmt.Sprintf("%s:user:%d", group, user.Id)
+			}
+
+			res, err := store.Take(key, limit)
+			if err != nil {
+				// a broken store must not take the API down with it
+				log.Printf("RateLimitMiddleware: %s", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(res.Limit))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			w.Header().Set("X-RateLimit-Reset", ceilSeconds(res.Reset))
+
+			if !res.Allowed {
+				controllers.TooManyRequests(w, ErrRateLimited, res.RetryAfter)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(hfn)
+	}
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
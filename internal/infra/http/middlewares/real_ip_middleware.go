@@ -0,0 +1,76 @@
+package middlewares
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// RealIpMiddleware replaces RemoteAddr with the client address reported by a trusted
+// reverse proxy, otherwise every client behind the proxy would share its address.
+// The headers are ignored unless the request comes from one of the trusted networks,
+// a client could set them to anything
+func RealIpMiddleware(trusted []netip.Prefix) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		hfn := func(w http.ResponseWriter, r *http.Request) {
+			if len(trusted) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			host, port, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			peer, err := netip.ParseAddr(host)
+			if err != nil || !isTrusted(trusted, peer) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if client, ok := forwardedFor(trusted, r.Header.Values("X-Forwarded-For")); ok {
+				r.RemoteAddr = net.JoinHostPort(client.String(), port)
+			} else if client, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP"))); err == nil {
+				r.RemoteAddr = net.JoinHostPort(client.Unmap().String(), port)
+			}
+
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(hfn)
+	}
+}
+
+// forwardedFor walks the chain from the nearest hop and returns the first address
+// which is not a trusted proxy, the entries before it could be forged by the client
+func forwardedFor(trusted []netip.Prefix, headers []string) (netip.Addr, bool) {
+	var hops []string
+	for _, h := range headers {
+		hops = append(hops, strings.Split(h, ",")...)
+	}
+
+	var client netip.Addr
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		client = addr.Unmap()
+		if !isTrusted(trusted, client) {
+			break
+		}
+	}
+
+	return client, client.IsValid()
+}
+
+func isTrusted(trusted []netip.Prefix, addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, p := range trusted {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,26 @@
+package middlewares
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/app"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/controllers"
+)
+
+// RequireRole must run after AuthMiddleware
+func RequireRole(roles ...domain.Role) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		hfn := func(w http.ResponseWriter, r *http.Request) {
+			user := r.Context().Value(controllers.UserKey).(domain.User)
+			if !slices.Contains(roles, user.Role) {
+				controllers.Forbidden(w, app.ErrAccessDenied)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(hfn)
+	}
+}
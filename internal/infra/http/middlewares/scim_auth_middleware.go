@@ -0,0 +1,40 @@
+package middlewares
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/controllers"
+	"github.com/go-chi/jwtauth/v5"
+)
+
+var (
+	ErrScimDisabled     = errors.New("SCIM provisioning is not configured")
+	ErrInvalidScimToken = errors.New("invalid SCIM bearer token")
+)
+
+// ScimAuthMiddleware accepts only the token shared with the identity provider,
+// with an empty token the SCIM API is switched off
+func ScimAuthMiddleware(token string) func(http.Handler) http.Handler {
+	expected := sha256.Sum256([]byte(token))
+	return func(next http.Handler) http.Handler {
+		hfn := func(w http.ResponseWriter, r *http.Request) {
+			if token == "" {
+				controllers.ScimError(w, http.StatusNotFound, "", ErrScimDisabled)
+				return
+			}
+
+			// hashing first makes the comparison independent of the token length
+			given := sha256.Sum256([]byte(jwtauth.TokenFromHeader(r)))
+			if subtle.ConstantTimeCompare(given[:], expected[:]) != 1 {
+				controllers.ScimError(w, http.StatusUnauthorized, "", ErrInvalidScimToken)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(hfn)
+	}
+}
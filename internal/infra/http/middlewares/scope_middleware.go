@@ -0,0 +1,41 @@
+package middlewares
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/controllers"
+)
+
+// RequireScope lets through user sessions and personal access tokens granted the scope.
+// Routes that don't declare a scope must use SessionOnly instead,
+// otherwise a token would get the full access of its owner
+func RequireScope(scope domain.Scope) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		hfn := func(w http.ResponseWriter, r *http.Request) {
+			pat, ok := r.Context().Value(controllers.AuthTokenKey).(domain.PersonalAccessToken)
+			if ok && !pat.HasScope(scope) {
+				controllers.Forbidden(w, fmt.Errorf("access token lacks the %s scope", scope))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(hfn)
+	}
+}
+
+// SessionOnly rejects requests authenticated with a personal access token
+func SessionOnly(next http.Handler) http.Handler {
+	hfn := func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Value(controllers.AuthTokenKey).(domain.PersonalAccessToken); ok {
+			controllers.Forbidden(w, errors.New("this endpoint is not available for access tokens"))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(hfn)
+}
@@ -0,0 +1,13 @@
+package requests
+
+import "github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+
+type ChangeRoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=ADMIN CUSTOMER"`
+}
+
+func (r ChangeRoleRequest) ToDomainModel() (interface{}, error) {
+	return domain.User{
+		Role: domain.Role(r.Role),
+	}, nil
+}
@@ -0,0 +1,15 @@
+package requests
+
+import "github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+
+type ImpersonationRequest struct {
+	Reason           string `json:"reason" validate:"required,max=255"`
+	AllowDestructive bool   `json:"allowDestructive"`
+}
+
+func (r ImpersonationRequest) ToDomainModel() (interface{}, error) {
+	return domain.Impersonation{
+		Reason:           r.Reason,
+		AllowDestructive: r.AllowDestructive,
+	}, nil
+}
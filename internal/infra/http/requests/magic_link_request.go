@@ -0,0 +1,22 @@
+package requests
+
+import "github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+
+type MagicLinkRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ConsumeMagicLinkRequest struct {
+	Token string `json:"token" validate:"required,max=100"`
+	Nonce string `json:"nonce" validate:"required,max=100"`
+}
+
+func (r MagicLinkRequest) ToDomainModel() (interface{}, error) {
+	return domain.User{
+		Email: r.Email,
+	}, nil
+}
+
+func (r ConsumeMagicLinkRequest) ToDomainModel() (interface{}, error) {
+	return r.Token, nil
+}
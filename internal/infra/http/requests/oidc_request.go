@@ -0,0 +1,12 @@
+package requests
+
+type OidcCallbackRequest struct {
+	Code  string `json:"code" validate:"required,max=2048"`
+	State string `json:"state" validate:"required,max=100"`
+	// ClientNonce is the one returned when the login was started
+	ClientNonce string `json:"clientNonce" validate:"required,max=100"`
+}
+
+func (r OidcCallbackRequest) ToDomainModel() (interface{}, error) {
+	return r.Code, nil
+}
@@ -0,0 +1,36 @@
+package requests
+
+import (
+	"errors"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+)
+
+type PersonalAccessTokenRequest struct {
+	Name      string   `json:"name" validate:"required,max=100"`
+	Scopes    []string `json:"scopes" validate:"required,min=1,unique,dive,oneof=tasks:read tasks:write users:read"`
+	ExpiresAt *int64   `json:"expiresAt"`
+}
+
+func (r PersonalAccessTokenRequest) ToDomainModel() (interface{}, error) {
+	var expiresAt *time.Time
+	if r.ExpiresAt != nil {
+		exp := time.Unix(*r.ExpiresAt, 0)
+		if exp.Before(time.Now()) {
+			return nil, errors.New("expiresAt must be in the future")
+		}
+		expiresAt = &exp
+	}
+
+	scopes := make([]domain.Scope, len(r.Scopes))
+	for i, s := range r.Scopes {
+		scopes[i] = domain.Scope(s)
+	}
+
+	return domain.PersonalAccessToken{
+		Name:      r.Name,
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	}, nil
+}
@@ -0,0 +1,27 @@
+package requests
+
+import (
+	"errors"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+)
+
+type PublicLinkRequest struct {
+	ExpiresAt *int64 `json:"expiresAt"`
+}
+
+func (r PublicLinkRequest) ToDomainModel() (interface{}, error) {
+	var expiresAt *time.Time
+	if r.ExpiresAt != nil {
+		exp := time.Unix(*r.ExpiresAt, 0)
+		if exp.Before(time.Now()) {
+			return nil, errors.New("expiresAt must be in the future")
+		}
+		expiresAt = &exp
+	}
+
+	return domain.PublicLink{
+		ExpiresAt: expiresAt,
+	}, nil
+}
@@ -0,0 +1,186 @@
+package requests
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+)
+
+const maxNameLength = 40
+
+var ErrScimInvalidPatch = errors.New("invalid patch operation")
+
+type ScimNameRequest struct {
+	GivenName  string `json:"givenName" validate:"max=40"`
+	FamilyName string `json:"familyName" validate:"max=40"`
+}
+
+type ScimEmailRequest struct {
+	Value   string `json:"value"`
+	Primary bool   `json:"primary"`
+}
+
+type ScimUserRequest struct {
+	UserName string             `json:"userName" validate:"required,email"`
+	Name     ScimNameRequest    `json:"name"`
+	Emails   []ScimEmailRequest `json:"emails"`
+	// Active is true when it is missing
+	Active *bool `json:"active"`
+}
+
+type ScimPatchOperation struct {
+	Op    string          `json:"op" validate:"required"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"`
+}
+
+type ScimPatchRequest struct {
+	Operations []ScimPatchOperation `json:"Operations" validate:"required,min=1,dive"`
+}
+
+// ToDomainModel uses userName as the email, the emails list is informational
+// because the app has one email which is also the login
+func (r ScimUserRequest) ToDomainModel() (interface{}, error) {
+	firstName := r.Name.GivenName
+	if firstName == "" {
+		firstName, _, _ = strings.Cut(r.UserName, "@")
+		if len(firstName) > maxNameLength {
+			firstName = firstName[:maxNameLength]
+		}
+	}
+
+	return domain.User{
+		Email:      r.UserName,
+		FirstName:  firstName,
+		SecondName: r.Name.FamilyName,
+	}, nil
+}
+
+func (r ScimUserRequest) IsActive() bool {
+	return r.Active == nil || *r.Active
+}
+
+func (r ScimPatchRequest) ToDomainModel() (interface{}, error) {
+	return r, nil
+}
+
+// Apply runs the operations on the current state of the user and validates the result.
+// Both forms are accepted: with a path, and without one where the value holds the attributes
+func (r ScimPatchRequest) Apply(u ScimUserRequest) (ScimUserRequest, error) {
+	for _, op := range r.Operations {
+		var err error
+		switch strings.ToLower(op.Op) {
+		case "add", "replace":
+			if op.Path == "" {
+				err = u.setAll(op.Value)
+			} else {
+				err = u.set(op.Path, op.Value)
+			}
+		case "remove":
+			err = u.remove(op.Path)
+		default:
+			err = fmt.Errorf("%w: unknown op %q", ErrScimInvalidPatch, op.Op)
+		}
+		if err != nil {
+			return ScimUserRequest{}, err
+		}
+	}
+
+	err := v.Struct(u)
+	if err != nil {
+		return ScimUserRequest{}, err
+	}
+
+	return u, nil
+}
+
+func (u *ScimUserRequest) setAll(raw json.RawMessage) error {
+	var attrs map[string]json.RawMessage
+	err := json.Unmarshal(raw, &attrs)
+	if err != nil {
+		return fmt.Errorf("%w: value must be an object when there is no path", ErrScimInvalidPatch)
+	}
+
+	for path, value := range attrs {
+		err = u.set(path, value)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (u *ScimUserRequest) set(path string, raw json.RawMessage) error {
+	var err error
+	switch p := strings.ToLower(path); {
+	case p == "username":
+		err = json.Unmarshal(raw, &u.UserName)
+	case p == "active":
+		u.Active, err = parseBool(raw)
+	case p == "name":
+		err = json.Unmarshal(raw, &u.Name)
+	case p == "name.givenname":
+		err = json.Unmarshal(raw, &u.Name.GivenName)
+	case p == "name.familyname":
+		err = json.Unmarshal(raw, &u.Name.FamilyName)
+	case p == "emails":
+		err = json.Unmarshal(raw, &u.Emails)
+	// e.g. emails[type eq "work"].value, there is a single email so the filter isn't checked
+	case strings.HasPrefix(p, "emails[") && strings.HasSuffix(p, "].value"):
+		var value string
+		err = json.Unmarshal(raw, &value)
+		u.Emails = []ScimEmailRequest{{Value: value, Primary: true}}
+	case p == "schemas" || p == "externalid" || p == "displayname":
+		// accepted and ignored, these attributes aren't stored
+	default:
+		return fmt.Errorf("%w: unsupported path %q", ErrScimInvalidPatch, path)
+	}
+
+	if err != nil {
+		return fmt.Errorf("%w: invalid value for %q", ErrScimInvalidPatch, path)
+	}
+	return nil
+}
+
+func (u *ScimUserRequest) remove(path string) error {
+	switch strings.ToLower(path) {
+	case "name.givenname":
+		u.Name.GivenName = ""
+	case "name.familyname":
+		u.Name.FamilyName = ""
+	case "name":
+		u.Name = ScimNameRequest{}
+	case "emails":
+		u.Emails = nil
+	default:
+		return fmt.Errorf("%w: %q can't be removed", ErrScimInvalidPatch, path)
+	}
+	return nil
+}
+
+// parseBool accepts "True"/"False" strings as well, some identity providers send them
+func parseBool(raw json.RawMessage) (*bool, error) {
+	var b bool
+	err := json.Unmarshal(raw, &b)
+	if err == nil {
+		return &b, nil
+	}
+
+	var s string
+	err = json.Unmarshal(raw, &s)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(s) {
+	case "true":
+		b = true
+	case "false":
+		b = false
+	default:
+		return nil, fmt.Errorf("invalid boolean %q", s)
+	}
+	return &b, nil
+}
@@ -10,6 +10,11 @@ type TaskRequest struct {
 	Title       string  `json:"title" validate:"required"`
 	Description *string `json:"description"`
 	Date        *int64  `json:"date"`
+	WorkspaceId *uint64 `json:"workspaceId"`
+}
+
+type AssignTaskRequest struct {
+	AssigneeId uint64 `json:"assigneeId" validate:"required"`
 }
 
 func (r TaskRequest) ToDomainModel() (interface{}, error) {
@@ -22,5 +27,12 @@ func (r TaskRequest) ToDomainModel() (interface{}, error) {
 		Title:       r.Title,
 		Description: r.Description,
 		Date:        &date,
+		WorkspaceId: r.WorkspaceId,
+	}, nil
+}
+
+func (r AssignTaskRequest) ToDomainModel() (interface{}, error) {
+	return domain.Task{
+		AssigneeId: &r.AssigneeId,
 	}, nil
 }
@@ -0,0 +1,16 @@
+package requests
+
+import (
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+)
+
+type ShareTaskRequest struct {
+	Email      string `json:"email" validate:"required,email"`
+	Permission string `json:"permission" validate:"required,oneof=VIEWER EDITOR OWNER"`
+}
+
+func (r ShareTaskRequest) ToDomainModel() (interface{}, error) {
+	return domain.TaskShare{
+		Permission: domain.Permission(r.Permission),
+	}, nil
+}
@@ -0,0 +1,18 @@
+package requests
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required,max=20"`
+}
+
+type LoginMfaRequest struct {
+	MfaToken string `json:"mfaToken" validate:"required"`
+	Code     string `json:"code" validate:"required,max=20"`
+}
+
+func (r TwoFactorCodeRequest) ToDomainModel() (interface{}, error) {
+	return r.Code, nil
+}
+
+func (r LoginMfaRequest) ToDomainModel() (interface{}, error) {
+	return r.Code, nil
+}
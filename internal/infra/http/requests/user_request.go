@@ -7,19 +7,41 @@ import (
 type RegisterRequest struct {
 	FirstName  string `json:"firstName" validate:"required,gte=1,max=40"`
 	SecondName string `json:"secondName" validate:"required,gte=1,max=40"`
-	Email      string `json:"email" validate:"required,email"`
-	Password   string `json:"password" validate:"required,gte=4,max=20"`
+	Email      string `json:"email" validate:"required,email,max=255"`
+	Password   string `json:"password" validate:"required,gte=4,max=256"`
 }
 
 type LoginRequest struct {
 	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password"  validate:"required,gte=4"`
+	Password string `json:"password"  validate:"required,gte=4,max=256"`
+}
+
+type ChangePasswordRequest struct {
+	OldPassword string `json:"oldPassword" validate:"required"`
+	NewPassword string `json:"newPassword" validate:"required,gte=4,max=256,nefield=OldPassword"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,gte=4,max=256"`
+}
+
+type RestoreAccountRequest struct {
+	Token string `json:"token" validate:"required,max=300"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken" validate:"required"`
 }
 
 type UpdateUserRequest struct {
 	FirstName  string `json:"firstName" validate:"required,gte=1,max=40"`
 	SecondName string `json:"secondName" validate:"required,gte=1,max=40"`
-	Email      string `json:"email" validate:"required,email"`
+	Email      string `json:"email" validate:"required,email,max=255"`
 }
 
 func (r RegisterRequest) ToDomainModel() (interface{}, error) {
@@ -45,3 +67,32 @@ func (r LoginRequest) ToDomainModel() (interface{}, error) {
 		Email:    r.Email,
 	}, nil
 }
+
+func (r RefreshRequest) ToDomainModel() (interface{}, error) {
+	return domain.AuthTokens{
+		RefreshToken: r.RefreshToken,
+	}, nil
+}
+
+func (r ChangePasswordRequest) ToDomainModel() (interface{}, error) {
+	return domain.ChangePassword{
+		OldPassword: r.OldPassword,
+		NewPassword: r.NewPassword,
+	}, nil
+}
+
+func (r ForgotPasswordRequest) ToDomainModel() (interface{}, error) {
+	return domain.User{
+		Email: r.Email,
+	}, nil
+}
+
+func (r RestoreAccountRequest) ToDomainModel() (interface{}, error) {
+	return r.Token, nil
+}
+
+func (r ResetPasswordRequest) ToDomainModel() (interface{}, error) {
+	return domain.User{
+		Password: r.Password,
+	}, nil
+}
@@ -0,0 +1,37 @@
+package requests
+
+import (
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+)
+
+type WorkspaceRequest struct {
+	Name string `json:"name" validate:"required,gte=1,max=50"`
+}
+
+type WorkspaceMemberRequest struct {
+	Role string `json:"role" validate:"required,oneof=ADMIN MEMBER GUEST"`
+}
+
+type WorkspaceInvitationRequest struct {
+	Email string `json:"email" validate:"required,email,max=255"`
+	Role  string `json:"role" validate:"required,oneof=ADMIN MEMBER GUEST"`
+}
+
+func (r WorkspaceRequest) ToDomainModel() (interface{}, error) {
+	return domain.Workspace{
+		Name: r.Name,
+	}, nil
+}
+
+func (r WorkspaceMemberRequest) ToDomainModel() (interface{}, error) {
+	return domain.WorkspaceMember{
+		Role: domain.WorkspaceRole(r.Role),
+	}, nil
+}
+
+func (r WorkspaceInvitationRequest) ToDomainModel() (interface{}, error) {
+	return domain.WorkspaceInvitation{
+		Email: r.Email,
+		Role:  domain.WorkspaceRole(r.Role),
+	}, nil
+}
@@ -0,0 +1,30 @@
+package resources
+
+import (
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/google/uuid"
+)
+
+// ImpersonationDto carries no refresh token, the session ends at ExpiresAt
+type ImpersonationDto struct {
+	Token            string    `json:"token"`
+	SessionUUID      uuid.UUID `json:"sessionUuid"`
+	ActorId          uint64    `json:"actorId"`
+	AllowDestructive bool      `json:"allowDestructive"`
+	ExpiresAt        time.Time `json:"expiresAt"`
+	User             UserDto   `json:"user"`
+}
+
+func (d ImpersonationDto) DomainToDto(token string, sess domain.Session, user domain.User) ImpersonationDto {
+	var userDto UserDto
+	return ImpersonationDto{
+		Token:            token,
+		SessionUUID:      sess.UUID,
+		ActorId:          *sess.ActorId,
+		AllowDestructive: sess.AllowDestructive,
+		ExpiresAt:        sess.ExpiresAt,
+		User:             userDto.DomainToDto(user),
+	}
+}
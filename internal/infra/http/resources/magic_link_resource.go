@@ -0,0 +1,7 @@
+package resources
+
+type MagicLinkDto struct {
+	Message string `json:"message"`
+	// Nonce has to be kept by the client and sent back with the token from the email
+	Nonce string `json:"nonce"`
+}
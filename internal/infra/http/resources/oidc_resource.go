@@ -0,0 +1,7 @@
+package resources
+
+type OidcAuthorizationDto struct {
+	AuthorizationUrl string `json:"authorizationUrl"`
+	// ClientNonce has to be kept by the client and sent back with the code and state
+	ClientNonce string `json:"clientNonce"`
+}
@@ -0,0 +1,41 @@
+package resources
+
+import (
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+)
+
+type PersonalAccessTokenDto struct {
+	Id          uint64         `json:"id"`
+	Name        string         `json:"name"`
+	Token       string         `json:"token,omitempty"`
+	Scopes      []domain.Scope `json:"scopes"`
+	Active      bool           `json:"active"`
+	ExpiresAt   *time.Time     `json:"expiresAt,omitempty"`
+	LastUsedAt  *time.Time     `json:"lastUsedAt,omitempty"`
+	RevokedDate *time.Time     `json:"revokedDate,omitempty"`
+	CreatedDate time.Time      `json:"createdDate"`
+}
+
+func (d PersonalAccessTokenDto) DomainToDto(t domain.PersonalAccessToken) PersonalAccessTokenDto {
+	return PersonalAccessTokenDto{
+		Id:          t.Id,
+		Name:        t.Name,
+		Token:       t.Token,
+		Scopes:      t.Scopes,
+		Active:      t.IsActive(),
+		ExpiresAt:   t.ExpiresAt,
+		LastUsedAt:  t.LastUsedAt,
+		RevokedDate: t.RevokedDate,
+		CreatedDate: t.CreatedDate,
+	}
+}
+
+func (d PersonalAccessTokenDto) DomainToDtoCollection(ts []domain.PersonalAccessToken) []PersonalAccessTokenDto {
+	result := make([]PersonalAccessTokenDto, len(ts))
+	for i, t := range ts {
+		result[i] = d.DomainToDto(t)
+	}
+	return result
+}
@@ -0,0 +1,90 @@
+package resources
+
+import (
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+)
+
+const publicPrefix = "/api/v1/public"
+
+type PublicLinkDto struct {
+	Id             uint64     `json:"id"`
+	TaskId         *uint64    `json:"taskId,omitempty"`
+	WorkspaceId    *uint64    `json:"workspaceId,omitempty"`
+	Url            string     `json:"url,omitempty"`
+	Active         bool       `json:"active"`
+	ExpiresAt      *time.Time `json:"expiresAt,omitempty"`
+	RevokedDate    *time.Time `json:"revokedDate,omitempty"`
+	AccessCount    uint64     `json:"accessCount"`
+	LastAccessedAt *time.Time `json:"lastAccessedAt,omitempty"`
+	CreatedDate    time.Time  `json:"createdDate"`
+}
+
+type PublicTaskDto struct {
+	Title       string            `json:"title"`
+	Description *string           `json:"description,omitempty"`
+	Date        *time.Time        `json:"date,omitempty"`
+	Status      domain.TaskStatus `json:"status"`
+}
+
+type PublicListDto struct {
+	Name  string          `json:"name"`
+	Tasks []PublicTaskDto `json:"tasks"`
+}
+
+func (d PublicLinkDto) DomainToDto(l domain.PublicLink) PublicLinkDto {
+	// the url can only be rendered right after creation, when the raw token is known
+	var url string
+	if l.Token != "" {
+		if l.TaskId != nil {
+			url = publicPrefix + "/tasks/" + l.Token
+		} else {
+			url = publicPrefix + "/lists/" + l.Token
+		}
+	}
+
+	return PublicLinkDto{
+		Id:             l.Id,
+		TaskId:         l.TaskId,
+		WorkspaceId:    l.WorkspaceId,
+		Url:            url,
+		Active:         l.IsActive(),
+		ExpiresAt:      l.ExpiresAt,
+		RevokedDate:    l.RevokedDate,
+		AccessCount:    l.AccessCount,
+		LastAccessedAt: l.LastAccessedAt,
+		CreatedDate:    l.CreatedDate,
+	}
+}
+
+func (d PublicLinkDto) DomainToDtoCollection(ls []domain.PublicLink) []PublicLinkDto {
+	linksDto := make([]PublicLinkDto, len(ls))
+	for i, l := range ls {
+		linksDto[i] = d.DomainToDto(l)
+	}
+
+	return linksDto
+}
+
+func (d PublicTaskDto) DomainToDto(t domain.Task) PublicTaskDto {
+	return PublicTaskDto{
+		Title:       t.Title,
+		Description: t.Description,
+		Date:        t.Date,
+		Status:      t.Status,
+	}
+}
+
+func (d PublicListDto) DomainToDto(w domain.Workspace, ts []domain.Task) PublicListDto {
+	var taskDto PublicTaskDto
+	tasksDto := make([]PublicTaskDto, len(ts))
+	for i, t := range ts {
+		tasksDto[i] = taskDto.DomainToDto(t)
+	}
+
+	return PublicListDto{
+		Name:  w.Name,
+		Tasks: tasksDto,
+	}
+}
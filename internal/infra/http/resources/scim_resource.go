@@ -0,0 +1,96 @@
+package resources
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+)
+
+const (
+	ScimUserSchema         = "urn:ietf:params:scim:schemas:core:2.0:User"
+	ScimListResponseSchema = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	ScimErrorSchema        = "urn:ietf:params:scim:api:messages:2.0:Error"
+)
+
+type ScimNameDto struct {
+	GivenName  string `json:"givenName"`
+	FamilyName string `json:"familyName"`
+	Formatted  string `json:"formatted"`
+}
+
+type ScimEmailDto struct {
+	Value   string `json:"value"`
+	Type    string `json:"type"`
+	Primary bool   `json:"primary"`
+}
+
+type ScimMetaDto struct {
+	ResourceType string    `json:"resourceType"`
+	Created      time.Time `json:"created"`
+	LastModified time.Time `json:"lastModified"`
+	Location     string    `json:"location"`
+}
+
+type ScimUserDto struct {
+	Schemas  []string       `json:"schemas"`
+	Id       string         `json:"id"`
+	UserName string         `json:"userName"`
+	Name     ScimNameDto    `json:"name"`
+	Emails   []ScimEmailDto `json:"emails"`
+	Active   bool           `json:"active"`
+	Meta     ScimMetaDto    `json:"meta"`
+}
+
+type ScimListDto struct {
+	Schemas      []string      `json:"schemas"`
+	TotalResults uint64        `json:"totalResults"`
+	StartIndex   uint64        `json:"startIndex"`
+	ItemsPerPage int           `json:"itemsPerPage"`
+	Resources    []ScimUserDto `json:"Resources"`
+}
+
+type ScimErrorDto struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail"`
+}
+
+// DomainToDto needs the base URL of the SCIM API for meta.location
+func (d ScimUserDto) DomainToDto(u domain.User, baseUrl string) ScimUserDto {
+	id := strconv.FormatUint(u.Id, 10)
+	return ScimUserDto{
+		Schemas:  []string{ScimUserSchema},
+		Id:       id,
+		UserName: u.Email,
+		Name: ScimNameDto{
+			GivenName:  u.FirstName,
+			FamilyName: u.SecondName,
+			Formatted:  u.FirstName + " " + u.SecondName,
+		},
+		Emails: []ScimEmailDto{{Value: u.Email, Type: "work", Primary: true}},
+		Active: u.DeletedDate == nil,
+		Meta: ScimMetaDto{
+			ResourceType: "User",
+			Created:      u.CreatedDate,
+			LastModified: u.UpdatedDate,
+			Location:     baseUrl + "/Users/" + id,
+		},
+	}
+}
+
+func (d ScimListDto) DomainToDto(users domain.Users, startIndex uint64, baseUrl string) ScimListDto {
+	resources := make([]ScimUserDto, len(users.Items))
+	for i, u := range users.Items {
+		resources[i] = ScimUserDto{}.DomainToDto(u, baseUrl)
+	}
+
+	return ScimListDto{
+		Schemas:      []string{ScimListResponseSchema},
+		TotalResults: users.Total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	}
+}
@@ -0,0 +1,58 @@
+package resources
+
+import (
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/google/uuid"
+)
+
+type SecurityEventDto struct {
+	Id          uint64     `json:"id"`
+	UserId      *uint64    `json:"userId,omitempty"`
+	Type        string     `json:"type"`
+	Email       string     `json:"email,omitempty"`
+	Ip          string     `json:"ip"`
+	UserAgent   string     `json:"userAgent"`
+	SessionUUID *uuid.UUID `json:"sessionUuid,omitempty"`
+	Details     string     `json:"details,omitempty"`
+	CreatedDate time.Time  `json:"createdDate"`
+}
+
+type SecurityEventsDto struct {
+	Items []SecurityEventDto `json:"items"`
+	Total uint64             `json:"total"`
+	Pages uint               `json:"pages"`
+}
+
+func (d SecurityEventDto) DomainToDto(e domain.SecurityEvent) SecurityEventDto {
+	return SecurityEventDto{
+		Id:          e.Id,
+		UserId:      e.UserId,
+		Type:        string(e.Type),
+		Email:       e.Email,
+		Ip:          e.Ip,
+		UserAgent:   e.UserAgent,
+		SessionUUID: e.SessionUUID,
+		Details:     e.Details,
+		CreatedDate: e.CreatedDate,
+	}
+}
+
+func (d SecurityEventDto) DomainToDtoCollection(es []domain.SecurityEvent) []SecurityEventDto {
+	eventsDto := make([]SecurityEventDto, len(es))
+	for i, e := range es {
+		eventsDto[i] = d.DomainToDto(e)
+	}
+
+	return eventsDto
+}
+
+func (d SecurityEventsDto) DomainToDto(es domain.SecurityEvents) SecurityEventsDto {
+	var eventDto SecurityEventDto
+	return SecurityEventsDto{
+		Items: eventDto.DomainToDtoCollection(es.Items),
+		Total: es.Total,
+		Pages: es.Pages,
+	}
+}
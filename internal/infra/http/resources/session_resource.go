@@ -0,0 +1,42 @@
+package resources
+
+import (
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+	"github.com/google/uuid"
+)
+
+type SessionDto struct {
+	UUID        uuid.UUID  `json:"uuid"`
+	UserAgent   string     `json:"userAgent"`
+	Ip          string     `json:"ip"`
+	Current     bool       `json:"current"`
+	CreatedDate time.Time  `json:"createdDate"`
+	LastSeenAt  *time.Time `json:"lastSeenAt,omitempty"`
+	ExpiresAt   time.Time  `json:"expiresAt"`
+	// Impersonated marks sessions opened by an admin on the user's behalf
+	Impersonated bool `json:"impersonated"`
+}
+
+func (d SessionDto) DomainToDto(s domain.Session, current domain.Session) SessionDto {
+	return SessionDto{
+		UUID:         s.UUID,
+		UserAgent:    s.UserAgent,
+		Ip:           s.Ip,
+		Current:      s.UUID == current.UUID,
+		CreatedDate:  s.CreatedDate,
+		LastSeenAt:   s.LastSeenAt,
+		ExpiresAt:    s.ExpiresAt,
+		Impersonated: s.IsImpersonation(),
+	}
+}
+
+func (d SessionDto) DomainToDtoCollection(ss []domain.Session, current domain.Session) []SessionDto {
+	sessionsDto := make([]SessionDto, len(ss))
+	for i, s := range ss {
+		sessionsDto[i] = d.DomainToDto(s, current)
+	}
+
+	return sessionsDto
+}
@@ -9,16 +9,44 @@ import (
 type TaskDto struct {
 	Id          uint64            `json:"id"`
 	UserId      uint64            `json:"userId"`
+	AssigneeId  *uint64           `json:"assigneeId,omitempty"`
+	Assignee    *AssigneeDto      `json:"assignee,omitempty"`
 	Title       string            `json:"title"`
 	Description *string           `json:"description,omitempty"`
 	Date        *time.Time        `json:"date,omitempty"`
 	Status      domain.TaskStatus `json:"status"`
 }
 
+// AssigneeDto is the public part of a user, the email and account state stay private
+type AssigneeDto struct {
+	Id         uint64         `json:"id"`
+	FirstName  string         `json:"firstName"`
+	SecondName string         `json:"secondName"`
+	Avatar     map[int]string `json:"avatar,omitempty"`
+}
+
+func (d AssigneeDto) DomainToDto(user domain.User) AssigneeDto {
+	u := UserDto{}.DomainToDto(user)
+	return AssigneeDto{
+		Id:         u.Id,
+		FirstName:  u.FirstName,
+		SecondName: u.SecondName,
+		Avatar:     u.Avatar,
+	}
+}
+
 func (d TaskDto) DomainToDto(t domain.Task) TaskDto {
+	var assignee *AssigneeDto
+	if t.Assignee != nil {
+		assigneeDto := AssigneeDto{}.DomainToDto(*t.Assignee)
+		assignee = &assigneeDto
+	}
+
 	return TaskDto{
 		Id:          t.Id,
 		UserId:      t.UserId,
+		AssigneeId:  t.AssigneeId,
+		Assignee:    assignee,
 		Title:       t.Title,
 		Description: t.Description,
 		Date:        t.Date,
@@ -0,0 +1,34 @@
+package resources
+
+import (
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+)
+
+type TaskShareDto struct {
+	Id          uint64            `json:"id"`
+	TaskId      uint64            `json:"taskId"`
+	UserId      uint64            `json:"userId"`
+	Permission  domain.Permission `json:"permission"`
+	CreatedDate time.Time         `json:"createdDate"`
+}
+
+func (d TaskShareDto) DomainToDto(s domain.TaskShare) TaskShareDto {
+	return TaskShareDto{
+		Id:          s.Id,
+		TaskId:      s.TaskId,
+		UserId:      s.UserId,
+		Permission:  s.Permission,
+		CreatedDate: s.CreatedDate,
+	}
+}
+
+func (d TaskShareDto) DomainToDtoCollection(ss []domain.TaskShare) []TaskShareDto {
+	sharesDto := make([]TaskShareDto, len(ss))
+	for i, s := range ss {
+		sharesDto[i] = d.DomainToDto(s)
+	}
+
+	return sharesDto
+}
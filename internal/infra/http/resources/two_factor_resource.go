@@ -0,0 +1,30 @@
+package resources
+
+import (
+	"encoding/base64"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+)
+
+type TwoFactorEnrollmentDto struct {
+	Secret string `json:"secret"`
+	Uri    string `json:"uri"`
+	QrCode string `json:"qrCode"`
+}
+
+type RecoveryCodesDto struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+type MfaChallengeDto struct {
+	MfaRequired bool   `json:"mfaRequired"`
+	MfaToken    string `json:"mfaToken"`
+}
+
+func (d TwoFactorEnrollmentDto) DomainToDto(e domain.TwoFactorEnrollment) TwoFactorEnrollmentDto {
+	return TwoFactorEnrollmentDto{
+		Secret: e.Secret,
+		Uri:    e.Uri,
+		QrCode: "data:image/png;base64," + base64.StdEncoding.EncodeToString(e.QrCode),
+	}
+}
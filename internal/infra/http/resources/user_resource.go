@@ -1,18 +1,30 @@
 package resources
 
-import "github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+import (
+	"path"
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+)
+
+const staticPrefix = "/static"
 
 type UserDto struct {
-	Id         uint64      `json:"id"`
-	FirstName  string      `json:"firstName"`
-	SecondName string      `json:"secondName"`
-	Email      string      `json:"email"`
-	Role       domain.Role `json:"role,omitempty"`
+	Id            uint64         `json:"id"`
+	FirstName     string         `json:"firstName"`
+	SecondName    string         `json:"secondName"`
+	Email         string         `json:"email"`
+	EmailVerified bool           `json:"emailVerified"`
+	Role          domain.Role    `json:"role,omitempty"`
+	Avatar        map[int]string `json:"avatar,omitempty"`
+	SuspendedAt   *time.Time     `json:"suspendedAt,omitempty"`
+	DeletedDate   *time.Time     `json:"deletedDate,omitempty"`
 }
 
 type AuthDto struct {
-	Token string  `json:"token"`
-	User  UserDto `json:"user"`
+	Token        string  `json:"token,omitempty"`
+	RefreshToken string  `json:"refreshToken,omitempty"`
+	User         UserDto `json:"user"`
 }
 
 type UsersDto struct {
@@ -22,12 +34,24 @@ type UsersDto struct {
 }
 
 func (d UserDto) DomainToDto(user domain.User) UserDto {
+	var avatar map[int]string
+	if user.Avatar != nil {
+		avatar = make(map[int]string, len(domain.AvatarSizes))
+		for _, size := range domain.AvatarSizes {
+			avatar[size] = path.Join(staticPrefix, domain.AvatarPath(*user.Avatar, size))
+		}
+	}
+
 	return UserDto{
-		Id:         user.Id,
-		FirstName:  user.FirstName,
-		SecondName: user.SecondName,
-		Email:      user.Email,
-		Role:       user.Role,
+		Id:            user.Id,
+		FirstName:     user.FirstName,
+		SecondName:    user.SecondName,
+		Email:         user.Email,
+		EmailVerified: user.IsEmailVerified(),
+		Role:          user.Role,
+		Avatar:        avatar,
+		SuspendedAt:   user.SuspendedAt,
+		DeletedDate:   user.DeletedDate,
 	}
 }
 
@@ -39,10 +63,20 @@ func (d UserDto) DomainToDtoCollection(users []domain.User) []UserDto {
 	return result
 }
 
-func (d AuthDto) DomainToDto(token string, user domain.User) AuthDto {
+func (d UsersDto) DomainToDto(users domain.Users) UsersDto {
+	var userDto UserDto
+	return UsersDto{
+		Items: userDto.DomainToDtoCollection(users.Items),
+		Total: users.Total,
+		Pages: users.Pages,
+	}
+}
+
+func (d AuthDto) DomainToDto(tokens domain.AuthTokens, user domain.User) AuthDto {
 	var userDto UserDto
 	return AuthDto{
-		Token: token,
-		User:  userDto.DomainToDto(user),
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		User:         userDto.DomainToDto(user),
 	}
 }
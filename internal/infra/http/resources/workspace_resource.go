@@ -0,0 +1,82 @@
+package resources
+
+import (
+	"time"
+
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
+)
+
+type WorkspaceDto struct {
+	Id          uint64    `json:"id"`
+	Name        string    `json:"name"`
+	OwnerId     uint64    `json:"ownerId"`
+	CreatedDate time.Time `json:"createdDate"`
+}
+
+type WorkspaceMemberDto struct {
+	WorkspaceId uint64               `json:"workspaceId"`
+	UserId      uint64               `json:"userId"`
+	User        *UserDto             `json:"user,omitempty"`
+	Role        domain.WorkspaceRole `json:"role"`
+}
+
+type WorkspaceInvitationDto struct {
+	Id          uint64               `json:"id"`
+	WorkspaceId uint64               `json:"workspaceId"`
+	Email       string               `json:"email"`
+	Role        domain.WorkspaceRole `json:"role"`
+	ExpiresAt   time.Time            `json:"expiresAt"`
+}
+
+func (d WorkspaceDto) DomainToDto(w domain.Workspace) WorkspaceDto {
+	return WorkspaceDto{
+		Id:          w.Id,
+		Name:        w.Name,
+		OwnerId:     w.OwnerId,
+		CreatedDate: w.CreatedDate,
+	}
+}
+
+func (d WorkspaceDto) DomainToDtoCollection(ws []domain.Workspace) []WorkspaceDto {
+	workspacesDto := make([]WorkspaceDto, len(ws))
+	for i, w := range ws {
+		workspacesDto[i] = d.DomainToDto(w)
+	}
+
+	return workspacesDto
+}
+
+func (d WorkspaceMemberDto) DomainToDto(m domain.WorkspaceMember) WorkspaceMemberDto {
+	var user *UserDto
+	if m.User != nil {
+		var userDto UserDto
+		userDto = userDto.DomainToDto(*m.User)
+		user = &userDto
+	}
+
+	return WorkspaceMemberDto{
+		WorkspaceId: m.WorkspaceId,
+		UserId:      m.UserId,
+		User:        user,
+		Role:        m.Role,
+	}
+}
+
+func (d WorkspaceMemberDto) DomainToDtoCollection(ms []domain.WorkspaceMember) []WorkspaceMemberDto {
+	membersDto := make([]WorkspaceMemberDto, len(ms))
+	for i, m := range ms {
+		membersDto[i] = d.DomainToDto(m)
+	}
+
+	return membersDto
+}
+
+func (d WorkspaceInvitationDto) DomainToDto(i domain.WorkspaceInvitation) WorkspaceInvitationDto {
+	return WorkspaceInvitationDto{
+		Id:          i.Id,
+		WorkspaceId: i.WorkspaceId,
+		Email:       i.Email,
+		Role:        i.Role,
+		ExpiresAt:   i.ExpiresAt,
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/BohdanBoriak/boilerplate-go-back/config"
 	"github.com/BohdanBoriak/boilerplate-go-back/config/container"
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/app"
+	"github.com/BohdanBoriak/boilerplate-go-back/internal/domain"
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/controllers"
 	"github.com/BohdanBoriak/boilerplate-go-back/internal/infra/http/middlewares"
 	"github.com/go-chi/chi/v5"
@@ -20,15 +21,16 @@ import (
 )
 
 func Router(cont container.Container) http.Handler {
+	conf := config.GetConfiguration()
 
 	router := chi.NewRouter()
 
-	router.Use(middleware.RedirectSlashes, middleware.Logger, cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"https://*", "http://*", "capacitor://localhost"},
+	router.Use(cont.RealIpMw, middleware.RedirectSlashes, middleware.Logger, cors.Handler(cors.Options{
+		AllowedOrigins:   conf.CorsAllowedOrigins,
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
-		AllowCredentials: false,
+		ExposedHeaders:   []string{"Link", "Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset"},
+		AllowCredentials: conf.AuthCookies,
 		MaxAge:           300,
 	}))
 
@@ -43,24 +45,49 @@ func Router(cont container.Container) http.Handler {
 			// Public routes
 			apiRouter.Group(func(apiRouter chi.Router) {
 				apiRouter.Route("/auth", func(apiRouter chi.Router) {
-					AuthRouter(apiRouter, cont.AuthController, cont.AuthMw)
+					apiRouter.Use(cont.AuthRateLimitMw)
+					AuthRouter(apiRouter, cont.AuthController, cont.ImpersonationController, cont.AuthMw)
+					OidcRouter(apiRouter, cont.OidcController)
+				})
+				apiRouter.Route("/public", func(apiRouter chi.Router) {
+					PublicRouter(apiRouter, cont.PublicLinkController, cont.PublicLinkMw)
 				})
 			})
 
 			// Protected routes
 			apiRouter.Group(func(apiRouter chi.Router) {
-				apiRouter.Use(cont.AuthMw)
+				apiRouter.Use(cont.AuthMw, cont.EmailVerifiedMw, middlewares.ImpersonationReadOnly)
 
-				UserRouter(apiRouter, cont.UserController)
-				TaskRouter(apiRouter, cont.TaskController, cont.TaskService)
+				UserRouter(
+					apiRouter,
+					cont.UserController,
+					cont.TwoFactorController,
+					cont.PersonalAccessTokenController,
+					cont.SecurityEventController,
+					cont.PersonalAccessTokenService,
+				)
+				TaskRouter(apiRouter, cont.TaskController, cont.TaskShareController, cont.PublicLinkController, cont.TaskService, cont.TaskReadRateLimitMw, cont.TaskWriteRateLimitMw)
+				WorkspaceRouter(apiRouter, cont.WorkspaceController, cont.PublicLinkController, cont.WorkspaceService)
+				PublicLinkRouter(apiRouter, cont.PublicLinkController, cont.PublicLinkService)
+				apiRouter.Route("/admin", func(apiRouter chi.Router) {
+					apiRouter.Use(middlewares.SessionOnly, middlewares.RequireRole(domain.AdminRole))
+					AdminRouter(apiRouter, cont.AdminController, cont.ImpersonationController, cont.SecurityEventController, cont.AdminService)
+				})
 				apiRouter.Handle("/*", NotFoundJSON())
 			})
 		})
 	})
 
+	router.Get("/.well-known/jwks.json", cont.JwksController.Find())
+
+	router.Route("/scim/v2", func(scimRouter chi.Router) {
+		scimRouter.Use(cont.ScimAuthMw)
+		ScimRouter(scimRouter, cont.ScimController)
+	})
+
 	router.Get("/static/*", func(w http.ResponseWriter, r *http.Request) {
 		workDir, _ := os.Getwd()
-		filesDir := http.Dir(filepath.Join(workDir, config.GetConfiguration().FileStorageLocation))
+		filesDir := http.Dir(filepath.Join(workDir, conf.FileStorageLocation))
 		rctx := chi.RouteContext(r.Context())
 		pathPrefix := strings.TrimSuffix(rctx.RoutePattern(), "/*")
 		fs := http.StripPrefix(pathPrefix, http.FileServer(filesDir))
@@ -70,7 +97,12 @@ func Router(cont container.Container) http.Handler {
 	return router
 }
 
-func AuthRouter(r chi.Router, ac controllers.AuthController, amw func(http.Handler) http.Handler) {
+func AuthRouter(
+	r chi.Router,
+	ac controllers.AuthController,
+	imc controllers.ImpersonationController,
+	amw func(http.Handler) http.Handler,
+) {
 	r.Route("/", func(apiRouter chi.Router) {
 		apiRouter.Post(
 			"/register",
@@ -80,58 +112,369 @@ func AuthRouter(r chi.Router, ac controllers.AuthController, amw func(http.Handl
 			"/login",
 			ac.Login(),
 		)
-		apiRouter.With(amw).Post(
+		apiRouter.Post(
+			"/login/mfa",
+			ac.LoginMfa(),
+		)
+		apiRouter.Post(
+			"/refresh",
+			ac.Refresh(),
+		)
+		apiRouter.Post(
+			"/magic-link",
+			ac.RequestMagicLink(),
+		)
+		apiRouter.Post(
+			"/magic-link/consume",
+			ac.ConsumeMagicLink(),
+		)
+		apiRouter.Post(
+			"/password/forgot",
+			ac.ForgotPassword(),
+		)
+		apiRouter.Post(
+			"/password/reset",
+			ac.ResetPassword(),
+		)
+		apiRouter.Post(
+			"/account/restore",
+			ac.RestoreAccount(),
+		)
+		apiRouter.Get(
+			"/email/verify",
+			ac.VerifyEmail(),
+		)
+		apiRouter.With(amw, middlewares.SessionOnly, middlewares.ImpersonationReadOnly).Post(
+			"/email/resend",
+			ac.ResendVerification(),
+		)
+		apiRouter.With(amw, middlewares.SessionOnly).Post(
 			"/logout",
 			ac.Logout(),
 		)
+		apiRouter.With(amw, middlewares.SessionOnly).Get(
+			"/sessions",
+			ac.FindSessions(),
+		)
+		apiRouter.With(amw, middlewares.SessionOnly, middlewares.ImpersonationReadOnly).Delete(
+			"/sessions",
+			ac.RevokeOtherSessions(),
+		)
+		apiRouter.With(amw, middlewares.SessionOnly, middlewares.ImpersonationReadOnly).Delete(
+			"/sessions/{uuid}",
+			ac.RevokeSession(),
+		)
+		apiRouter.With(amw, middlewares.SessionOnly).Post(
+			"/impersonation/stop",
+			imc.Stop(),
+		)
 	})
 }
 
-func UserRouter(r chi.Router, uc controllers.UserController) {
-	r.Route("/users", func(apiRouter chi.Router) {
+func OidcRouter(r chi.Router, oc controllers.OidcController) {
+	r.Route("/oidc", func(apiRouter chi.Router) {
+		apiRouter.Get(
+			"/login",
+			oc.Start(),
+		)
+		apiRouter.Post(
+			"/callback",
+			oc.Callback(),
+		)
+	})
+}
+
+func ScimRouter(r chi.Router, sc controllers.ScimController) {
+	r.Route("/Users", func(apiRouter chi.Router) {
 		apiRouter.Get(
 			"/",
-			uc.FindMe(),
+			sc.FindUsers(),
+		)
+		apiRouter.Post(
+			"/",
+			sc.Create(),
+		)
+		apiRouter.Get(
+			"/{userId}",
+			sc.Find(),
 		)
 		apiRouter.Put(
+			"/{userId}",
+			sc.Replace(),
+		)
+		apiRouter.Patch(
+			"/{userId}",
+			sc.Patch(),
+		)
+		apiRouter.Delete(
+			"/{userId}",
+			sc.Delete(),
+		)
+	})
+}
+
+func UserRouter(
+	r chi.Router,
+	uc controllers.UserController,
+	tfc controllers.TwoFactorController,
+	patc controllers.PersonalAccessTokenController,
+	sec controllers.SecurityEventController,
+	pats app.PersonalAccessTokenService,
+) {
+	patpom := middlewares.PathObject("tokenId", controllers.PersonalAccessTokenKey, pats)
+	r.Route("/users", func(apiRouter chi.Router) {
+		apiRouter.With(middlewares.RequireScope(domain.UsersReadScope)).Get(
+			"/",
+			uc.FindMe(),
+		)
+		apiRouter.With(middlewares.SessionOnly, middlewares.NotImpersonated).Put(
 			"/",
 			uc.Update(),
 		)
-		apiRouter.Delete(
+		apiRouter.With(middlewares.SessionOnly).Delete(
 			"/",
 			uc.Delete(),
 		)
+		apiRouter.With(middlewares.SessionOnly, middlewares.NotImpersonated).Put(
+			"/password",
+			uc.ChangePassword(),
+		)
+		apiRouter.With(middlewares.SessionOnly).Put(
+			"/avatar",
+			uc.UploadAvatar(),
+		)
+		apiRouter.With(middlewares.SessionOnly, middlewares.NotImpersonated).Post(
+			"/2fa",
+			tfc.Enroll(),
+		)
+		apiRouter.With(middlewares.SessionOnly, middlewares.NotImpersonated).Post(
+			"/2fa/enable",
+			tfc.Enable(),
+		)
+		apiRouter.With(middlewares.SessionOnly, middlewares.NotImpersonated).Post(
+			"/2fa/disable",
+			tfc.Disable(),
+		)
+		apiRouter.With(middlewares.SessionOnly, middlewares.NotImpersonated).Post(
+			"/2fa/recovery-codes",
+			tfc.RegenerateRecoveryCodes(),
+		)
+		apiRouter.With(middlewares.SessionOnly).Get(
+			"/tokens",
+			patc.FindAll(),
+		)
+		apiRouter.With(middlewares.SessionOnly, middlewares.NotImpersonated).Post(
+			"/tokens",
+			patc.Save(),
+		)
+		apiRouter.With(middlewares.SessionOnly, patpom).Delete(
+			"/tokens/{tokenId}",
+			patc.Revoke(),
+		)
+		apiRouter.With(middlewares.SessionOnly).Get(
+			"/security-events",
+			sec.FindMine(),
+		)
 	})
 }
 
-func TaskRouter(r chi.Router, tc controllers.TaskController, ts app.TaskService) {
+func TaskRouter(
+	r chi.Router,
+	tc controllers.TaskController,
+	tsc controllers.TaskShareController,
+	plc controllers.PublicLinkController,
+	ts app.TaskService,
+	readLimit func(http.Handler) http.Handler,
+	writeLimit func(http.Handler) http.Handler,
+) {
 	tpom := middlewares.PathObject("taskId", controllers.TaskKey, ts)
+	read := chi.Chain(readLimit, middlewares.RequireScope(domain.TasksReadScope)).Handler
+	write := chi.Chain(writeLimit, middlewares.RequireScope(domain.TasksWriteScope)).Handler
 	r.Route("/tasks", func(apiRouter chi.Router) {
-		apiRouter.Post(
+		apiRouter.With(write).Post(
 			"/",
 			tc.Save(),
 		)
-		apiRouter.Get(
+		apiRouter.With(read).Get(
 			"/",
 			tc.FindAll(),
 		)
-		apiRouter.With(tpom).Get(
+		apiRouter.With(read, tpom).Get(
 			"/{taskId}",
 			tc.Find(),
 		)
-		apiRouter.With(tpom).Put(
+		apiRouter.With(write, tpom).Put(
 			"/{taskId}",
 			tc.Update(),
 		)
-		apiRouter.With(tpom).Delete(
+		apiRouter.With(write, tpom).Delete(
 			"/{taskId}",
 			tc.Delete(),
 		)
 		//new endpoint
-		apiRouter.With(tpom).Patch(
+		apiRouter.With(write, tpom).Patch(
 			"/{taskId}/status",
 			tc.UpdateStatus(),
 		)
+		apiRouter.With(write, tpom).Put(
+			"/{taskId}/assignee",
+			tc.Assign(),
+		)
+		apiRouter.With(write, tpom).Delete(
+			"/{taskId}/assignee",
+			tc.Unassign(),
+		)
+		apiRouter.With(read, tpom).Get(
+			"/{taskId}/shares",
+			tsc.FindAll(),
+		)
+		apiRouter.With(write, tpom).Post(
+			"/{taskId}/shares",
+			tsc.Share(),
+		)
+		apiRouter.With(write, tpom).Delete(
+			"/{taskId}/shares/{userId}",
+			tsc.Revoke(),
+		)
+		apiRouter.With(middlewares.SessionOnly, tpom).Get(
+			"/{taskId}/public-links",
+			plc.FindForTask(),
+		)
+		apiRouter.With(middlewares.SessionOnly, tpom).Post(
+			"/{taskId}/public-links",
+			plc.SaveForTask(),
+		)
+	})
+}
+
+func WorkspaceRouter(r chi.Router, wc controllers.WorkspaceController, plc controllers.PublicLinkController, ws app.WorkspaceService) {
+	wpom := middlewares.PathObject("workspaceId", controllers.WorkspaceKey, ws)
+	r.Route("/workspaces", func(apiRouter chi.Router) {
+		apiRouter.Use(middlewares.SessionOnly)
+		apiRouter.Post(
+			"/",
+			wc.Save(),
+		)
+		apiRouter.Get(
+			"/",
+			wc.FindAll(),
+		)
+		apiRouter.Post(
+			"/invitations/{token}/accept",
+			wc.AcceptInvitation(),
+		)
+		apiRouter.With(wpom).Get(
+			"/{workspaceId}",
+			wc.Find(),
+		)
+		apiRouter.With(wpom).Put(
+			"/{workspaceId}",
+			wc.Update(),
+		)
+		apiRouter.With(wpom).Delete(
+			"/{workspaceId}",
+			wc.Delete(),
+		)
+		apiRouter.With(wpom).Get(
+			"/{workspaceId}/members",
+			wc.FindMembers(),
+		)
+		apiRouter.With(wpom).Put(
+			"/{workspaceId}/members/{userId}",
+			wc.UpdateMember(),
+		)
+		apiRouter.With(wpom).Delete(
+			"/{workspaceId}/members/{userId}",
+			wc.RemoveMember(),
+		)
+		apiRouter.With(wpom).Post(
+			"/{workspaceId}/invitations",
+			wc.Invite(),
+		)
+		apiRouter.With(wpom).Get(
+			"/{workspaceId}/public-links",
+			plc.FindForWorkspace(),
+		)
+		apiRouter.With(wpom).Post(
+			"/{workspaceId}/public-links",
+			plc.SaveForWorkspace(),
+		)
+	})
+}
+
+func PublicLinkRouter(r chi.Router, plc controllers.PublicLinkController, pls app.PublicLinkService) {
+	lpom := middlewares.PathObject("linkId", controllers.PublicLinkKey, pls)
+	r.Route("/public-links", func(apiRouter chi.Router) {
+		apiRouter.Use(middlewares.SessionOnly)
+		apiRouter.Get(
+			"/",
+			plc.FindAll(),
+		)
+		apiRouter.With(lpom).Delete(
+			"/{linkId}",
+			plc.Revoke(),
+		)
+	})
+}
+
+func AdminRouter(
+	r chi.Router,
+	adc controllers.AdminController,
+	imc controllers.ImpersonationController,
+	sec controllers.SecurityEventController,
+	as app.AdminService,
+) {
+	upom := middlewares.PathObject("userId", controllers.PathUserKey, as)
+	r.Route("/users", func(apiRouter chi.Router) {
+		apiRouter.Get(
+			"/",
+			adc.FindUsers(),
+		)
+		apiRouter.With(upom).Get(
+			"/{userId}",
+			adc.FindUser(),
+		)
+		apiRouter.With(upom).Delete(
+			"/{userId}",
+			adc.Delete(),
+		)
+		apiRouter.With(upom).Post(
+			"/{userId}/suspend",
+			adc.Suspend(),
+		)
+		apiRouter.With(upom).Post(
+			"/{userId}/restore",
+			adc.Restore(),
+		)
+		apiRouter.With(upom).Post(
+			"/{userId}/logout",
+			adc.ForceLogout(),
+		)
+		apiRouter.With(upom).Put(
+			"/{userId}/role",
+			adc.ChangeRole(),
+		)
+		apiRouter.With(upom).Post(
+			"/{userId}/impersonate",
+			imc.Start(),
+		)
+	})
+	r.Get(
+		"/security-events",
+		sec.FindAll(),
+	)
+}
+
+func PublicRouter(r chi.Router, plc controllers.PublicLinkController, plmw func(http.Handler) http.Handler) {
+	r.Route("/", func(apiRouter chi.Router) {
+		apiRouter.With(plmw).Get(
+			"/tasks/{token}",
+			plc.PublicTask(),
+		)
+		apiRouter.With(plmw).Get(
+			"/lists/{token}",
+			plc.PublicList(),
+		)
 	})
 }
 
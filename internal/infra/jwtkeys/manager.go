@@ -0,0 +1,214 @@
+package jwtkeys
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jws"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+)
+
+var ErrInvalidToken = errors.New("token is invalid")
+
+// Manager signs access tokens with the current key and verifies them
+// with any of the published keys, so a previous key can be kept around
+// until the tokens it signed expire
+type Manager interface {
+	Sign(claims map[string]interface{}) (string, error)
+	Verify(token string) (jwt.Token, error)
+	// PublicKeys returns the JWKS, it is empty for HS256 because a secret can't be published
+	PublicKeys() jwk.Set
+}
+
+type hmacManager struct {
+	secret []byte
+}
+
+// NewHmacManager keeps the legacy shared secret mode
+func NewHmacManager(secret string) Manager {
+	return hmacManager{
+		secret: []byte(secret),
+	}
+}
+
+func (m hmacManager) Sign(claims map[string]interface{}) (string, error) {
+	return sign(claims, jwt.WithKey(jwa.HS256, m.secret))
+}
+
+func (m hmacManager) Verify(token string) (jwt.Token, error) {
+	return verify(token, jwt.WithKey(jwa.HS256, m.secret))
+}
+
+func (m hmacManager) PublicKeys() jwk.Set {
+	return jwk.NewSet()
+}
+
+type keySetManager struct {
+	signingKey jwk.Key
+	publicKeys jwk.Set
+}
+
+// KeyFile is a PEM key, an empty Id is replaced with the RFC 7638 thumbprint of the key
+type KeyFile struct {
+	Id   string
+	Path string
+}
+
+// LoadManager reads a private key for signing and the keys of previous
+// rotations which are still accepted for verification
+func LoadManager(signing KeyFile, verification []KeyFile) (Manager, error) {
+	signingKey, err := loadKey(signing)
+	if err != nil {
+		return nil, err
+	}
+	asymmetric, ok := signingKey.(jwk.AsymmetricKey)
+	if !ok || !asymmetric.IsPrivate() {
+		return nil, fmt.Errorf("%s: a private key is required for signing", signing.Path)
+	}
+
+	set := jwk.NewSet()
+	for _, kf := range verification {
+		key, err := loadKey(kf)
+		if err != nil {
+			return nil, err
+		}
+		err = addPublicKey(set, key)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", kf.Path, err)
+		}
+	}
+	err = addPublicKey(set, signingKey)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", signing.Path, err)
+	}
+
+	return keySetManager{
+		signingKey: signingKey,
+		publicKeys: set,
+	}, nil
+}
+
+func (m keySetManager) Sign(claims map[string]interface{}) (string, error) {
+	// the kid header is taken from the key, verifiers use it to pick the key from the JWKS
+	return sign(claims, jwt.WithKey(m.signingKey.Algorithm(), m.signingKey))
+}
+
+func (m keySetManager) Verify(token string) (jwt.Token, error) {
+	return verify(token, jwt.WithKeySet(m.publicKeys, jws.WithRequireKid(true)))
+}
+
+func (m keySetManager) PublicKeys() jwk.Set {
+	return m.publicKeys
+}
+
+func sign(claims map[string]interface{}, key jwt.SignEncryptParseOption) (string, error) {
+	t := jwt.New()
+	for k, v := range claims {
+		err := t.Set(k, v)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	signed, err := jwt.Sign(t, key)
+	if err != nil {
+		return "", err
+	}
+
+	return string(signed), nil
+}
+
+func verify(token string, key jwt.ParseOption) (jwt.Token, error) {
+	t, err := jwt.ParseString(token, key, jwt.WithValidate(true))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidToken, err)
+	}
+
+	return t, nil
+}
+
+func addPublicKey(set jwk.Set, key jwk.Key) error {
+	public, err := key.PublicKey()
+	if err != nil {
+		return err
+	}
+
+	if _, found := set.LookupKeyID(public.KeyID()); found {
+		return fmt.Errorf("key id %q is used twice", public.KeyID())
+	}
+	return set.AddKey(public)
+}
+
+func loadKey(kf KeyFile) (jwk.Key, error) {
+	content, err := os.ReadFile(kf.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := jwk.ParseKey(content, jwk.WithPEM(true))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", kf.Path, err)
+	}
+
+	alg, err := algorithmOf(key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", kf.Path, err)
+	}
+
+	err = key.Set(jwk.AlgorithmKey, alg)
+	if err != nil {
+		return nil, err
+	}
+	err = key.Set(jwk.KeyUsageKey, jwk.ForSignature)
+	if err != nil {
+		return nil, err
+	}
+
+	if kf.Id != "" {
+		err = key.Set(jwk.KeyIDKey, kf.Id)
+	} else {
+		// the thumbprint is the same for the private key and its public part
+		err = jwk.AssignKeyID(key)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// algorithmOf picks the signature algorithm from the key type
+func algorithmOf(key jwk.Key) (jwa.SignatureAlgorithm, error) {
+	var raw interface{}
+	err := key.Raw(&raw)
+	if err != nil {
+		return "", err
+	}
+
+	switch k := raw.(type) {
+	case *rsa.PrivateKey, *rsa.PublicKey:
+		return jwa.RS256, nil
+	case *ecdsa.PrivateKey:
+		return ecdsaAlgorithm(k.Curve)
+	case *ecdsa.PublicKey:
+		return ecdsaAlgorithm(k.Curve)
+	case ed25519.PrivateKey, ed25519.PublicKey:
+		return jwa.EdDSA, nil
+	default:
+		return "", fmt.Errorf("unsupported key type %T (use RSA, EC P-256 or Ed25519)", raw)
+	}
+}
+
+func ecdsaAlgorithm(curve elliptic.Curve) (jwa.SignatureAlgorithm, error) {
+	if curve != elliptic.P256() {
+		return "", fmt.Errorf("unsupported curve %s (use P-256)", curve.Params().Name)
+	}
+	return jwa.ES256, nil
+}
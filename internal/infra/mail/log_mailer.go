@@ -0,0 +1,46 @@
+package mail
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// logMailer is meant for local development: it writes every message
+// to the application log and appends it to a file instead of sending it
+type logMailer struct {
+	file string
+	mu   *sync.Mutex
+}
+
+func NewLogMailer(location string) Mailer {
+	return logMailer{
+		file: filepath.Join(location, "mail.log"),
+		mu:   &sync.Mutex{},
+	}
+}
+
+func (m logMailer) Send(msg Message) error {
+	entry := fmt.Sprintf("Date: %s\nTo: %s\nSubject: %s\n\n%s\n\n", time.Now().Format(time.RFC1123Z), msg.To, msg.Subject, msg.Body)
+	log.Printf("Mailer: message to %s\n%s", msg.To, entry)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	err := os.MkdirAll(filepath.Dir(m.file), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(m.file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.WriteString(entry)
+	return err
+}
@@ -0,0 +1,286 @@
+package oidc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jws"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+)
+
+const clockSkew = time.Minute
+
+var (
+	ErrInvalidIdToken = errors.New("id token is invalid")
+	ErrExchangeFailed = errors.New("authorization code exchange failed")
+)
+
+type Config struct {
+	Issuer       string
+	ClientId     string
+	ClientSecret string
+	RedirectUrl  string
+	Scopes       []string
+}
+
+// Claims are the ID token claims used to find or create the user
+type Claims struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	GivenName     string
+	FamilyName    string
+}
+
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksUri               string `json:"jwks_uri"`
+}
+
+// Provider talks to one OpenID provider. Discovery and keys are loaded
+// on first use, so the API starts even when the provider is unreachable
+type Provider struct {
+	config Config
+	client *http.Client
+
+	mu   sync.Mutex
+	meta *metadata
+	keys jwk.Set
+}
+
+func NewProvider(c Config, client *http.Client) *Provider {
+	return &Provider{
+		config: c,
+		client: client,
+	}
+}
+
+// AuthCodeUrl builds the URL of the provider's login page, the code is
+// bound to the verifier behind codeChallenge (PKCE, S256)
+func (p *Provider) AuthCodeUrl(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	meta, err := p.metadata(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(meta.AuthorizationEndpoint)
+	if err != nil {
+		return "", err
+	}
+
+	q := u.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", p.config.ClientId)
+	q.Set("redirect_uri", p.config.RedirectUrl)
+	q.Set("scope", strings.Join(p.config.Scopes, " "))
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", codeChallenge)
+	q.Set("code_challenge_method", "S256")
+	u.RawQuery = q.Encode()
+
+	return u.String(), nil
+}
+
+// Exchange trades the authorization code for tokens and returns the raw ID token
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier string) (string, error) {
+	meta, err := p.metadata(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.config.RedirectUrl)
+	form.Set("code_verifier", codeVerifier)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	// client_secret_basic is the default authentication method of the token endpoint
+	req.SetBasicAuth(url.QueryEscape(p.config.ClientId), url.QueryEscape(p.config.ClientSecret))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%w: %s %s", ErrExchangeFailed, resp.Status, body)
+	}
+
+	var tokens struct {
+		IdToken string `json:"id_token"`
+	}
+	err = json.Unmarshal(body, &tokens)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrExchangeFailed, err)
+	}
+	if tokens.IdToken == "" {
+		return "", fmt.Errorf("%w: response has no id_token", ErrExchangeFailed)
+	}
+
+	return tokens.IdToken, nil
+}
+
+// VerifyIdToken checks the signature against the provider's JWKS, the issuer,
+// the audience, the lifetime and the nonce sent in the authorization request
+func (p *Provider) VerifyIdToken(ctx context.Context, raw, nonce string) (Claims, error) {
+	meta, err := p.metadata(ctx)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	keys, err := p.keySet(ctx, raw)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	token, err := jwt.ParseString(
+		raw,
+		jwt.WithKeySet(keys, jws.WithInferAlgorithmFromKey(true)),
+		jwt.WithValidate(true),
+		jwt.WithIssuer(meta.Issuer),
+		jwt.WithAudience(p.config.ClientId),
+		jwt.WithAcceptableSkew(clockSkew),
+		jwt.WithRequiredClaim("nonce"),
+	)
+	if err != nil {
+		return Claims{}, fmt.Errorf("%w: %s", ErrInvalidIdToken, err)
+	}
+
+	private := token.PrivateClaims()
+	if n, _ := private["nonce"].(string); n != nonce {
+		return Claims{}, fmt.Errorf("%w: nonce mismatch", ErrInvalidIdToken)
+	}
+	if token.Subject() == "" {
+		return Claims{}, fmt.Errorf("%w: sub is missing", ErrInvalidIdToken)
+	}
+
+	c := Claims{Subject: token.Subject()}
+	c.Email, _ = private["email"].(string)
+	c.GivenName, _ = private["given_name"].(string)
+	c.FamilyName, _ = private["family_name"].(string)
+	// some providers send email_verified as a string
+	switch v := private["email_verified"].(type) {
+	case bool:
+		c.EmailVerified = v
+	case string:
+		c.EmailVerified = v == "true"
+	}
+
+	return c, nil
+}
+
+func (p *Provider) metadata(ctx context.Context) (*metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.meta != nil {
+		return p.meta, nil
+	}
+
+	var meta metadata
+	err := p.getJson(ctx, strings.TrimSuffix(p.config.Issuer, "/")+"/.well-known/openid-configuration", &meta)
+	if err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+	// the issuer in the document must be exactly the configured one (OpenID Connect Discovery 4.3)
+	if meta.Issuer != p.config.Issuer {
+		return nil, fmt.Errorf("oidc discovery: issuer %q doesn't match %q", meta.Issuer, p.config.Issuer)
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JwksUri == "" {
+		return nil, errors.New("oidc discovery: required endpoints are missing")
+	}
+
+	p.meta = &meta
+	return p.meta, nil
+}
+
+// keySet returns the cached JWKS and reloads it when the token is signed
+// with an unknown key, which happens after the provider rotates its keys
+func (p *Provider) keySet(ctx context.Context, raw string) (jwk.Set, error) {
+	msg, err := jws.ParseString(raw)
+	if err != nil || len(msg.Signatures()) != 1 {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidIdToken)
+	}
+	kid := msg.Signatures()[0].ProtectedHeaders().KeyID()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.keys != nil {
+		if _, found := p.keys.LookupKeyID(kid); found {
+			return p.keys, nil
+		}
+	}
+
+	resp, err := p.get(ctx, p.meta.JwksUri)
+	if err != nil {
+		return nil, fmt.Errorf("oidc jwks: %w", err)
+	}
+
+	keys, err := jwk.Parse(resp)
+	if err != nil {
+		return nil, fmt.Errorf("oidc jwks: %w", err)
+	}
+
+	p.keys = keys
+	return p.keys, nil
+}
+
+func (p *Provider) getJson(ctx context.Context, url string, v interface{}) error {
+	body, err := p.get(ctx, url)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
+
+func (p *Provider) get(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+// CodeChallenge derives the S256 PKCE challenge from the verifier
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
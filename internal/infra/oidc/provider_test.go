@@ -0,0 +1,260 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+)
+
+const (
+	testClientId     = "todo-app"
+	testClientSecret = "client-secret"
+	testRedirectUrl  = "http://localhost:3000/oidc/callback"
+)
+
+// mockProvider is a minimal OpenID provider: discovery, JWKS and a token
+// endpoint which checks PKCE for the codes issued by authorize
+type mockProvider struct {
+	t      *testing.T
+	server *httptest.Server
+	key    jwk.Key
+
+	mu    sync.Mutex
+	codes map[string]authorization
+	// audience overrides the aud claim of issued tokens when set
+	audience string
+}
+
+type authorization struct {
+	challenge string
+	nonce     string
+}
+
+func newMockProvider(t *testing.T) *mockProvider {
+	m := &mockProvider{t: t, key: newSigningKey(t, "key-1"), codes: map[string]authorization{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJson(w, map[string]string{
+			"issuer":                 m.server.URL,
+			"authorization_endpoint": m.server.URL + "/authorize",
+			"token_endpoint":         m.server.URL + "/token",
+			"jwks_uri":               m.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		set := jwk.NewSet()
+		_ = set.AddKey(m.key)
+		public, err := jwk.PublicSetOf(set)
+		if err != nil {
+			t.Errorf("public jwks: %s", err)
+		}
+		writeJson(w, public)
+	})
+	mux.HandleFunc("/token", m.token)
+
+	m.server = httptest.NewServer(mux)
+	t.Cleanup(m.server.Close)
+	return m
+}
+
+// authorize plays the login page: it reads the authorization URL the way the
+// provider would and returns the code the browser is redirected back with
+func (m *mockProvider) authorize(authUrl string) (code, state string) {
+	u, err := url.Parse(authUrl)
+	if err != nil {
+		m.t.Fatalf("authorization url: %s", err)
+	}
+	q := u.Query()
+	if q.Get("client_id") != testClientId || q.Get("redirect_uri") != testRedirectUrl || q.Get("code_challenge_method") != "S256" {
+		m.t.Fatalf("unexpected authorization request: %s", authUrl)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	code = "code-" + q.Get("state")
+	m.codes[code] = authorization{challenge: q.Get("code_challenge"), nonce: q.Get("nonce")}
+	return code, q.Get("state")
+}
+
+func (m *mockProvider) token(w http.ResponseWriter, r *http.Request) {
+	id, secret, ok := r.BasicAuth()
+	if !ok || id != testClientId || secret != testClientSecret {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	m.mu.Lock()
+	a, found := m.codes[r.PostForm.Get("code")]
+	delete(m.codes, r.PostForm.Get("code"))
+	m.mu.Unlock()
+
+	if !found || CodeChallenge(r.PostForm.Get("code_verifier")) != a.challenge {
+		w.WriteHeader(http.StatusBadRequest)
+		writeJson(w, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	writeJson(w, map[string]string{"id_token": m.idToken(m.key, a.nonce)})
+}
+
+func (m *mockProvider) idToken(key jwk.Key, nonce string) string {
+	audience := testClientId
+	if m.audience != "" {
+		audience = m.audience
+	}
+
+	token, err := jwt.NewBuilder().
+		Issuer(m.server.URL).
+		Subject("subject-1").
+		Audience([]string{audience}).
+		IssuedAt(time.Now()).
+		Expiration(time.Now().Add(5*time.Minute)).
+		Claim("nonce", nonce).
+		Claim("email", "jane@example.com").
+		Claim("email_verified", true).
+		Claim("given_name", "Jane").
+		Claim("family_name", "Doe").
+		Build()
+	if err != nil {
+		m.t.Fatalf("build id token: %s", err)
+	}
+
+	signed, err := jwt.Sign(token, jwt.WithKey(jwa.RS256, key))
+	if err != nil {
+		m.t.Fatalf("sign id token: %s", err)
+	}
+	return string(signed)
+}
+
+func (m *mockProvider) provider() *Provider {
+	return NewProvider(Config{
+		Issuer:       m.server.URL,
+		ClientId:     testClientId,
+		ClientSecret: testClientSecret,
+		RedirectUrl:  testRedirectUrl,
+		Scopes:       []string{"openid", "email", "profile"},
+	}, m.server.Client())
+}
+
+func newSigningKey(t *testing.T, kid string) jwk.Key {
+	raw, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %s", err)
+	}
+	key, err := jwk.FromRaw(raw)
+	if err != nil {
+		t.Fatalf("jwk: %s", err)
+	}
+	_ = key.Set(jwk.KeyIDKey, kid)
+	_ = key.Set(jwk.AlgorithmKey, jwa.RS256)
+	return key
+}
+
+func writeJson(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func TestProviderCodeFlow(t *testing.T) {
+	m := newMockProvider(t)
+	p := m.provider()
+	ctx := context.Background()
+
+	authUrl, err := p.AuthCodeUrl(ctx, "state-1", "nonce-1", CodeChallenge("verifier-1"))
+	if err != nil {
+		t.Fatalf("AuthCodeUrl: %s", err)
+	}
+	code, state := m.authorize(authUrl)
+	if state != "state-1" {
+		t.Fatalf("state = %q, want state-1", state)
+	}
+
+	raw, err := p.Exchange(ctx, code, "verifier-1")
+	if err != nil {
+		t.Fatalf("Exchange: %s", err)
+	}
+	claims, err := p.VerifyIdToken(ctx, raw, "nonce-1")
+	if err != nil {
+		t.Fatalf("VerifyIdToken: %s", err)
+	}
+
+	want := Claims{Subject: "subject-1", Email: "jane@example.com", EmailVerified: true, GivenName: "Jane", FamilyName: "Doe"}
+	if claims != want {
+		t.Errorf("claims = %+v, want %+v", claims, want)
+	}
+}
+
+func TestProviderRejectsWrongCodeVerifier(t *testing.T) {
+	m := newMockProvider(t)
+	p := m.provider()
+	ctx := context.Background()
+
+	authUrl, err := p.AuthCodeUrl(ctx, "state-1", "nonce-1", CodeChallenge("verifier-1"))
+	if err != nil {
+		t.Fatalf("AuthCodeUrl: %s", err)
+	}
+	code, _ := m.authorize(authUrl)
+
+	_, err = p.Exchange(ctx, code, "stolen-code-without-verifier")
+	if !errors.Is(err, ErrExchangeFailed) {
+		t.Fatalf("err = %v, want %v", err, ErrExchangeFailed)
+	}
+}
+
+func TestProviderRejectsInvalidIdTokens(t *testing.T) {
+	m := newMockProvider(t)
+	p := m.provider()
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		raw   func() string
+		nonce string
+	}{
+		{
+			name:  "nonce of another login",
+			raw:   func() string { return m.idToken(m.key, "nonce-1") },
+			nonce: "nonce-2",
+		},
+		{
+			name:  "signed with a foreign key",
+			raw:   func() string { return m.idToken(newSigningKey(t, "key-1"), "nonce-1") },
+			nonce: "nonce-1",
+		},
+		{
+			name: "issued to another client",
+			raw: func() string {
+				m.audience = "other-app"
+				defer func() { m.audience = "" }()
+				return m.idToken(m.key, "nonce-1")
+			},
+			nonce: "nonce-1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := p.VerifyIdToken(ctx, tt.raw(), tt.nonce)
+			if !errors.Is(err, ErrInvalidIdToken) {
+				t.Fatalf("err = %v, want %v", err, ErrInvalidIdToken)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit is a token bucket which holds Requests tokens and refills all of them in Per
+type Limit struct {
+	Requests int
+	Per      time.Duration
+}
+
+// ParseLimit reads limits like "60/1m" or "5/10s"
+func ParseLimit(s string) (Limit, error) {
+	requests, per, found := strings.Cut(s, "/")
+	if !found {
+		return Limit{}, fmt.Errorf("invalid rate limit %q (use requests/duration, e.g. 60/1m)", s)
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(requests))
+	if err != nil || n <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: requests must be a positive integer", s)
+	}
+
+	d, err := time.ParseDuration(strings.TrimSpace(per))
+	if err != nil || d <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: duration must be positive", s)
+	}
+
+	return Limit{Requests: n, Per: d}, nil
+}
+
+// tokensPerSecond is the refill rate
+func (l Limit) tokensPerSecond() float64 {
+	return float64(l.Requests) / l.Per.Seconds()
+}
+
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is the time until the bucket is full again
+	Reset time.Duration
+	// RetryAfter is the time until the next token, zero when the request is allowed
+	RetryAfter time.Duration
+}
+
+// Store keeps the buckets, the key is usually "group:user:<id>" or "group:ip:<address>"
+type Store interface {
+	Take(key string, limit Limit) (Result, error)
+	// DeleteStale forgets buckets which are full, they are the same as missing ones
+	DeleteStale() error
+}
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+	fullAt    time.Time
+}
+
+// take refills the bucket for the time passed since the last request and tries to spend one token
+func take(b *bucket, limit Limit, now time.Time) Result {
+	rate := limit.tokensPerSecond()
+	capacity := float64(limit.Requests)
+
+	tokens := math.Min(capacity, b.tokens+now.Sub(b.updatedAt).Seconds()*rate)
+
+	res := Result{Limit: limit.Requests}
+	if tokens >= 1 {
+		tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = seconds((1 - tokens) / rate)
+	}
+
+	b.tokens = tokens
+	b.updatedAt = now
+	res.Reset = seconds((capacity - tokens) / rate)
+	b.fullAt = now.Add(res.Reset)
+	res.Remaining = int(math.Floor(tokens))
+
+	return res
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
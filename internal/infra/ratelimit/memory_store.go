@@ -0,0 +1,45 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+type memoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+// NewMemoryStore keeps the buckets in the process, so every instance counts on its own
+func NewMemoryStore() Store {
+	return &memoryStore{
+		buckets: make(map[string]*bucket),
+	}
+}
+
+func (s *memoryStore) Take(key string, limit Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Requests), updatedAt: time.Now()}
+		s.buckets[key] = b
+	}
+
+	return take(b, limit, time.Now()), nil
+}
+
+func (s *memoryStore) DeleteStale() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for key, b := range s.buckets {
+		if b.fullAt.Before(now) {
+			delete(s.buckets, key)
+		}
+	}
+
+	return nil
+}
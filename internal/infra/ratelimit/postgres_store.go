@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"time"
+
+	"github.com/upper/db/v4"
+)
+
+const BucketsTableName = "rate_limit_buckets"
+
+type postgresStore struct {
+	sess db.Session
+}
+
+// NewPostgresStore shares the buckets between all instances using the same database
+func NewPostgresStore(sess db.Session) Store {
+	return postgresStore{
+		sess: sess,
+	}
+}
+
+// Take locks the bucket row, so concurrent requests from several instances are applied one by one.
+// The database clock is used because the clocks of the instances may differ. It is read with
+// clock_timestamp() after the lock is taken, now() is the start of the transaction and would be
+// older than the updated_at of a transaction which held the lock meanwhile
+func (s postgresStore) Take(key string, limit Limit) (Result, error) {
+	var res Result
+	err := s.sess.Tx(func(tx db.Session) error {
+		_, err := tx.SQL().Exec(`
+			INSERT INTO `+BucketsTableName+` (bucket_key, tokens, updated_at, full_at)
+			VALUES (?, ?, clock_timestamp(), clock_timestamp())
+			ON CONFLICT (bucket_key) DO NOTHING`,
+			key, limit.Requests,
+		)
+		if err != nil {
+			return err
+		}
+
+		row, err := tx.SQL().QueryRow(`
+			SELECT tokens, updated_at, clock_timestamp() FROM `+BucketsTableName+`
+			WHERE bucket_key = ? FOR UPDATE`,
+			key,
+		)
+		if err != nil {
+			return err
+		}
+
+		var b bucket
+		var now time.Time
+		err = row.Scan(&b.tokens, &b.updatedAt, &now)
+		if err != nil {
+			return err
+		}
+
+		res = take(&b, limit, now)
+
+		_, err = tx.SQL().
+			Update(BucketsTableName).
+			Set("tokens", b.tokens, "updated_at", b.updatedAt, "full_at", b.fullAt).
+			Where(db.Cond{"bucket_key": key}).
+			Exec()
+		return err
+	})
+	if err != nil {
+		return Result{}, err
+	}
+
+	return res, nil
+}
+
+func (s postgresStore) DeleteStale() error {
+	_, err := s.sess.SQL().
+		DeleteFrom(BucketsTableName).
+		Where(db.Cond{"full_at <": db.Raw("now()")}).
+		Exec()
+	return err
+}
@@ -0,0 +1,31 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Every runs the job once per interval until the context is cancelled,
+// a non-positive interval disables the job instead of panicking in the goroutine
+func Every(ctx context.Context, interval time.Duration, name string, job func() error) {
+	if interval <= 0 {
+		log.Printf("Scheduler: %s is disabled, interval %s is not positive", name, interval)
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := job()
+			if err != nil {
+				log.Printf("Scheduler: %s failed: %s", name, err)
+			}
+		}
+	}
+}
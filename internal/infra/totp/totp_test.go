@@ -0,0 +1,113 @@
+package totp
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+// the SHA1 seed of RFC 6238 appendix B
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c fakeClock) Now() time.Time {
+	return c.now
+}
+
+// the vectors of RFC 6238 appendix B are 8 digits long,
+// a 6 digit code is the same value modulo 10^6, i.e. its last 6 digits
+var rfcVectors = []struct {
+	unix int64
+	code string
+}{
+	{59, "287082"},
+	{1111111109, "081804"},
+	{1111111111, "050471"},
+	{1234567890, "005924"},
+	{2000000000, "279037"},
+	{20000000000, "353130"},
+}
+
+func TestCodeMatchesRfc6238Vectors(t *testing.T) {
+	g := NewGenerator(SystemClock())
+
+	for _, v := range rfcVectors {
+		code, err := g.Code(rfcSecret, time.Unix(v.unix, 0))
+		if err != nil {
+			t.Fatalf("Code(%d): %s", v.unix, err)
+		}
+		if code != v.code {
+			t.Errorf("Code(%d) = %s, want %s", v.unix, code, v.code)
+		}
+	}
+}
+
+func TestValidateAcceptsRfc6238VectorsAtTheirTime(t *testing.T) {
+	for _, v := range rfcVectors {
+		g := NewGenerator(fakeClock{now: time.Unix(v.unix, 0)})
+
+		step, valid := g.Validate(rfcSecret, v.code)
+		if !valid {
+			t.Errorf("Validate(%d, %s) rejected a valid code", v.unix, v.code)
+			continue
+		}
+		if want := v.unix / period; step != want {
+			t.Errorf("Validate(%d, %s) step = %d, want %d", v.unix, v.code, step, want)
+		}
+	}
+}
+
+func TestValidateAllowsOnePeriodOfDrift(t *testing.T) {
+	// the server clock is moved by whole periods, codes of the neighbouring steps are still accepted
+	issued := time.Unix(1111111111, 0)
+	g := NewGenerator(SystemClock())
+	code, err := g.Code(rfcSecret, issued)
+	if err != nil {
+		t.Fatalf("Code: %s", err)
+	}
+
+	tests := []struct {
+		name  string
+		drift time.Duration
+		valid bool
+	}{
+		{"device ahead by two periods", -2 * period * time.Second, false},
+		{"device ahead by one period", -period * time.Second, true},
+		{"same period", 0, true},
+		{"device behind by one period", period * time.Second, true},
+		{"device behind by two periods", 2 * period * time.Second, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGenerator(fakeClock{now: issued.Add(tt.drift)})
+
+			step, valid := g.Validate(rfcSecret, code)
+			if valid != tt.valid {
+				t.Fatalf("valid = %t, want %t", valid, tt.valid)
+			}
+			// the step is the one of the code, not of the server clock, so replay protection holds across the window
+			if valid && step != issued.Unix()/period {
+				t.Errorf("step = %d, want %d", step, issued.Unix()/period)
+			}
+		})
+	}
+}
+
+func TestValidateRejectsMalformedInput(t *testing.T) {
+	g := NewGenerator(fakeClock{now: time.Unix(59, 0)})
+
+	for _, tc := range []struct{ secret, code string }{
+		{rfcSecret, "28708"},
+		{rfcSecret, "2870820"},
+		{rfcSecret, "abcdef"},
+		{"not base32!", "287082"},
+	} {
+		if _, valid := g.Validate(tc.secret, tc.code); valid {
+			t.Errorf("Validate(%q, %q) accepted malformed input", tc.secret, tc.code)
+		}
+	}
+}